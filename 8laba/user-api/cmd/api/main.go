@@ -1,79 +1,539 @@
 package main
 
 import (
+	"context"
 	_ "embed"
+	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+	"user-api/docs"
+	"user-api/internal/blob"
+	"user-api/internal/cache"
+	"user-api/internal/config"
 	"user-api/internal/database"
+	"user-api/internal/events"
+	"user-api/internal/feed"
+	"user-api/internal/graphqlapi"
+	"user-api/internal/grpcapi"
 	"user-api/internal/handlers"
+	"user-api/internal/i18n"
+	"user-api/internal/mailer"
 	"user-api/internal/middleware"
+	"user-api/internal/outbox"
 	"user-api/internal/repository"
 	"user-api/internal/service"
+	"user-api/internal/tenant"
+	"user-api/internal/validation"
+	"user-api/internal/verification"
+	"user-api/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	"github.com/joho/godotenv"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+	"google.golang.org/grpc"
 )
 
 //go:embed static/index.html
 var indexHTML string
 
+// @title User API
+// @version 1.0
+// @description REST API для управления пользователями
+// @BasePath /api/v1
 func main() {
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
-	dbConfig := database.GetConfigFromEnv()
-	db, err := database.NewPostgresDB(dbConfig)
+	cfg, printConfig, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if printConfig {
+		fmt.Print(cfg)
+		return
+	}
+
+	setupLogging(cfg.Log)
+
+	// Чтение пользователей идет в реплики, если они заданы; остальные
+	// репозитории работают с основной БД
+	dbConfig := cfg.Database.DB()
+	dbConfig.RequireRLS = cfg.Tenancy.Enabled
+	cluster, err := database.NewPostgresCluster(dbConfig)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
+	defer cluster.Close()
+	db := cluster.Primary()
+
+	// Разделение арендаторов держится на row-level security, которую
+	// суперпользователь и роли с BYPASSRLS не соблюдают. Роли реплик
+	// проверяются при каждой проверке реплик (RequireRLS).
+	if cfg.Tenancy.Enabled {
+		bypass, err := database.BypassesRLS(context.Background(), db)
+		if err != nil {
+			log.Fatalf("Failed to check database role: %v", err)
+		}
+		if bypass {
+			log.Fatalf("Tenancy requires a database role without SUPERUSER and BYPASSRLS, got %q", cfg.Database.User)
+		}
+	}
+
+	// Токен из письма (подтверждение email, сброс пароля) сам определяет
+	// пользователя любого арендатора, поэтому такие операции идут под
+	// отдельной ролью с BYPASSRLS. Роль приложения обойти политики не
+	// может, даже если выполнит произвольный SQL.
+	systemDB := db
+	if cfg.Database.System.User != "" {
+		systemDB, err = database.NewPostgresDB(cfg.Database.SystemDB())
+		if err != nil {
+			log.Fatalf("Failed to connect to database as system role: %v", err)
+		}
+		defer systemDB.Close()
+	}
+	if cfg.Verification.Enabled || cfg.PasswordReset.Enabled {
+		bypass, err := database.BypassesRLS(context.Background(), systemDB)
+		if err != nil {
+			log.Fatalf("Failed to check database role: %v", err)
+		}
+		if !bypass {
+			log.Fatalf("Email verification and password reset require database.system.user with BYPASSRLS")
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if len(cfg.Database.Replicas.DSNs) > 0 {
+		go cluster.Run(ctx, cfg.Database.Replicas.CheckInterval.Std())
+	}
+
+	// Лента для SSE читает опубликованные события из outbox, поэтому на
+	// всех экземплярах одинакова. Закрывается при остановке, чтобы
+	// открытые потоки не задерживали завершение сервера.
+	outboxRepo := repository.NewOutboxRepository(db)
+	userFeed := feed.New(cfg.SSE.LogSize, cfg.SSE.ClientBuffer, outbox.NewHistory(outboxRepo))
+	go userFeed.Follow(ctx, cfg.Outbox.PollInterval.Std())
+	if cfg.Outbox.Listen {
+		go outbox.Listen(ctx, cfg.Database.DB().DSN(), repository.OutboxPublishedChannel, userFeed.Notify)
+	}
+	go func() {
+		<-ctx.Done()
+		userFeed.Close()
+	}()
+
+	var mail mailer.Mailer
+	if cfg.Verification.Enabled || cfg.PasswordReset.Enabled {
+		mail, err = newMailer(cfg.Mailer)
+		if err != nil {
+			log.Fatalf("Failed to create mailer: %v", err)
+		}
+		if closer, ok := mail.(io.Closer); ok {
+			defer closer.Close()
+		}
+	}
+
+	// Подтверждение email: письма со ссылкой отправляются в фоне при
+	// создании пользователя и смене адреса и дожидаются завершения при
+	// остановке
+	var verifier *service.EmailVerifier
+	if cfg.Verification.Enabled {
+		verifier = service.NewEmailVerifier(
+			repository.NewVerificationRepository(db, systemDB),
+			verification.NewSigner(cfg.Verification.Secret),
+			mail,
+			service.EmailVerifierConfig{
+				TokenTTL:    cfg.Verification.TokenTTL.Std(),
+				VerifyURL:   cfg.Verification.URL,
+				SendTimeout: cfg.Mailer.Timeout.Std(),
+			},
+		)
+		defer verifier.Wait()
+		go verifier.RunCleanup(ctx, time.Hour)
+	}
 
-	userRepo := repository.NewUserRepository(db)
-	userService := service.NewUserService(userRepo)
+	// Восстановление пароля; письма, отправка которых началась до остановки,
+	// дожидаются завершения
+	var passwordService *service.PasswordResetService
+	if cfg.PasswordReset.Enabled {
+		passwordService = service.NewPasswordResetService(repository.NewPasswordRepository(db, systemDB), mail, service.PasswordResetConfig{
+			TokenTTL:    cfg.PasswordReset.TokenTTL.Std(),
+			ResetURL:    cfg.PasswordReset.URL,
+			SendTimeout: cfg.Mailer.Timeout.Std(),
+			EmailLimit:  cfg.PasswordReset.EmailLimit,
+			IPLimit:     cfg.PasswordReset.IPLimit,
+			LimitWindow: cfg.PasswordReset.LimitWindow.Std(),
+		})
+		defer passwordService.Wait()
+		go passwordService.RunCleanup(ctx, time.Hour)
+	}
+
+	// Арендатор запроса берется из access токена или, для доверенных
+	// вызывающих, из заголовка
+	tenantService := service.NewTenantManager(repository.NewTenantRepository(db), cfg.Tenancy.StatusCacheTTL.Std())
+	tenantHandler := handlers.NewTenantHandler(tenantService)
+	var jwtSecret []byte
+	if cfg.Auth.Enabled {
+		jwtSecret = []byte(cfg.Auth.JWTSecret)
+	}
+	// Сброс пароля отзывает access токены, выданные до него
+	revocations := service.NewTokenRevocations(repository.NewPasswordRepository(db, systemDB))
+	resolver, err := tenant.NewResolver(cfg.Tenancy.Enabled, jwtSecret, cfg.Tenancy.TrustedNetworks, tenantService, revocations)
+	if err != nil {
+		log.Fatalf("Failed to configure tenancy: %v", err)
+	}
+	tenantScoped := middleware.Tenant(resolver, cfg.Tenancy.Header)
+
+	disposable, err := validation.ReadDomainsFile(cfg.Validation.DisposableDomainsFile)
+	if err != nil {
+		log.Fatalf("Failed to read disposable domains: %v", err)
+	}
+	emailRules, err := validation.NewEmailRules(cfg.Validation.BlockDisposable, disposable,
+		cfg.Validation.BlockedDomains, cfg.Validation.BlockedEmails)
+	if err != nil {
+		log.Fatalf("Failed to configure email rules: %v", err)
+	}
+
+	userRepo := repository.NewUserRepository(cluster)
+	metadataService := service.NewMetadataService(repository.NewMetadataSchemaRepository(db))
+	metadataHandler := handlers.NewMetadataHandler(metadataService)
+	userService := service.NewUserService(userRepo, verifier, metadataService)
+	var userCache *service.CachedUserService
+	if cfg.Cache.Enabled {
+		userCache = service.NewCachedUserService(userService, cache.NewLRU(cfg.Cache.Size, cfg.Cache.TTL.Std()))
+		userService = userCache
+	}
+	// Статистика считается агрегатами по всем пользователям, поэтому
+	// кэшируется независимо от кэша пользователей
+	if ttl := cfg.Stats.CacheTTL.Std(); ttl > 0 {
+		userService = service.NewCachedUserStats(userService, ttl)
+	}
 	userHandler := handlers.NewUserHandler(userService)
+	userEventsHandler := handlers.NewUserEventsHandler(userFeed, cfg.SSE.Heartbeat.Std())
+
+	store, err := newBlobStore(cfg.Blob)
+	if err != nil {
+		log.Fatalf("Failed to create blob store: %v", err)
+	}
+	var invalidator service.UserInvalidator
+	if userCache != nil {
+		invalidator = userCache
+	}
+	avatarService := service.NewAvatarStore(userRepo, store, invalidator)
+	avatarHandler := handlers.NewAvatarHandler(avatarService, cfg.Avatar.MaxSize)
+
+	groupService := service.NewGroupService(repository.NewGroupRepository(db))
+	groupHandler := handlers.NewGroupHandler(groupService)
+
+	webhookRepo := repository.NewWebhookRepository(db)
+	var dispatcher *webhooks.Dispatcher
+	if cfg.Webhooks.Enabled {
+		dispatcher = webhooks.NewDispatcher(webhookRepo, webhooks.Config{
+			Workers:      cfg.Webhooks.Workers,
+			BatchSize:    cfg.Webhooks.BatchSize,
+			PollInterval: cfg.Webhooks.PollInterval.Std(),
+			Timeout:      cfg.Webhooks.Timeout.Std(),
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			BackoffBase:  cfg.Webhooks.BackoffBase.Std(),
+			BackoffMax:   cfg.Webhooks.BackoffMax.Std(),
+		})
+		go dispatcher.Run(ctx)
+	}
+
+	// События пользователей записываются в outbox вместе с изменениями.
+	// Relay отмечает событие опубликованным, только когда получатели его
+	// сохранили: доставки вебхуков - в БД, файлы аватаров удалены, событие
+	// записано в файл. Лента SSE читает опубликованные события сама.
+	publishers := []events.EventPublisher{avatarService}
+	if dispatcher != nil {
+		publishers = append(publishers, dispatcher)
+	}
+	if cfg.Outbox.File != "" {
+		file, err := events.NewFilePublisher(cfg.Outbox.File)
+		if err != nil {
+			log.Fatalf("Failed to open outbox file: %v", err)
+		}
+		defer file.Close()
+		publishers = append(publishers, file)
+	}
+
+	if cfg.Outbox.RelayEnabled {
+		relay := outbox.NewRelay(outboxRepo, events.Multi(publishers...), outbox.Config{
+			BatchSize:       cfg.Outbox.BatchSize,
+			PollInterval:    cfg.Outbox.PollInterval.Std(),
+			Retention:       cfg.Outbox.Retention.Std(),
+			CleanupInterval: cfg.Outbox.CleanupInterval.Std(),
+		})
+		go relay.Run(ctx)
+		if cfg.Outbox.Listen {
+			go relay.Listen(ctx, cfg.Database.DB().DSN())
+		}
+	}
+	var notifier service.DeliveryNotifier
+	if dispatcher != nil {
+		notifier = dispatcher
+	}
+	webhookService := service.NewWebhookService(webhookRepo, notifier)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+
+	// Правила для email (теги not_disposable и not_blocked) и переводы
+	// сообщений об ошибках (Accept-Language)
+	ginValidator := binding.Validator.Engine().(*validator.Validate)
+	if err := validation.Register(ginValidator, emailRules); err != nil {
+		log.Fatalf("Failed to register email rules: %v", err)
+	}
+	translator, err := i18n.New()
+	if err != nil {
+		log.Fatalf("Failed to load translations: %v", err)
+	}
+	if err := translator.RegisterValidator(ginValidator); err != nil {
+		log.Fatalf("Failed to register validation translations: %v", err)
+	}
 
 	router := gin.Default()
+	// Без списка прокси gin доверяет X-Forwarded-For от любого клиента, и
+	// лимиты по IP обходились бы подменой заголовка
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("Failed to set trusted proxies: %v", err)
+	}
 
 	router.Use(middleware.Logger())
 	router.Use(middleware.ErrorHandler())
 	router.Use(middleware.CORS())
+	router.Use(middleware.Localize(translator))
+	router.Use(middleware.DatabaseSession())
 
 	// ГЛАВНАЯ СТРАНИЦА ИЗ ФАЙЛА static/index.html
 	router.GET("/", func(c *gin.Context) {
 		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(indexHTML))
 	})
 
-	// API Routes
+	// Спецификация OpenAPI и Swagger UI
+	spec, err := docs.OpenAPI()
+	if err != nil {
+		log.Fatalf("Failed to load OpenAPI spec: %v", err)
+	}
+	specValidator, err := middleware.OpenAPIValidator(spec)
+	if err != nil {
+		log.Fatalf("Failed to create request validator: %v", err)
+	}
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.JSON(http.StatusOK, spec)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/docs/index.html")
+	})
+	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.URL("/openapi.json")))
+
+	// Файлы аватаров при хранении на локальном диске
+	if cfg.Blob.Driver == "local" && strings.HasPrefix(cfg.Blob.BaseURL, "/") {
+		router.Static(cfg.Blob.BaseURL, cfg.Blob.Dir)
+	}
+
+	// API Routes. Маршруты делятся на три части: данные арендатора
+	// (scoped), запросы по одноразовым токенам из писем (public) и
+	// администрирование, общее для всех арендаторов (trusted).
 	api := router.Group("/api/v1")
+	api.Use(specValidator)
+	scoped := api.Group("", tenantScoped)
+	public := api.Group("")
+	trusted := api.Group("", middleware.TrustedOnly(resolver))
+	if cfg.Idempotency.Enabled {
+		idempotencyRepo := repository.NewIdempotencyRepository(db)
+		// Idempotency идет после Tenant, чтобы ключи разных арендаторов
+		// не пересекались
+		idempotency := middleware.Idempotency(idempotencyRepo, middleware.IdempotencyConfig{
+			TTL:         cfg.Idempotency.TTL.Std(),
+			LockTimeout: cfg.Idempotency.LockTimeout.Std(),
+			MaxBodySize: cfg.Idempotency.MaxBodySize,
+		})
+		scoped.Use(idempotency)
+		public.Use(idempotency)
+		trusted.Use(idempotency)
+		go middleware.CleanupIdempotencyKeys(ctx, idempotencyRepo, time.Hour)
+	}
 	{
-		users := api.Group("/users")
+		public.GET("/verify-email", userHandler.VerifyEmailPage)
+		public.POST("/verify-email", userHandler.VerifyEmail)
+
+		users := scoped.Group("/users")
 		{
 			users.GET("", userHandler.GetUsers)
+			users.GET("/events", userEventsHandler.StreamUserEvents)
+			users.GET("/stats", middleware.CacheControl(cfg.Stats.CacheTTL.Std()), userHandler.GetUserStats)
 			users.GET("/:id", userHandler.GetUser)
 			users.POST("", userHandler.CreateUser)
 			users.PUT("/:id", userHandler.UpdateUser)
 			users.DELETE("/:id", userHandler.DeleteUser)
+			users.POST("/:id/verification", userHandler.ResendVerification)
+			users.PUT("/:id/avatar", avatarHandler.UploadAvatar)
+			users.DELETE("/:id/avatar", avatarHandler.DeleteAvatar)
+		}
+
+		if passwordService != nil {
+			authHandler := handlers.NewAuthHandler(passwordService)
+			public.POST("/auth/password/forgot", authHandler.ForgotPassword)
+			public.POST("/auth/password/reset", authHandler.ResetPassword)
+		}
+
+		groups := scoped.Group("/groups")
+		{
+			groups.GET("", groupHandler.GetGroups)
+			groups.GET("/:id", groupHandler.GetGroup)
+			groups.POST("", groupHandler.CreateGroup)
+			groups.PUT("/:id", groupHandler.UpdateGroup)
+			groups.DELETE("/:id", groupHandler.DeleteGroup)
+			groups.GET("/:id/members", groupHandler.GetMembers)
+			groups.POST("/:id/members", groupHandler.AddMember)
+			groups.PUT("/:id/members/:user_id", groupHandler.UpdateMember)
+			groups.DELETE("/:id/members/:user_id", groupHandler.RemoveMember)
+		}
+
+		admin := trusted.Group("/admin")
+		{
+			admin.GET("/tenants", tenantHandler.GetTenants)
+			admin.GET("/tenants/:id", tenantHandler.GetTenant)
+			admin.POST("/tenants", tenantHandler.CreateTenant)
+			admin.POST("/tenants/:id/suspend", tenantHandler.SuspendTenant)
+			admin.POST("/tenants/:id/activate", tenantHandler.ActivateTenant)
+
+			admin.GET("/metadata/schemas", metadataHandler.GetSchemas)
+			admin.GET("/metadata/schemas/:key", metadataHandler.GetSchema)
+			admin.PUT("/metadata/schemas/:key", metadataHandler.PutSchema)
+			admin.DELETE("/metadata/schemas/:key", metadataHandler.DeleteSchema)
+		}
+
+		// Вебхуки получают события всех арендаторов
+		hooks := trusted.Group("/webhooks")
+		{
+			hooks.GET("", webhookHandler.GetWebhooks)
+			hooks.GET("/:id", webhookHandler.GetWebhook)
+			hooks.POST("", webhookHandler.CreateWebhook)
+			hooks.PUT("/:id", webhookHandler.UpdateWebhook)
+			hooks.DELETE("/:id", webhookHandler.DeleteWebhook)
+			hooks.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+			hooks.POST("/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+		}
+	}
+
+	// GraphQL
+	if cfg.GraphQL.Enabled {
+		schema, err := graphqlapi.NewSchema(userService, emailRules)
+		if err != nil {
+			log.Fatalf("Failed to build GraphQL schema: %v", err)
+		}
+		graphqlHandler := graphqlapi.NewHandler(schema, graphqlapi.Limits{
+			MaxDepth:      cfg.GraphQL.MaxDepth,
+			MaxComplexity: cfg.GraphQL.MaxComplexity,
+		})
+		router.GET("/graphql", tenantScoped, graphqlHandler.Query)
+		router.POST("/graphql", tenantScoped, graphqlHandler.Query)
+		if cfg.GraphQL.Playground {
+			router.GET("/graphiql", graphqlHandler.Playground)
 		}
 	}
 
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "ok"})
+		if len(cfg.Database.Replicas.DSNs) == 0 {
+			c.JSON(200, gin.H{"status": "ok"})
+			return
+		}
+		c.JSON(200, gin.H{"status": "ok", "replicas": cluster.Replicas()})
 	})
 
-	port := os.Getenv("SERVER_PORT")
-	if port == "" {
-		port = "8080"
+	// Счетчики кэша пользователей
+	if userCache != nil {
+		router.GET("/debug/cache", func(c *gin.Context) {
+			c.JSON(http.StatusOK, userCache.Stats())
+		})
 	}
 
-	log.Printf("🚀 Server starting on port %s", port)
-	log.Printf("🌐 Web interface: http://localhost:%s", port)
-	log.Printf("📡 API endpoint: http://localhost:%s/api/v1/users", port)
-	log.Printf("❤️  Health check: http://localhost:%s/health", port)
+	scheme := "http"
+	if cfg.Server.TLS.Enabled() {
+		scheme = "https"
+	}
+	base := fmt.Sprintf("%s://localhost:%d", scheme, cfg.Server.Port)
+
+	log.Printf("🚀 Server starting on port %d", cfg.Server.Port)
+	log.Printf("🌐 Web interface: %s", base)
+	log.Printf("📡 API endpoint: %s/api/v1/users", base)
+	log.Printf("📖 API docs: %s/docs", base)
+	log.Printf("❤️  Health check: %s/health", base)
+
+	var newGRPCServer func(...grpc.ServerOption) *grpc.Server
+	if cfg.Server.GRPC.Enabled {
+		newGRPCServer = func(opts ...grpc.ServerOption) *grpc.Server {
+			opts = append(opts, grpcapi.TenantInterceptors(resolver, cfg.Tenancy.Header)...)
+			return grpcapi.NewServer(userService, emailRules, cfg.Server.GRPC.Reflection,
+				append(opts, grpcapi.SessionInterceptors()...)...)
+		}
+	}
 
-	if err := router.Run("0.0.0.0:" + port); err != nil {
+	if err := runServer(ctx, cfg.Server, router, newGRPCServer); err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// newMailer создает способ отправки писем по конфигурации
+func newMailer(cfg config.MailerConfig) (mailer.Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTP.Host,
+			Port:     cfg.SMTP.Port,
+			Username: cfg.SMTP.Username,
+			Password: cfg.SMTP.Password,
+			From:     cfg.From,
+			Timeout:  cfg.Timeout.Std(),
+		}), nil
+	case "file":
+		return mailer.NewFileMailer(cfg.From, cfg.File)
+	default:
+		return mailer.NewWriterMailer(cfg.From, os.Stderr), nil
+	}
+}
+
+// newBlobStore создает хранилище файлов по конфигурации
+func newBlobStore(cfg config.BlobConfig) (blob.Store, error) {
+	if cfg.Driver == "s3" {
+		return blob.NewS3Store(blob.S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Region:    cfg.S3.Region,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKey,
+			SecretKey: cfg.S3.SecretKey,
+			PathStyle: cfg.S3.PathStyle,
+			PublicURL: cfg.S3.PublicURL,
+		}, &http.Client{Timeout: 30 * time.Second})
+	}
+	return blob.NewLocalStore(cfg.Dir, cfg.BaseURL)
+}
+
+// setupLogging настраивает стандартный логгер по конфигурации
+func setupLogging(cfg config.LogConfig) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		level = slog.LevelInfo
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	if cfg.Format == "json" {
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	} else {
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	}
+	slog.SetDefault(slog.New(handler))
+}
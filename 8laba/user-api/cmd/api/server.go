@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"user-api/internal/certs"
+	"user-api/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// runServer запускает HTTP или HTTPS сервер (и, если настроено, сервер
+// перенаправления HTTP→HTTPS и gRPC сервер) и корректно завершает их
+// после отмены ctx. newGRPCServer создает gRPC сервер с дополнительными
+// опциями; nil - gRPC выключен.
+func runServer(ctx context.Context, cfg config.ServerConfig, handler http.Handler, newGRPCServer func(...grpc.ServerOption) *grpc.Server) error {
+	var tlsConfig *tls.Config
+	if cfg.TLS.Enabled() {
+		reloader, err := certs.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		go reloader.Watch(ctx, cfg.TLS.ReloadInterval.Std())
+		tlsConfig = reloader.TLSConfig(cfg.TLS.ClientAuthType())
+	}
+
+	// На отдельном порту TLS завершает сам gRPC сервер: тогда в peer.AuthInfo
+	// вызова есть credentials.TLSInfo с проверенным сертификатом клиента
+	var grpcServer *grpc.Server
+	if newGRPCServer != nil {
+		var opts []grpc.ServerOption
+		if cfg.GRPC.Port != 0 && tlsConfig != nil {
+			opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer = newGRPCServer(opts...)
+	}
+
+	// gRPC на порту HTTP: запросы разделяются по протоколу и Content-Type
+	if grpcServer != nil && cfg.GRPC.Port == 0 {
+		handler = grpcMux(grpcServer, handler)
+	}
+
+	srv := &http.Server{
+		Addr:         cfg.Addr(),
+		Handler:      handler,
+		TLSConfig:    tlsConfig,
+		ReadTimeout:  cfg.ReadTimeout.Std(),
+		WriteTimeout: cfg.WriteTimeout.Std(),
+	}
+	if grpcServer != nil && cfg.GRPC.Port == 0 && tlsConfig == nil {
+		// gRPC без TLS требует HTTP/2 без шифрования (h2c)
+		srv.Protocols = new(http.Protocols)
+		srv.Protocols.SetHTTP1(true)
+		srv.Protocols.SetUnencryptedHTTP2(true)
+	}
+
+	servers := []*http.Server{srv}
+	errCh := make(chan error, 3)
+
+	go func() {
+		if tlsConfig != nil {
+			// Сертификаты уже заданы в TLSConfig, поэтому пути не передаются
+			errCh <- srv.ListenAndServeTLS("", "")
+			return
+		}
+		errCh <- srv.ListenAndServe()
+	}()
+
+	if tlsConfig != nil && cfg.TLS.RedirectPort != 0 {
+		redirect := &http.Server{
+			Addr:         net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.TLS.RedirectPort)),
+			Handler:      redirectToHTTPS(cfg.Port),
+			ReadTimeout:  cfg.ReadTimeout.Std(),
+			WriteTimeout: cfg.WriteTimeout.Std(),
+		}
+		servers = append(servers, redirect)
+		log.Printf("↪️  Redirecting HTTP on port %d to HTTPS", cfg.TLS.RedirectPort)
+		go func() {
+			errCh <- redirect.ListenAndServe()
+		}()
+	}
+
+	if grpcServer != nil {
+		if cfg.GRPC.Port == 0 {
+			log.Printf("🔌 gRPC API on port %d (shared with HTTP)", cfg.Port)
+		} else {
+			lis, err := net.Listen("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.GRPC.Port)))
+			if err != nil {
+				return fmt.Errorf("failed to listen for gRPC: %w", err)
+			}
+			log.Printf("🔌 gRPC API on port %d", cfg.GRPC.Port)
+			go func() {
+				errCh <- grpcServer.Serve(lis)
+			}()
+		}
+	}
+
+	select {
+	case err := <-errCh:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+	case <-ctx.Done():
+	}
+
+	log.Println("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout.Std())
+	defer cancel()
+
+	if grpcServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcServer.Stop()
+		}
+	}
+	for _, s := range servers {
+		if err := s.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Server forced to shutdown: %v", err)
+		}
+	}
+	return nil
+}
+
+// grpcMux направляет gRPC запросы в grpcServer, остальные - в handler
+func grpcMux(grpcServer *grpc.Server, handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// redirectToHTTPS перенаправляет все запросы на тот же адрес по HTTPS
+func redirectToHTTPS(httpsPort int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != 443 {
+			host = net.JoinHostPort(host, strconv.Itoa(httpsPort))
+		}
+		target := fmt.Sprintf("https://%s%s", host, r.URL.RequestURI())
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
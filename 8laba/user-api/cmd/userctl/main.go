@@ -0,0 +1,19 @@
+// Команда userctl - утилита администратора для управления пользователями
+// в БД или через запущенный API (см. internal/cli)
+package main
+
+import (
+	"os"
+	"user-api/internal/cli"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	// Как и сервер, в режиме БД берет настройки из .env, если он есть
+	_ = godotenv.Load()
+
+	if err := cli.NewRootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,72 @@
+// Package docs содержит спецификацию API, сгенерированную swag из аннотаций
+// обработчиков и моделей, и отдает ее в формате OpenAPI 3.
+package docs
+
+//go:generate swag init -g cmd/api/main.go -d .. -o . --outputTypes json,yaml --parseInternal
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi2"
+	"github.com/getkin/kin-openapi/openapi2conv"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed swagger.json
+var swaggerJSON []byte
+
+// OpenAPI возвращает спецификацию, преобразованную из Swagger 2.0 в OpenAPI 3
+func OpenAPI() (*openapi3.T, error) {
+	var v2 openapi2.T
+	if err := json.Unmarshal(swaggerJSON, &v2); err != nil {
+		return nil, fmt.Errorf("failed to parse swagger.json: %w", err)
+	}
+
+	doc, err := openapi2conv.ToV3(&v2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert spec to OpenAPI 3: %w", err)
+	}
+
+	applyCollectionFormats(&v2, doc)
+
+	// Без host конвертер не создает servers, и пути теряют префикс basePath
+	if len(doc.Servers) == 0 && v2.BasePath != "" {
+		doc.Servers = openapi3.Servers{{URL: v2.BasePath}}
+	}
+
+	if err := doc.Validate(openapi3.NewLoader().Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+
+	return doc, nil
+}
+
+// applyCollectionFormats переносит collectionFormat параметров-массивов,
+// который конвертер не учитывает: csv ("a,b") становится style=form,
+// explode=false, а multi ("x=a&x=b") - style=form, explode=true.
+func applyCollectionFormats(v2 *openapi2.T, doc *openapi3.T) {
+	for path, item := range v2.Paths {
+		v3Item := doc.Paths.Value(path)
+		if v3Item == nil {
+			continue
+		}
+		for method, op := range item.Operations() {
+			v3Op := v3Item.GetOperation(method)
+			if v3Op == nil {
+				continue
+			}
+			for _, param := range op.Parameters {
+				if param.CollectionFormat != "csv" && param.CollectionFormat != "multi" {
+					continue
+				}
+				if v3Param := v3Op.Parameters.GetByInAndName(param.In, param.Name); v3Param != nil {
+					explode := param.CollectionFormat == "multi"
+					v3Param.Style = openapi3.SerializationForm
+					v3Param.Explode = &explode
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package avatar
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// Регистрация декодеров для image.Decode
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Ошибки обработки изображения
+var (
+	ErrUnsupportedType = errors.New("unsupported image type: only JPEG, PNG and GIF are allowed")
+	ErrInvalidImage    = errors.New("invalid image")
+	ErrTooLarge        = errors.New("image dimensions are too large")
+)
+
+// Size - вариант аватара: квадрат со стороной Pixels
+type Size struct {
+	Name   string
+	Pixels int
+}
+
+// Sizes - размеры, в которые пересохраняется аватар
+var Sizes = []Size{
+	{Name: "small", Pixels: 64},
+	{Name: "medium", Pixels: 256},
+	{Name: "large", Pixels: 512},
+}
+
+// ContentType - тип файлов аватара после обработки
+const ContentType = "image/jpeg"
+
+// allowedTypes - типы, определяемые по содержимому файла
+var allowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// maxPixels ограничивает размер декодируемого изображения, чтобы небольшой
+// файл не занял гигабайты памяти после распаковки
+const maxPixels = 40_000_000
+
+// Process проверяет тип изображения по содержимому (а не по заголовку
+// Content-Type клиента), обрезает его до квадрата по центру и
+// пересохраняет в JPEG каждого размера из Sizes. Пересохранение удаляет
+// метаданные (EXIF и т.п.) и все, что не является изображением.
+func Process(data []byte) (map[string][]byte, error) {
+	if !allowedTypes[http.DetectContentType(data)] {
+		return nil, ErrUnsupportedType
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	square := flatten(src, cropSquare(src))
+
+	result := make(map[string][]byte, len(Sizes))
+	for _, size := range Sizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, resize(square, size.Pixels), &jpeg.Options{Quality: 85}); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		result[size.Name] = buf.Bytes()
+	}
+	return result, nil
+}
+
+// cropSquare вырезает центральный квадрат изображения
+func cropSquare(src image.Image) image.Rectangle {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
+
+// flatten копирует область r изображения src в RGBA на белом фоне: JPEG
+// не поддерживает прозрачность
+func flatten(src image.Image, r image.Rectangle) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, r.Min, draw.Over)
+	return dst
+}
+
+// resize масштабирует квадратное изображение до side x side. При
+// уменьшении каждый пиксель - среднее покрываемой им области исходного
+// изображения (box filter), при увеличении - ближайший пиксель.
+func resize(src *image.RGBA, side int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	srcSide := src.Bounds().Dx()
+
+	for y := 0; y < side; y++ {
+		y0 := y * srcSide / side
+		y1 := max((y+1)*srcSide/side, y0+1)
+		for x := 0; x < side; x++ {
+			x0 := x * srcSide / side
+			x1 := max((x+1)*srcSide/side, x0+1)
+
+			var r, g, b, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+3]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = 0xff
+		}
+	}
+	return dst
+}
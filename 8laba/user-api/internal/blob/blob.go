@@ -0,0 +1,43 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidKey возвращается для ключей, которые могут выйти за пределы
+// хранилища (абсолютные пути, "..")
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Store - хранилище файлов. Ключи - относительные пути через "/",
+// например avatars/1/3f2a_small.jpg.
+type Store interface {
+	// Put сохраняет size байт из r под ключом key, заменяя существующий файл
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete удаляет файл; отсутствие файла ошибкой не считается
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix удаляет все файлы, ключи которых начинаются с prefix
+	DeletePrefix(ctx context.Context, prefix string) error
+	// URL возвращает публичный адрес файла
+	URL(key string) string
+}
+
+// validKey проверяет, что ключ - непустой относительный путь без "." и ".."
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
+
+// validPrefix допускает в конце префикса "/"
+func validPrefix(prefix string) bool {
+	return validKey(strings.TrimSuffix(prefix, "/"))
+}
@@ -0,0 +1,286 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// UnsignedPayload - значение x-amz-content-sha256 для тела, хэш которого
+// не подписывается (тело передается потоком)
+const UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// emptyPayloadHash - SHA-256 пустого тела
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config содержит настройки S3-совместимого хранилища (AWS S3, MinIO и т.п.)
+type S3Config struct {
+	// Endpoint - адрес API, например https://s3.eu-central-1.amazonaws.com
+	// или http://minio:9000
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	// PathStyle - адресовать бакет путем (endpoint/bucket/key), а не
+	// поддоменом (bucket.endpoint/key). MinIO обычно требует path style.
+	PathStyle bool
+	// PublicURL - базовый адрес файлов для клиентов (CDN и т.п.); по
+	// умолчанию - адрес бакета в Endpoint
+	PublicURL string
+}
+
+// S3Store хранит файлы в S3-совместимом хранилище. Запросы подписываются
+// AWS Signature Version 4; файлы должны быть доступны на чтение по
+// PublicURL (публичный бакет или CDN).
+type S3Store struct {
+	cfg      S3Config
+	client   *http.Client
+	endpoint *url.URL
+}
+
+// NewS3Store создает S3Store
+func NewS3Store(cfg S3Config, client *http.Client) (*S3Store, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s := &S3Store{cfg: cfg, client: client, endpoint: endpoint}
+	if s.cfg.PublicURL == "" {
+		s.cfg.PublicURL = s.objectURL("").String()
+	}
+	s.cfg.PublicURL = strings.TrimSuffix(s.cfg.PublicURL, "/")
+	return s, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	_, err = s.do(req, UnsignedPayload, http.StatusOK)
+	return err
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if !validKey(key) {
+		return ErrInvalidKey
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+	// S3 отвечает 204 и для несуществующего ключа
+	_, err = s.do(req, emptyPayloadHash, http.StatusNoContent, http.StatusOK)
+	return err
+}
+
+// DeletePrefix находит ключи через ListObjectsV2 и удаляет их по одному
+func (s *S3Store) DeletePrefix(ctx context.Context, prefix string) error {
+	if !validPrefix(prefix) {
+		return ErrInvalidKey
+	}
+
+	token := ""
+	for {
+		list, err := s.list(ctx, prefix, token)
+		if err != nil {
+			return err
+		}
+		for _, obj := range list.Contents {
+			if err := s.Delete(ctx, obj.Key); err != nil {
+				return err
+			}
+		}
+		if !list.IsTruncated {
+			return nil
+		}
+		token = list.NextContinuationToken
+	}
+}
+
+func (s *S3Store) URL(key string) string {
+	return s.cfg.PublicURL + "/" + encodePath(key)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func (s *S3Store) list(ctx context.Context, prefix, token string) (*listBucketResult, error) {
+	u := s.objectURL("")
+	query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+	if token != "" {
+		query.Set("continuation-token", token)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	body, err := s.do(req, emptyPayloadHash, http.StatusOK)
+	if err != nil {
+		return nil, err
+	}
+
+	var result listBucketResult
+	if err := xml.Unmarshal(body, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse S3 list response: %w", err)
+	}
+	return &result, nil
+}
+
+// do подписывает и выполняет запрос и возвращает тело ответа
+func (s *S3Store) do(req *http.Request, payloadHash string, expected ...int) ([]byte, error) {
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	SignV4(req, s.cfg.AccessKey, s.cfg.SecretKey, s.cfg.Region, "s3", time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("S3 %s %s: %w", req.Method, req.URL.Path, err)
+	}
+	for _, code := range expected {
+		if resp.StatusCode == code {
+			return body, nil
+		}
+	}
+	return nil, fmt.Errorf("S3 %s %s: unexpected status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// objectURL возвращает адрес объекта key (или бакета, если key пуст)
+func (s *S3Store) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimSuffix(u.Path, "/")
+	if s.cfg.PathStyle {
+		path += "/" + s.cfg.Bucket
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	u.Path = path + "/" + key
+	u.RawPath = encodePath(u.Path)
+	return &u
+}
+
+// SignV4 подписывает запрос AWS Signature Version 4. Подписываются Host и
+// все заголовки, уже установленные в запросе; заголовок
+// x-amz-content-sha256 должен содержать хэш тела или UNSIGNED-PAYLOAD.
+func SignV4(req *http.Request, accessKey, secretKey, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		encodePath(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("x-amz-content-sha256"),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// encodePath кодирует путь по правилам SigV4, сохраняя "/"
+func encodePath(path string) string {
+	return uriEncode(path, false)
+}
+
+// uriEncode кодирует все байты, кроме A-Z a-z 0-9 - _ . ~ (и "/", если
+// encodeSlash равен false)
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+	"user-api/internal/models"
+)
+
+// Store - хранилище кэша пользователей. По умолчанию используется LRU в
+// памяти процесса; для общего кэша нескольких экземпляров API (Redis,
+// memcached) достаточно реализовать этот интерфейс. Ошибки внешнего
+// хранилища реализация должна трактовать как промах.
+type Store interface {
+	Get(id int) (*models.User, bool)
+	Set(id int, user *models.User)
+	Delete(id int)
+}
+
+// LRU - ограниченный по размеру кэш с временем жизни записей. При
+// переполнении вытесняется запись, к которой дольше всего не обращались.
+type LRU struct {
+	size int
+	ttl  time.Duration
+
+	mu    sync.Mutex
+	order *list.List // в начале - недавно использованные
+	items map[int]*list.Element
+}
+
+type lruEntry struct {
+	id        int
+	user      models.User
+	expiresAt time.Time
+}
+
+// NewLRU создает кэш на size записей, каждая живет ttl
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:  size,
+		ttl:   ttl,
+		order: list.New(),
+		items: make(map[int]*list.Element, size),
+	}
+}
+
+// Get возвращает копию пользователя, если запись есть и не устарела
+func (c *LRU) Get(id int) (*models.User, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		return nil, false
+	}
+
+	c.order.MoveToFront(elem)
+	user := entry.user
+	return &user, true
+}
+
+// Set сохраняет копию пользователя
+func (c *LRU) Set(id int, user *models.User) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiresAt := time.Now().Add(c.ttl)
+	if elem, ok := c.items[id]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.user = *user
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[id] = c.order.PushFront(&lruEntry{id: id, user: *user, expiresAt: expiresAt})
+	if c.order.Len() > c.size {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Delete удаляет запись
+func (c *LRU) Delete(id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[id]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Len возвращает число записей, включая еще не удаленные устаревшие
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+func (c *LRU) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*lruEntry).id)
+}
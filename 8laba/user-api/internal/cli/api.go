@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"context"
+	"net/http"
+	"user-api/internal/models"
+	"user-api/pkg/client"
+)
+
+// apiBackend работает с запущенным API через pkg/client. Типы клиента
+// переводятся в models, с которыми работают команды.
+type apiBackend struct {
+	api *client.Client
+}
+
+func newAPIBackend(o *options) (*apiBackend, error) {
+	opts := []client.Option{
+		client.WithHTTPClient(&http.Client{Timeout: o.timeout}),
+		client.WithLanguage(systemLanguage()),
+		client.WithUserAgent("userctl"),
+		client.WithTenantHeader(o.tenantHeader),
+	}
+	if o.token != "" {
+		opts = append(opts, client.WithToken(o.token))
+	}
+	// Арендатор по умолчанию API определяет сам (из токена или DefaultID);
+	// заголовок принимается только от доверенных вызывающих
+	if o.tenantSet {
+		opts = append(opts, client.WithTenant(o.tenantID))
+	}
+	c, err := client.New(o.api, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &apiBackend{api: c}, nil
+}
+
+func (b *apiBackend) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	user, err := b.api.CreateUser(ctx, &client.CreateUserRequest{
+		Name:     req.Name,
+		Email:    req.Email,
+		Age:      req.Age,
+		Metadata: client.Metadata(req.Metadata),
+	})
+	return fromClientUser(user), err
+}
+
+func (b *apiBackend) GetUser(ctx context.Context, id int) (*models.User, error) {
+	user, err := b.api.GetUser(ctx, id)
+	return fromClientUser(user), err
+}
+
+func (b *apiBackend) GetUsers(ctx context.Context, page, pageSize int, filter *client.UserFilter) (*models.UserListResponse, error) {
+	response, err := b.api.GetUsers(ctx, page, pageSize, filter)
+	if err != nil {
+		return nil, err
+	}
+	users := make([]models.User, len(response.Users))
+	for i := range response.Users {
+		users[i] = *fromClientUser(&response.Users[i])
+	}
+	return &models.UserListResponse{
+		Users:      users,
+		Total:      response.Total,
+		Page:       response.Page,
+		PageSize:   response.PageSize,
+		TotalPages: response.TotalPages,
+	}, nil
+}
+
+func (b *apiBackend) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := b.api.UpdateUser(ctx, id, &client.UpdateUserRequest{
+		Name:     req.Name,
+		Email:    req.Email,
+		Age:      req.Age,
+		Metadata: client.Metadata(req.Metadata),
+	})
+	return fromClientUser(user), err
+}
+
+func (b *apiBackend) DeleteUser(ctx context.Context, id int) error {
+	return b.api.DeleteUser(ctx, id)
+}
+
+func (b *apiBackend) Close() error {
+	b.api.CloseIdleConnections()
+	return nil
+}
+
+// fromClientUser переводит пользователя из ответа API в models.User
+func fromClientUser(user *client.User) *models.User {
+	if user == nil {
+		return nil
+	}
+	var groups []models.UserGroup
+	for _, group := range user.Groups {
+		groups = append(groups, models.UserGroup{ID: group.ID, Name: group.Name, Role: group.Role})
+	}
+	return &models.User{
+		ID:              user.ID,
+		TenantID:        user.TenantID,
+		Name:            user.Name,
+		Email:           user.Email,
+		Age:             user.Age,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		Avatar:          models.AvatarURLs(user.Avatar),
+		Metadata:        models.Metadata(user.Metadata),
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
+		Groups:          groups,
+	}
+}
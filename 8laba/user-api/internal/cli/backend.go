@@ -0,0 +1,217 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"user-api/internal/config"
+	"user-api/internal/database"
+	"user-api/internal/i18n"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+	"user-api/internal/validation"
+	"user-api/pkg/client"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/jmoiron/sqlx"
+)
+
+// backend выполняет операции с пользователями в БД или через API
+type backend interface {
+	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+	GetUser(ctx context.Context, id int) (*models.User, error)
+	GetUsers(ctx context.Context, page, pageSize int, filter *client.UserFilter) (*models.UserListResponse, error)
+	UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, id int) error
+	Close() error
+}
+
+// open создает backend по глобальным флагам: API при заданном --api,
+// иначе БД
+func (o *options) open() (backend, error) {
+	if o.api != "" {
+		return newAPIBackend(o)
+	}
+	return newDBBackend(o)
+}
+
+// dbBackend работает с БД через service.UserService, как API. Запросы
+// проверяются по тем же тегам binding; письма подтверждения email не
+// отправляются.
+type dbBackend struct {
+	users     service.UserService
+	validate  *validator.Validate
+	localizer *i18n.Localizer
+	close     func() error
+}
+
+func newDBBackend(o *options) (*dbBackend, error) {
+	cfg, err := o.loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	disposable, err := validation.ReadDomainsFile(cfg.Validation.DisposableDomainsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read disposable domains: %w", err)
+	}
+	rules, err := validation.NewEmailRules(cfg.Validation.BlockDisposable, disposable,
+		cfg.Validation.BlockedDomains, cfg.Validation.BlockedEmails)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure email rules: %w", err)
+	}
+	validate := validation.New(rules)
+	translator, err := i18n.New()
+	if err != nil {
+		return nil, err
+	}
+	if err := translator.RegisterValidator(validate); err != nil {
+		return nil, err
+	}
+
+	db, err := connectDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := service.NewMetadataService(repository.NewMetadataSchemaRepository(db))
+	return &dbBackend{
+		users:     service.NewUserService(repository.NewUserRepository(database.NewCluster(db, nil, database.ClusterOptions{})), nil, metadata),
+		validate:  validate,
+		localizer: translator.Localizer(systemLanguage()),
+		close:     db.Close,
+	}, nil
+}
+
+// loadConfig загружает конфигурацию API из --config или переменных
+// окружения
+func (o *options) loadConfig() (*config.Config, error) {
+	var args []string
+	if o.config != "" {
+		args = append(args, "-config", o.config)
+	}
+	cfg, _, err := config.Load(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// connectDB подключается к основной БД из cfg. Реплики userctl не
+// использует: после изменения он должен сразу читать его результат.
+func connectDB(cfg *config.Config) (*sqlx.DB, error) {
+	db, err := database.NewPostgresDB(cfg.Database.DB())
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	// Как и API, не работаем с арендаторами под ролью, которая не
+	// соблюдает row-level security
+	if cfg.Tenancy.Enabled {
+		bypass, err := database.BypassesRLS(context.Background(), db)
+		if err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to check database role: %w", err)
+		}
+		if bypass {
+			db.Close()
+			return nil, fmt.Errorf("tenancy requires a database role without SUPERUSER and BYPASSRLS, got %q", cfg.Database.User)
+		}
+	}
+	return db, nil
+}
+
+// connectSystemDB подключается к основной БД под ролью database.system
+// (или ролью приложения, если она не задана) для операций над данными всех
+// арендаторов. Роль должна обходить row-level security.
+func connectSystemDB(cfg *config.Config) (*sqlx.DB, error) {
+	dbConfig := cfg.Database.DB()
+	if cfg.Database.System.User != "" {
+		dbConfig = cfg.Database.SystemDB()
+	}
+	db, err := database.NewPostgresDB(dbConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	bypass, err := database.BypassesRLS(context.Background(), db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to check database role: %w", err)
+	}
+	if !bypass {
+		db.Close()
+		return nil, fmt.Errorf("database role %q does not bypass row-level security, set database.system.user", dbConfig.User)
+	}
+	return db, nil
+}
+
+func (b *dbBackend) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	if err := b.validate.Struct(req); err != nil {
+		return nil, b.error(err)
+	}
+	user, err := b.users.CreateUser(ctx, req)
+	return user, b.error(err)
+}
+
+func (b *dbBackend) GetUser(ctx context.Context, id int) (*models.User, error) {
+	user, err := b.users.GetUser(ctx, id)
+	return user, b.error(err)
+}
+
+func (b *dbBackend) GetUsers(ctx context.Context, page, pageSize int, filter *client.UserFilter) (*models.UserListResponse, error) {
+	filters, err := service.ParseUserFilters(filter.Values())
+	if err != nil {
+		return nil, b.error(err)
+	}
+	response, err := b.users.GetUsers(ctx, page, pageSize, filters)
+	return response, b.error(err)
+}
+
+func (b *dbBackend) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	if err := b.validate.Struct(req); err != nil {
+		return nil, b.error(err)
+	}
+	user, err := b.users.UpdateUser(ctx, id, req)
+	return user, b.error(err)
+}
+
+func (b *dbBackend) DeleteUser(ctx context.Context, id int) error {
+	return b.error(b.users.DeleteUser(ctx, id))
+}
+
+func (b *dbBackend) Close() error {
+	return b.close()
+}
+
+// error переводит сообщение err на язык системы
+func (b *dbBackend) error(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &localizedError{err: err, message: b.localizer.Error(err)}
+}
+
+// localizedError - ошибка с переведенным сообщением; исходная ошибка
+// доступна через errors.Is и errors.As
+type localizedError struct {
+	err     error
+	message string
+}
+
+func (e *localizedError) Error() string { return e.message }
+
+func (e *localizedError) Unwrap() error { return e.err }
+
+// systemLanguage возвращает язык из LC_ALL, LC_MESSAGES или LANG
+// (ru_RU.UTF-8 -> ru)
+func systemLanguage() string {
+	for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			language, _, _ := strings.Cut(value, ".")
+			language, _, _ = strings.Cut(language, "_")
+			return language
+		}
+	}
+	return ""
+}
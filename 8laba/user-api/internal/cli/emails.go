@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/validation"
+
+	"github.com/spf13/cobra"
+)
+
+func newNormalizeEmailsCommand(opts *options) *cobra.Command {
+	var dryRun bool
+
+	cmd := &cobra.Command{
+		Use:   "normalize-emails",
+		Short: "Normalize stored emails of all tenants",
+		Long: "Bring the emails of all users of all tenants to the form the API stores: trimmed, lower case, " +
+			"Unicode NFC and the domain in punycode. Run it before migrations/011_normalize_user_emails.sql, " +
+			"which cannot convert domains to punycode and stops while they are left. Nothing is changed if two " +
+			"users of a tenant end up with the same email; they are listed and have to be resolved by hand. " +
+			"Changed users are written to the outbox as user.updated events. Connects as database.system.user, " +
+			"a role with BYPASSRLS, to see the users of all tenants.",
+		Example: "  userctl normalize-emails --dry-run\n  userctl normalize-emails",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.api != "" {
+				return errors.New("normalize-emails works only with the database, remove --api")
+			}
+			appCfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			db, err := connectSystemDB(appCfg)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			plan, err := repository.NormalizeEmails(cmd.Context(), db, validation.NormalizeEmail, dryRun)
+			if plan != nil {
+				if printErr := opts.printEmailNormalization(cmd, plan, dryRun); printErr != nil {
+					return printErr
+				}
+			}
+			if errors.Is(err, models.ErrEmailTaken) {
+				return fmt.Errorf("%d emails are taken by several users after normalization, nothing changed", len(plan.Conflicts))
+			}
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only show what would change")
+	return cmd
+}
+
+// printEmailNormalization выводит план нормализации адресов в формате
+// --output
+func (o *options) printEmailNormalization(cmd *cobra.Command, plan *repository.EmailNormalization, dryRun bool) error {
+	w := cmd.OutOrStdout()
+	if o.output != outputTable {
+		return encode(w, o.output, plan)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(plan.Conflicts) > 0 {
+		fmt.Fprintln(tw, "TENANT\tEMAIL\tUSERS")
+		for _, conflict := range plan.Conflicts {
+			fmt.Fprintf(tw, "%d\t%s\t%v\n", conflict.TenantID, conflict.Email, conflict.UserIDs)
+		}
+		return tw.Flush()
+	}
+
+	fmt.Fprintln(tw, "ID\tTENANT\tEMAIL")
+	for _, change := range plan.Changes {
+		fmt.Fprintf(tw, "%d\t%d\t%s\n", change.UserID, change.TenantID, change.Email)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	for _, invalid := range plan.Invalid {
+		fmt.Fprintf(cmd.ErrOrStderr(), "User %d: invalid email %q left unchanged\n", invalid.UserID, invalid.Email)
+	}
+	verb := "Normalized"
+	if dryRun {
+		verb = "Would normalize"
+	}
+	_, err := fmt.Fprintf(w, "\n%s %d emails\n", verb, len(plan.Changes))
+	return err
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+	"user-api/internal/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// printUser выводит одного пользователя в формате --output
+func (o *options) printUser(w io.Writer, user *models.User) error {
+	if o.output != outputTable {
+		return encode(w, o.output, user)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%d\n", user.ID)
+	fmt.Fprintf(tw, "Tenant:\t%d\n", user.TenantID)
+	fmt.Fprintf(tw, "Name:\t%s\n", user.Name)
+	fmt.Fprintf(tw, "Email:\t%s\n", user.Email)
+	fmt.Fprintf(tw, "Age:\t%d\n", user.Age)
+	fmt.Fprintf(tw, "Verified:\t%s\n", verified(user))
+	if len(user.Metadata) > 0 {
+		metadata, err := json.Marshal(user.Metadata)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "Metadata:\t%s\n", metadata)
+	}
+	fmt.Fprintf(tw, "Created:\t%s\n", formatTime(user.CreatedAt))
+	fmt.Fprintf(tw, "Updated:\t%s\n", formatTime(user.UpdatedAt))
+	return tw.Flush()
+}
+
+// printUsers выводит страницу списка пользователей в формате --output
+func (o *options) printUsers(w io.Writer, response *models.UserListResponse) error {
+	if o.output != outputTable {
+		return encode(w, o.output, response)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tEMAIL\tAGE\tVERIFIED\tCREATED")
+	for i := range response.Users {
+		user := &response.Users[i]
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%s\t%s\n",
+			user.ID, user.Name, user.Email, user.Age, verified(user), formatTime(user.CreatedAt))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nPage %d of %d, %d users total\n", response.Page, response.TotalPages, response.Total)
+	return err
+}
+
+// encode записывает v в JSON или YAML. YAML строится из JSON
+// представления, поэтому имена и порядок полей совпадают.
+func encode(w io.Writer, output string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if output == outputJSON {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+		return err
+	}
+
+	// JSON - подмножество YAML; узлы сохраняют порядок ключей, а стиль
+	// сбрасывается, чтобы вывод был в блочной форме
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+func verified(user *models.User) string {
+	if user.EmailVerifiedAt == nil {
+		return "no"
+	}
+	return "yes"
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.DateTime)
+}
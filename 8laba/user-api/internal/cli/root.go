@@ -0,0 +1,100 @@
+// Package cli реализует userctl - утилиту администратора для управления
+// пользователями. Команды работают либо напрямую с БД через те же
+// репозитории и сервисы, что и API, либо с запущенным API по HTTP.
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+	"user-api/internal/tenant"
+
+	"github.com/spf13/cobra"
+)
+
+// Форматы вывода
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+)
+
+var outputFormats = []string{outputTable, outputJSON, outputYAML}
+
+// options - глобальные флаги userctl
+type options struct {
+	api          string
+	token        string
+	tenantID     int
+	tenantSet    bool
+	tenantHeader string
+	config       string
+	output       string
+	timeout      time.Duration
+}
+
+// NewRootCommand создает команду userctl со всеми подкомандами
+func NewRootCommand() *cobra.Command {
+	opts := &options{}
+
+	root := &cobra.Command{
+		Use:   "userctl",
+		Short: "Manage users of user-api",
+		Long: "userctl manages users either directly in the database (the default, " +
+			"configured like the API server) or through a running API (--api).",
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if !slices.Contains(outputFormats, opts.output) {
+				return fmt.Errorf("unknown output format %q, expected one of %s", opts.output, strings.Join(outputFormats, ", "))
+			}
+			opts.tenantSet = cmd.Flags().Changed("tenant")
+			return nil
+		},
+	}
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&opts.api, "api", os.Getenv("USERCTL_API"), "base URL of a running API, e.g. http://localhost:8080 (env USERCTL_API); without it userctl connects to the database")
+	flags.StringVar(&opts.token, "token", os.Getenv("USERCTL_TOKEN"), "access token sent to the API (env USERCTL_TOKEN)")
+	flags.IntVar(&opts.tenantID, "tenant", tenant.DefaultID, "tenant ID; sent to the API in --tenant-header when set explicitly")
+	flags.StringVar(&opts.tenantHeader, "tenant-header", "X-Tenant-ID", "header with the tenant ID (tenancy.header of the API)")
+	flags.StringVar(&opts.config, "config", os.Getenv("CONFIG_FILE"), "path to the API config file for database mode (env CONFIG_FILE)")
+	flags.StringVarP(&opts.output, "output", "o", outputTable, "output format: table, json or yaml")
+	flags.DurationVar(&opts.timeout, "timeout", 30*time.Second, "timeout of a single API request")
+
+	_ = root.RegisterFlagCompletionFunc("output", fixedCompletion(outputFormats...))
+	_ = root.MarkPersistentFlagFilename("config", "yaml", "yml", "toml")
+
+	root.AddCommand(
+		newCreateCommand(opts),
+		newGetCommand(opts),
+		newListCommand(opts),
+		newUpdateCommand(opts),
+		newDeleteCommand(opts),
+		newImportCommand(opts),
+		newExportCommand(opts),
+		newSeedCommand(opts),
+		newNormalizeEmailsCommand(opts),
+	)
+	return root
+}
+
+// run открывает backend, выполняет fn и закрывает backend
+func (o *options) run(cmd *cobra.Command, fn func(ctx context.Context, b backend) error) error {
+	b, err := o.open()
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	return fn(tenant.WithID(cmd.Context(), o.tenantID), b)
+}
+
+// fixedCompletion дополняет значение флага или аргумента из values
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
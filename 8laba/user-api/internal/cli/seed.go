@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"user-api/internal/database"
+	"user-api/internal/repository"
+	"user-api/internal/seed"
+	"user-api/internal/service"
+	"user-api/internal/tenant"
+
+	"github.com/spf13/cobra"
+)
+
+func newSeedCommand(opts *options) *cobra.Command {
+	var cfg seed.Config
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with fake users",
+		Long: "Generate realistic users with Russian and English names, unique emails on reserved example domains " +
+			"and a realistic age distribution, and insert them in batches. The same --seed gives the same users; " +
+			"registration dates are counted back from the current time. Users whose email is already taken are skipped. " +
+			"Works only with the database: no events are written to the outbox and no emails are sent.",
+		Example: "  userctl seed --count 10000\n  userctl seed --wipe --seed 42 --yes",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.api != "" {
+				return errors.New("seed works only with the database, remove --api")
+			}
+			if cfg.RussianShare < 0 || cfg.RussianShare > 1 {
+				return fmt.Errorf("--ru-share must be between 0 and 1, got %g", cfg.RussianShare)
+			}
+			if !cmd.Flags().Changed("seed") {
+				cfg.Seed = rand.Uint64()
+			}
+			if cfg.Wipe && !yes && !confirm(cmd, fmt.Sprintf("Delete all users of tenant %d?", opts.tenantID)) {
+				return errors.New("aborted")
+			}
+
+			appCfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			db, err := connectDB(appCfg)
+			if err != nil {
+				return err
+			}
+			defer db.Close()
+
+			seeder := seed.New(repository.NewUserRepository(database.NewCluster(db, nil, database.ClusterOptions{})),
+				service.NewMetadataService(repository.NewMetadataSchemaRepository(db)))
+			stderr := cmd.ErrOrStderr()
+			fmt.Fprintf(stderr, "Seed: %d\n", cfg.Seed)
+			result, err := seeder.Run(tenant.WithID(cmd.Context(), opts.tenantID), cfg, func(done int) {
+				fmt.Fprintf(stderr, "\rInserted %d of %d", done, cfg.Count)
+			})
+			if cfg.Count > 0 {
+				fmt.Fprintln(stderr)
+			}
+			if err != nil {
+				return fmt.Errorf("%w; %d users created before the error", err, result.Created)
+			}
+
+			if opts.output != outputTable {
+				return encode(cmd.OutOrStdout(), opts.output, result)
+			}
+			if cfg.Wipe {
+				fmt.Fprintf(cmd.OutOrStdout(), "Deleted %d users\n", result.Deleted)
+			}
+			_, err = fmt.Fprintf(cmd.OutOrStdout(), "Created %d users, skipped %d with taken emails\n", result.Created, result.Skipped)
+			return err
+		},
+	}
+	flags := cmd.Flags()
+	flags.IntVar(&cfg.Count, "count", 1000, "number of users to generate")
+	flags.Uint64Var(&cfg.Seed, "seed", 0, "seed of the generator (default: random, printed to stderr)")
+	flags.IntVar(&cfg.BatchSize, "batch-size", seed.DefaultBatchSize, "users per INSERT statement")
+	flags.Float64Var(&cfg.RussianShare, "ru-share", 0.5, "share of users with Russian names, from 0 to 1")
+	flags.BoolVar(&cfg.Wipe, "wipe", false, "delete all users of the tenant before seeding")
+	flags.BoolVarP(&yes, "yes", "y", false, "do not ask for confirmation of --wipe")
+	return cmd
+}
+
+// confirm задает вопрос в stderr и читает ответ из stdin
+func confirm(cmd *cobra.Command, question string) bool {
+	fmt.Fprintf(cmd.ErrOrStderr(), "%s [y/N] ", question)
+	answer, _ := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,416 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"user-api/internal/format"
+	"user-api/internal/i18n"
+	"user-api/internal/models"
+	"user-api/internal/validation"
+	"user-api/pkg/client"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Форматы файлов import и export
+const (
+	fileJSON  = "json"
+	fileJSONL = "jsonl"
+	fileYAML  = "yaml"
+	fileCSV   = "csv"
+)
+
+var (
+	importFormats = []string{fileJSON, fileYAML, fileCSV}
+	exportFormats = []string{fileJSON, fileJSONL, fileYAML, fileCSV}
+)
+
+// exportPageSize - размер страницы при выгрузке
+const exportPageSize = client.MaxPageSize
+
+func newImportCommand(opts *options) *cobra.Command {
+	var fileFormat string
+	var dryRun, continueOnError bool
+
+	cmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Create users from a file",
+		Long: "Create users from a JSON (array or one object per line), YAML or CSV file; \"-\" reads stdin. " +
+			"Only name, email, age and metadata are used, so files written by export can be imported as is. " +
+			"CSV files need a header row; metadata is a JSON object.",
+		Example: "  userctl import users.csv --continue-on-error\n  userctl export --name smith | userctl import --dry-run -",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			inputFormat, err := fileFormatOf(args[0], fileFormat, importFormats)
+			if err != nil {
+				return err
+			}
+			in, err := openInput(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+
+			users, err := readUsers(in, inputFormat)
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", args[0], err)
+			}
+
+			if dryRun {
+				return opts.printImport(cmd.OutOrStdout(), checkUsers(users), true)
+			}
+			return opts.run(cmd, func(ctx context.Context, b backend) error {
+				result := &importResult{Total: len(users)}
+				for i := range users {
+					if _, err := b.CreateUser(ctx, &users[i]); err != nil {
+						if !continueOnError {
+							return fmt.Errorf("record %d (%s): %w; %d users created before it", i+1, users[i].Email, err, result.Created)
+						}
+						result.fail(i, &users[i], err)
+						continue
+					}
+					result.Created++
+				}
+				if err := opts.printImport(cmd.OutOrStdout(), result, false); err != nil {
+					return err
+				}
+				if result.Failed > 0 {
+					return fmt.Errorf("%d of %d users were not created", result.Failed, result.Total)
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVar(&fileFormat, "format", "", "file format: json, yaml or csv (default: by file extension, json for stdin)")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only read and validate the file, do not create users")
+	cmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "skip users that cannot be created instead of stopping at the first one")
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletion(importFormats...))
+	return cmd
+}
+
+func newExportCommand(opts *options) *cobra.Command {
+	var filters userFilters
+	var fileFormat, output string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write users to a file",
+		Long: "Write all users matching the filters (the same as in list) to a JSON, JSON Lines, YAML or CSV file. " +
+			"The file is written only after all pages have been read.",
+		Example: "  userctl export --file users.csv --verified true\n  userctl export --format jsonl --sort created_at",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			outputFormat, err := fileFormatOf(output, fileFormat, exportFormats)
+			if err != nil {
+				return err
+			}
+			filter, err := filters.filter()
+			if err != nil {
+				return err
+			}
+			return opts.run(cmd, func(ctx context.Context, b backend) error {
+				var users []models.User
+				for page := 1; ; page++ {
+					response, err := b.GetUsers(ctx, page, exportPageSize, filter)
+					if err != nil {
+						return err
+					}
+					users = append(users, response.Users...)
+					if page >= response.TotalPages || len(response.Users) == 0 {
+						break
+					}
+				}
+
+				var buf bytes.Buffer
+				if err := writeUsers(&buf, outputFormat, users); err != nil {
+					return err
+				}
+				if output == "" || output == "-" {
+					_, err := buf.WriteTo(cmd.OutOrStdout())
+					return err
+				}
+				if err := os.WriteFile(output, buf.Bytes(), 0o644); err != nil {
+					return err
+				}
+				fmt.Fprintf(cmd.ErrOrStderr(), "Exported %d users to %s\n", len(users), output)
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringVarP(&output, "file", "f", "", "output file (default: stdout)")
+	cmd.Flags().StringVar(&fileFormat, "format", "", "file format: json, jsonl, yaml or csv (default: by file extension, json for stdout)")
+	filters.register(cmd, "id")
+	_ = cmd.RegisterFlagCompletionFunc("format", fixedCompletion(exportFormats...))
+	_ = cmd.MarkFlagFilename("file", "json", "jsonl", "yaml", "yml", "csv")
+	return cmd
+}
+
+// importResult - итог импорта
+type importResult struct {
+	Total   int           `json:"total"`
+	Created int           `json:"created"`
+	Failed  int           `json:"failed"`
+	Errors  []importError `json:"errors,omitempty"`
+}
+
+// importError - запись, которую не удалось импортировать
+type importError struct {
+	Record int    `json:"record"`
+	Email  string `json:"email"`
+	Error  string `json:"error"`
+}
+
+func (r *importResult) fail(i int, user *models.CreateUserRequest, err error) {
+	r.Failed++
+	r.Errors = append(r.Errors, importError{Record: i + 1, Email: user.Email, Error: err.Error()})
+}
+
+// printImport выводит итог импорта; при dryRun Created - число записей,
+// прошедших проверку
+func (o *options) printImport(w io.Writer, result *importResult, dryRun bool) error {
+	if o.output != outputTable {
+		return encode(w, o.output, result)
+	}
+	for _, e := range result.Errors {
+		fmt.Fprintf(w, "record %d (%s): %s\n", e.Record, e.Email, e.Error)
+	}
+	if dryRun {
+		_, err := fmt.Fprintf(w, "%d of %d users are valid\n", result.Created, result.Total)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "Created %d of %d users\n", result.Created, result.Total)
+	return err
+}
+
+// checkUsers проверяет записи без обращения к БД или API: теги binding
+// и нормализацию имени и email. Правила, заданные в конфигурации
+// (одноразовые и заблокированные адреса), схемы metadata и занятость
+// email проверяются только при создании.
+func checkUsers(users []models.CreateUserRequest) *importResult {
+	validate := validation.New(nil)
+	var localizer *i18n.Localizer
+	if translator, err := i18n.New(); err == nil && translator.RegisterValidator(validate) == nil {
+		localizer = translator.Localizer(systemLanguage())
+	}
+
+	result := &importResult{Total: len(users)}
+	seen := map[string]int{}
+	for i := range users {
+		user := &users[i]
+		err := validate.Struct(user)
+		if err == nil {
+			_, err = validation.NormalizeName(user.Name)
+		}
+		if err == nil {
+			var email string
+			if email, err = validation.NormalizeEmail(user.Email); err == nil {
+				if first, ok := seen[email]; ok {
+					err = fmt.Errorf("%w (record %d)", models.ErrEmailTaken, first)
+				}
+				seen[email] = i + 1
+			}
+		}
+		if err != nil {
+			result.fail(i, user, errors.New(localizer.Error(err)))
+			continue
+		}
+		result.Created++
+	}
+	return result
+}
+
+// fileFormatOf возвращает формат файла: явно заданный или по расширению
+// имени (json для stdin и stdout)
+func fileFormatOf(name, explicit string, formats []string) (string, error) {
+	fileFormat := explicit
+	if fileFormat == "" {
+		switch strings.ToLower(filepath.Ext(name)) {
+		case ".jsonl", ".ndjson":
+			fileFormat = fileJSONL
+		case ".yaml", ".yml":
+			fileFormat = fileYAML
+		case ".csv":
+			fileFormat = fileCSV
+		default:
+			fileFormat = fileJSON
+		}
+		// JSON Lines читается тем же способом, что и JSON
+		if fileFormat == fileJSONL && !slices.Contains(formats, fileJSONL) {
+			fileFormat = fileJSON
+		}
+	}
+	if !slices.Contains(formats, fileFormat) {
+		return "", fmt.Errorf("unknown file format %q, expected one of %s", fileFormat, strings.Join(formats, ", "))
+	}
+	return fileFormat, nil
+}
+
+func openInput(cmd *cobra.Command, name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(cmd.InOrStdin()), nil
+	}
+	return os.Open(name)
+}
+
+// readUsers читает записи для создания пользователей. JSON может быть
+// массивом или последовательностью объектов (JSON Lines).
+func readUsers(r io.Reader, fileFormat string) ([]models.CreateUserRequest, error) {
+	switch fileFormat {
+	case fileYAML:
+		var records []interface{}
+		if err := yaml.NewDecoder(r).Decode(&records); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		// Записи приводятся к JSON, чтобы использовать те же теги, что и API
+		data, err := json.Marshal(records)
+		if err != nil {
+			return nil, err
+		}
+		var users []models.CreateUserRequest
+		if err := json.Unmarshal(data, &users); err != nil {
+			return nil, err
+		}
+		return users, nil
+	case fileCSV:
+		return readCSV(r)
+	}
+
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	if first, err := peekNonSpace(br); err == nil && first == '[' {
+		var users []models.CreateUserRequest
+		if err := dec.Decode(&users); err != nil {
+			return nil, err
+		}
+		return users, nil
+	}
+	var users []models.CreateUserRequest
+	for {
+		var user models.CreateUserRequest
+		err := dec.Decode(&user)
+		if errors.Is(err, io.EOF) {
+			return users, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", len(users)+1, err)
+		}
+		users = append(users, user)
+	}
+}
+
+// peekNonSpace возвращает первый непробельный байт, не извлекая его
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		switch b[0] {
+		case ' ', '\t', '\r', '\n':
+			_, _ = br.ReadByte()
+		default:
+			return b[0], nil
+		}
+	}
+}
+
+// readCSV читает CSV с заголовком. Используются колонки name, email, age
+// и metadata, остальные пропускаются.
+func readCSV(r io.Reader) ([]models.CreateUserRequest, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "email", "age"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing column %q", required)
+		}
+	}
+
+	var users []models.CreateUserRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return users, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line := len(users) + 2
+		user := models.CreateUserRequest{
+			Name:  csvCell(record[columns["name"]]),
+			Email: csvCell(record[columns["email"]]),
+		}
+		if user.Age, err = strconv.Atoi(strings.TrimSpace(record[columns["age"]])); err != nil {
+			return nil, fmt.Errorf("line %d: invalid age %q", line, record[columns["age"]])
+		}
+		if i, ok := columns["metadata"]; ok && strings.TrimSpace(record[i]) != "" {
+			if err := json.Unmarshal([]byte(record[i]), &user.Metadata); err != nil {
+				return nil, fmt.Errorf("line %d: metadata must be a JSON object: %w", line, err)
+			}
+		}
+		users = append(users, user)
+	}
+}
+
+// csvCell снимает префикс ', который export добавляет к значениям,
+// похожим на формулы (см. format.EncodeCSV)
+func csvCell(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune("=+-@", rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+// writeUsers записывает пользователей в формате fileFormat
+func writeUsers(w io.Writer, fileFormat string, users []models.User) error {
+	if users == nil {
+		users = []models.User{}
+	}
+	switch fileFormat {
+	case fileJSON:
+		return encode(w, outputJSON, users)
+	case fileYAML:
+		return encode(w, outputYAML, users)
+	case fileJSONL:
+		enc := json.NewEncoder(w)
+		for i := range users {
+			if err := enc.Encode(&users[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	rows := make([]map[string]json.RawMessage, len(users))
+	for i := range users {
+		data, err := json.Marshal(&users[i])
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &rows[i]); err != nil {
+			return err
+		}
+	}
+	return format.EncodeCSV(w, models.UserFields, rows)
+}
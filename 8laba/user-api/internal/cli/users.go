@@ -0,0 +1,257 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"user-api/internal/models"
+	"user-api/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newCreateCommand(opts *options) *cobra.Command {
+	var req models.CreateUserRequest
+	var metadata []string
+
+	cmd := &cobra.Command{
+		Use:     "create --name NAME --email EMAIL --age AGE",
+		Short:   "Create a user",
+		Example: "  userctl create --name \"John Doe\" --email john@example.com --age 30 --metadata department=sales",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if req.Metadata, err = parseMetadata(metadata); err != nil {
+				return err
+			}
+			return opts.run(cmd, func(ctx context.Context, b backend) error {
+				user, err := b.CreateUser(ctx, &req)
+				if err != nil {
+					return err
+				}
+				return opts.printUser(cmd.OutOrStdout(), user)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&req.Name, "name", "", "user name")
+	cmd.Flags().StringVar(&req.Email, "email", "", "user email")
+	cmd.Flags().IntVar(&req.Age, "age", 0, "user age")
+	cmd.Flags().StringArrayVar(&metadata, "metadata", nil, "metadata field as key=value (repeatable); the value is parsed as JSON if possible")
+	_ = cmd.MarkFlagRequired("name")
+	_ = cmd.MarkFlagRequired("email")
+	_ = cmd.MarkFlagRequired("age")
+	return cmd
+}
+
+func newGetCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get ID",
+		Short: "Show a user",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			return opts.run(cmd, func(ctx context.Context, b backend) error {
+				user, err := b.GetUser(ctx, id)
+				if err != nil {
+					return err
+				}
+				return opts.printUser(cmd.OutOrStdout(), user)
+			})
+		},
+	}
+}
+
+func newListCommand(opts *options) *cobra.Command {
+	var filters userFilters
+	var page, pageSize int
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List users",
+		Long:    "List users with the same filters and sorting as GET /api/v1/users.",
+		Example: "  userctl list --min-age 18 --sort name --metadata department=sales -o json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filter, err := filters.filter()
+			if err != nil {
+				return err
+			}
+			return opts.run(cmd, func(ctx context.Context, b backend) error {
+				response, err := b.GetUsers(ctx, page, pageSize, filter)
+				if err != nil {
+					return err
+				}
+				return opts.printUsers(cmd.OutOrStdout(), response)
+			})
+		},
+	}
+	cmd.Flags().IntVar(&page, "page", 1, "page number")
+	cmd.Flags().IntVar(&pageSize, "page-size", 10, "page size (1-100)")
+	filters.register(cmd, "")
+	return cmd
+}
+
+func newUpdateCommand(opts *options) *cobra.Command {
+	var req models.UpdateUserRequest
+	var metadata []string
+
+	cmd := &cobra.Command{
+		Use:     "update ID [--name NAME] [--email EMAIL] [--age AGE]",
+		Short:   "Update a user",
+		Long:    "Update the given fields of a user. Metadata fields are merged into existing ones; key=null removes a field.",
+		Example: "  userctl update 42 --email john.new@example.com --metadata department=null",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseID(args[0])
+			if err != nil {
+				return err
+			}
+			if req.Metadata, err = parseMetadata(metadata); err != nil {
+				return err
+			}
+			if req.Name == "" && req.Email == "" && req.Age == 0 && req.Metadata == nil {
+				return fmt.Errorf("nothing to update: set at least one of --name, --email, --age, --metadata")
+			}
+			return opts.run(cmd, func(ctx context.Context, b backend) error {
+				user, err := b.UpdateUser(ctx, id, &req)
+				if err != nil {
+					return err
+				}
+				return opts.printUser(cmd.OutOrStdout(), user)
+			})
+		},
+	}
+	cmd.Flags().StringVar(&req.Name, "name", "", "new name")
+	cmd.Flags().StringVar(&req.Email, "email", "", "new email")
+	cmd.Flags().IntVar(&req.Age, "age", 0, "new age")
+	cmd.Flags().StringArrayVar(&metadata, "metadata", nil, "metadata field as key=value (repeatable); key=null removes the field")
+	return cmd
+}
+
+func newDeleteCommand(opts *options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete ID...",
+		Short: "Delete users",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids := make([]int, len(args))
+			for i, arg := range args {
+				id, err := parseID(arg)
+				if err != nil {
+					return err
+				}
+				ids[i] = id
+			}
+			return opts.run(cmd, func(ctx context.Context, b backend) error {
+				for _, id := range ids {
+					if err := b.DeleteUser(ctx, id); err != nil {
+						return fmt.Errorf("user %d: %w", id, err)
+					}
+					fmt.Fprintf(cmd.ErrOrStderr(), "Deleted user %d\n", id)
+				}
+				return nil
+			})
+		},
+	}
+}
+
+// userFilters - флаги фильтрации и сортировки списка, общие для list и
+// export. В режиме БД фильтр переводится в параметры GET /users и
+// разбирается так же, как в API (service.ParseUserFilters).
+type userFilters struct {
+	name, email    string
+	minAge, maxAge int
+	verified       string
+	group          int
+	sort, order    string
+	metadata       []string
+}
+
+// register добавляет флаги в cmd; defaultSort - поле сортировки по
+// умолчанию (пустое - порядок API, сначала новые)
+func (f *userFilters) register(cmd *cobra.Command, defaultSort string) {
+	flags := cmd.Flags()
+	flags.StringVar(&f.name, "name", "", "filter by name (substring)")
+	flags.StringVar(&f.email, "email", "", "filter by email (substring)")
+	flags.IntVar(&f.minAge, "min-age", 0, "minimum age")
+	flags.IntVar(&f.maxAge, "max-age", 0, "maximum age")
+	flags.StringVar(&f.verified, "verified", "", "filter by email verification status: true or false")
+	flags.IntVar(&f.group, "group", 0, "filter by group membership (group ID)")
+	flags.StringVar(&f.sort, "sort", defaultSort, "sort field: id, name, email, age, created_at, updated_at")
+	flags.StringVar(&f.order, "order", "asc", "sort order: asc or desc")
+	flags.StringArrayVar(&f.metadata, "metadata", nil, "filter by metadata field as key=value (repeatable)")
+
+	_ = cmd.RegisterFlagCompletionFunc("verified", fixedCompletion("true", "false"))
+	_ = cmd.RegisterFlagCompletionFunc("sort", fixedCompletion("id", "name", "email", "age", "created_at", "updated_at"))
+	_ = cmd.RegisterFlagCompletionFunc("order", fixedCompletion("asc", "desc"))
+}
+
+// filter возвращает фильтр списка по флагам
+func (f *userFilters) filter() (*client.UserFilter, error) {
+	filter := &client.UserFilter{
+		Name:   f.name,
+		Email:  f.email,
+		MinAge: f.minAge,
+		MaxAge: f.maxAge,
+		Group:  f.group,
+		Sort:   f.sort,
+		Order:  f.order,
+	}
+	if f.verified != "" {
+		verified, err := strconv.ParseBool(f.verified)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --verified %q: expected true or false", f.verified)
+		}
+		filter.Verified = &verified
+	}
+	for _, field := range f.metadata {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --metadata %q: expected key=value", field)
+		}
+		if filter.Metadata == nil {
+			filter.Metadata = map[string]string{}
+		}
+		filter.Metadata[key] = value
+	}
+	return filter, nil
+}
+
+// parseMetadata разбирает поля metadata вида key=value. Значение, которое
+// является JSON (число, true, null, объект), сохраняется как JSON, иначе -
+// как строка.
+func parseMetadata(fields []string) (models.Metadata, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	metadata := models.Metadata{}
+	for _, field := range fields {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --metadata %q: expected key=value", field)
+		}
+		if json.Valid([]byte(value)) {
+			metadata[key] = json.RawMessage(value)
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = raw
+	}
+	return metadata, nil
+}
+
+func parseID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("invalid user ID %q", arg)
+	}
+	return id, nil
+}
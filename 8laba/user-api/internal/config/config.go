@@ -0,0 +1,682 @@
+package config
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"user-api/internal/database"
+	"user-api/internal/tenant"
+)
+
+// Config содержит всю конфигурацию приложения
+type Config struct {
+	Server        ServerConfig        `yaml:"server" toml:"server"`
+	Database      DatabaseConfig      `yaml:"database" toml:"database"`
+	GraphQL       GraphQLConfig       `yaml:"graphql" toml:"graphql"`
+	Cache         CacheConfig         `yaml:"cache" toml:"cache"`
+	Idempotency   IdempotencyConfig   `yaml:"idempotency" toml:"idempotency"`
+	Verification  VerificationConfig  `yaml:"verification" toml:"verification"`
+	PasswordReset PasswordResetConfig `yaml:"password_reset" toml:"password_reset"`
+	Mailer        MailerConfig        `yaml:"mailer" toml:"mailer"`
+	Avatar        AvatarConfig        `yaml:"avatar" toml:"avatar"`
+	Blob          BlobConfig          `yaml:"blob" toml:"blob"`
+	Outbox        OutboxConfig        `yaml:"outbox" toml:"outbox"`
+	SSE           SSEConfig           `yaml:"sse" toml:"sse"`
+	Stats         StatsConfig         `yaml:"stats" toml:"stats"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks" toml:"webhooks"`
+	Log           LogConfig           `yaml:"log" toml:"log"`
+	Auth          AuthConfig          `yaml:"auth" toml:"auth"`
+	Tenancy       TenancyConfig       `yaml:"tenancy" toml:"tenancy"`
+	Validation    ValidationConfig    `yaml:"validation" toml:"validation"`
+}
+
+// ServerConfig содержит настройки HTTP сервера
+type ServerConfig struct {
+	Host            string   `yaml:"host" toml:"host" env:"SERVER_HOST"`
+	Port            int      `yaml:"port" toml:"port" env:"SERVER_PORT"`
+	ReadTimeout     Duration `yaml:"read_timeout" toml:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	WriteTimeout    Duration `yaml:"write_timeout" toml:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// TrustedProxies - адреса и сети (CIDR) обратных прокси, от которых
+	// принимается X-Forwarded-For. Пусто - заголовок не учитывается, и
+	// адрес клиента (лимиты сброса пароля, лог) - адрес соединения.
+	TrustedProxies []string   `yaml:"trusted_proxies" toml:"trusted_proxies" env:"SERVER_TRUSTED_PROXIES"`
+	TLS            TLSConfig  `yaml:"tls" toml:"tls"`
+	GRPC           GRPCConfig `yaml:"grpc" toml:"grpc"`
+}
+
+// GRPCConfig содержит настройки gRPC API
+type GRPCConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"GRPC_ENABLED"`
+	// Port - отдельный порт для gRPC; 0 - обслуживать gRPC на порту HTTP
+	Port       int  `yaml:"port" toml:"port" env:"GRPC_PORT"`
+	Reflection bool `yaml:"reflection" toml:"reflection" env:"GRPC_REFLECTION"`
+}
+
+// TLSConfig содержит настройки HTTPS и проверки клиентских сертификатов
+type TLSConfig struct {
+	CertFile       string   `yaml:"cert_file" toml:"cert_file" env:"TLS_CERT_FILE"`
+	KeyFile        string   `yaml:"key_file" toml:"key_file" env:"TLS_KEY_FILE"`
+	ClientCAFile   string   `yaml:"client_ca_file" toml:"client_ca_file" env:"TLS_CLIENT_CA_FILE"`
+	ClientAuth     string   `yaml:"client_auth" toml:"client_auth" env:"TLS_CLIENT_AUTH"`
+	RedirectPort   int      `yaml:"redirect_port" toml:"redirect_port" env:"TLS_REDIRECT_PORT"`
+	ReloadInterval Duration `yaml:"reload_interval" toml:"reload_interval" env:"TLS_RELOAD_INTERVAL"`
+}
+
+// DatabaseConfig содержит настройки подключения к БД
+type DatabaseConfig struct {
+	Host        string         `yaml:"host" toml:"host" env:"DB_HOST"`
+	Port        int            `yaml:"port" toml:"port" env:"DB_PORT"`
+	User        string         `yaml:"user" toml:"user" env:"DB_USER"`
+	Password    string         `yaml:"password" toml:"password" env:"DB_PASSWORD" secret:"true"`
+	Name        string         `yaml:"name" toml:"name" env:"DB_NAME"`
+	SSLMode     string         `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE"`
+	SSLRootCert string         `yaml:"sslrootcert" toml:"sslrootcert" env:"DB_SSLROOTCERT"`
+	SSLCert     string         `yaml:"sslcert" toml:"sslcert" env:"DB_SSLCERT"`
+	SSLKey      string         `yaml:"sslkey" toml:"sslkey" env:"DB_SSLKEY"`
+	Pool        PoolConfig     `yaml:"pool" toml:"pool"`
+	Replicas    ReplicasConfig `yaml:"replicas" toml:"replicas"`
+	System      SystemDBConfig `yaml:"system" toml:"system"`
+}
+
+// SystemDBConfig задает роль для операций по одноразовым токенам из писем
+// (подтверждение email, сброс пароля), которые не привязаны к арендатору.
+// Роль должна обходить row-level security (BYPASSRLS); у роли приложения
+// такого права нет, и обойти политики из ее соединений нельзя. Пустой
+// User - используется роль приложения (подходит, только если она сама
+// обходит политики, например суперпользователь при выключенном разделении).
+type SystemDBConfig struct {
+	User     string `yaml:"user" toml:"user" env:"DB_SYSTEM_USER"`
+	Password string `yaml:"password" toml:"password" env:"DB_SYSTEM_PASSWORD" secret:"true"`
+}
+
+// ReplicasConfig содержит настройки реплик для чтения
+type ReplicasConfig struct {
+	// DSNs - строки подключения к репликам; пусто - все читается из
+	// основной БД
+	DSNs []string `yaml:"dsns" toml:"dsns" env:"DB_REPLICA_DSNS" secret:"true"`
+	// MaxLag - наибольшее отставание реплики, 0 - без ограничения
+	MaxLag        Duration `yaml:"max_lag" toml:"max_lag" env:"DB_REPLICA_MAX_LAG"`
+	CheckInterval Duration `yaml:"check_interval" toml:"check_interval" env:"DB_REPLICA_CHECK_INTERVAL"`
+}
+
+// PoolConfig содержит настройки пула соединений
+type PoolConfig struct {
+	MaxOpenConns    int      `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns    int      `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetime Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME"`
+	ConnMaxIdleTime Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME"`
+}
+
+// GraphQLConfig содержит настройки GraphQL API
+type GraphQLConfig struct {
+	Enabled       bool `yaml:"enabled" toml:"enabled" env:"GRAPHQL_ENABLED"`
+	Playground    bool `yaml:"playground" toml:"playground" env:"GRAPHQL_PLAYGROUND"`
+	MaxDepth      int  `yaml:"max_depth" toml:"max_depth" env:"GRAPHQL_MAX_DEPTH"`
+	MaxComplexity int  `yaml:"max_complexity" toml:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY"`
+}
+
+// CacheConfig содержит настройки кэша пользователей
+type CacheConfig struct {
+	Enabled bool     `yaml:"enabled" toml:"enabled" env:"CACHE_ENABLED"`
+	Size    int      `yaml:"size" toml:"size" env:"CACHE_SIZE"`
+	TTL     Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL"`
+}
+
+// IdempotencyConfig содержит настройки обработки заголовка Idempotency-Key
+type IdempotencyConfig struct {
+	Enabled     bool     `yaml:"enabled" toml:"enabled" env:"IDEMPOTENCY_ENABLED"`
+	TTL         Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL"`
+	LockTimeout Duration `yaml:"lock_timeout" toml:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT"`
+	// MaxBodySize - наибольший размер тела запроса с Idempotency-Key в байтах
+	MaxBodySize int64 `yaml:"max_body_size" toml:"max_body_size" env:"IDEMPOTENCY_MAX_BODY_SIZE"`
+}
+
+// VerificationConfig содержит настройки подтверждения email
+type VerificationConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled" env:"VERIFICATION_ENABLED"`
+	Secret  string `yaml:"secret" toml:"secret" env:"VERIFICATION_SECRET" secret:"true"`
+	// URL - адрес подтверждения в письме; токен добавляется параметром token
+	URL      string   `yaml:"url" toml:"url" env:"VERIFICATION_URL"`
+	TokenTTL Duration `yaml:"token_ttl" toml:"token_ttl" env:"VERIFICATION_TOKEN_TTL"`
+}
+
+// PasswordResetConfig содержит настройки восстановления пароля
+type PasswordResetConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"PASSWORD_RESET_ENABLED"`
+	// URL - страница сброса пароля в письме; токен добавляется параметром token
+	URL         string   `yaml:"url" toml:"url" env:"PASSWORD_RESET_URL"`
+	TokenTTL    Duration `yaml:"token_ttl" toml:"token_ttl" env:"PASSWORD_RESET_TOKEN_TTL"`
+	EmailLimit  int      `yaml:"email_limit" toml:"email_limit" env:"PASSWORD_RESET_EMAIL_LIMIT"`
+	IPLimit     int      `yaml:"ip_limit" toml:"ip_limit" env:"PASSWORD_RESET_IP_LIMIT"`
+	LimitWindow Duration `yaml:"limit_window" toml:"limit_window" env:"PASSWORD_RESET_LIMIT_WINDOW"`
+}
+
+// MailerConfig содержит настройки отправки писем
+type MailerConfig struct {
+	// Driver - способ отправки: log (в stderr), file или smtp
+	Driver  string     `yaml:"driver" toml:"driver" env:"MAILER_DRIVER"`
+	From    string     `yaml:"from" toml:"from" env:"MAILER_FROM"`
+	File    string     `yaml:"file" toml:"file" env:"MAILER_FILE"`
+	Timeout Duration   `yaml:"timeout" toml:"timeout" env:"MAILER_TIMEOUT"`
+	SMTP    SMTPConfig `yaml:"smtp" toml:"smtp"`
+}
+
+// SMTPConfig содержит настройки SMTP сервера
+type SMTPConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" toml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" toml:"username" env:"SMTP_USERNAME"`
+	Password string `yaml:"password" toml:"password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// AvatarConfig содержит настройки загрузки аватаров
+type AvatarConfig struct {
+	// MaxSize - максимальный размер загружаемого файла в байтах
+	MaxSize int64 `yaml:"max_size" toml:"max_size" env:"AVATAR_MAX_SIZE"`
+}
+
+// BlobConfig содержит настройки хранилища файлов
+type BlobConfig struct {
+	// Driver - хранилище: local (каталог на диске) или s3
+	Driver string `yaml:"driver" toml:"driver" env:"BLOB_DRIVER"`
+	Dir    string `yaml:"dir" toml:"dir" env:"BLOB_DIR"`
+	// BaseURL - адрес, по которому API отдает файлы из Dir (driver local)
+	BaseURL string   `yaml:"base_url" toml:"base_url" env:"BLOB_BASE_URL"`
+	S3      S3Config `yaml:"s3" toml:"s3"`
+}
+
+// S3Config содержит настройки S3-совместимого хранилища
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint" toml:"endpoint" env:"S3_ENDPOINT"`
+	Region    string `yaml:"region" toml:"region" env:"S3_REGION"`
+	Bucket    string `yaml:"bucket" toml:"bucket" env:"S3_BUCKET"`
+	AccessKey string `yaml:"access_key" toml:"access_key" env:"S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" toml:"secret_key" env:"S3_SECRET_KEY" secret:"true"`
+	// PathStyle - адресовать бакет путем, а не поддоменом (MinIO)
+	PathStyle bool `yaml:"path_style" toml:"path_style" env:"S3_PATH_STYLE"`
+	// PublicURL - базовый адрес файлов для клиентов (CDN); по умолчанию - адрес бакета
+	PublicURL string `yaml:"public_url" toml:"public_url" env:"S3_PUBLIC_URL"`
+}
+
+// OutboxConfig содержит настройки публикации событий из outbox
+type OutboxConfig struct {
+	RelayEnabled    bool     `yaml:"relay_enabled" toml:"relay_enabled" env:"OUTBOX_RELAY_ENABLED"`
+	Listen          bool     `yaml:"listen" toml:"listen" env:"OUTBOX_LISTEN"`
+	BatchSize       int      `yaml:"batch_size" toml:"batch_size" env:"OUTBOX_BATCH_SIZE"`
+	PollInterval    Duration `yaml:"poll_interval" toml:"poll_interval" env:"OUTBOX_POLL_INTERVAL"`
+	Retention       Duration `yaml:"retention" toml:"retention" env:"OUTBOX_RETENTION"`
+	CleanupInterval Duration `yaml:"cleanup_interval" toml:"cleanup_interval" env:"OUTBOX_CLEANUP_INTERVAL"`
+	File            string   `yaml:"file" toml:"file" env:"OUTBOX_FILE"`
+}
+
+// SSEConfig содержит настройки потока событий GET /api/v1/users/events
+type SSEConfig struct {
+	LogSize      int      `yaml:"log_size" toml:"log_size" env:"SSE_LOG_SIZE"`
+	ClientBuffer int      `yaml:"client_buffer" toml:"client_buffer" env:"SSE_CLIENT_BUFFER"`
+	Heartbeat    Duration `yaml:"heartbeat" toml:"heartbeat" env:"SSE_HEARTBEAT"`
+}
+
+// StatsConfig содержит настройки GET /api/v1/users/stats
+type StatsConfig struct {
+	// CacheTTL - сколько результат кэшируется на сервере и клиентом
+	// (Cache-Control: max-age); 0 отключает кэширование
+	CacheTTL Duration `yaml:"cache_ttl" toml:"cache_ttl" env:"STATS_CACHE_TTL"`
+}
+
+// WebhooksConfig содержит настройки доставки вебхуков
+type WebhooksConfig struct {
+	Enabled      bool     `yaml:"enabled" toml:"enabled" env:"WEBHOOKS_ENABLED"`
+	Workers      int      `yaml:"workers" toml:"workers" env:"WEBHOOKS_WORKERS"`
+	BatchSize    int      `yaml:"batch_size" toml:"batch_size" env:"WEBHOOKS_BATCH_SIZE"`
+	PollInterval Duration `yaml:"poll_interval" toml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL"`
+	Timeout      Duration `yaml:"timeout" toml:"timeout" env:"WEBHOOKS_TIMEOUT"`
+	MaxAttempts  int      `yaml:"max_attempts" toml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS"`
+	BackoffBase  Duration `yaml:"backoff_base" toml:"backoff_base" env:"WEBHOOKS_BACKOFF_BASE"`
+	BackoffMax   Duration `yaml:"backoff_max" toml:"backoff_max" env:"WEBHOOKS_BACKOFF_MAX"`
+}
+
+// LogConfig содержит настройки логирования
+type LogConfig struct {
+	Level  string `yaml:"level" toml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT"`
+}
+
+// AuthConfig содержит настройки аутентификации
+type AuthConfig struct {
+	Enabled         bool     `yaml:"enabled" toml:"enabled" env:"AUTH_ENABLED"`
+	JWTSecret       string   `yaml:"jwt_secret" toml:"jwt_secret" env:"AUTH_JWT_SECRET" secret:"true"`
+	AccessTokenTTL  Duration `yaml:"access_token_ttl" toml:"access_token_ttl" env:"AUTH_ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL Duration `yaml:"refresh_token_ttl" toml:"refresh_token_ttl" env:"AUTH_REFRESH_TOKEN_TTL"`
+}
+
+// TenancyConfig содержит настройки разделения данных по арендаторам
+type TenancyConfig struct {
+	Enabled bool `yaml:"enabled" toml:"enabled" env:"TENANCY_ENABLED"`
+	// Header - заголовок с ID арендатора, который принимается только от
+	// доверенных вызывающих
+	Header string `yaml:"header" toml:"header" env:"TENANCY_HEADER"`
+	// TrustedNetworks - сети (CIDR) доверенных вызывающих. Клиенты с
+	// сертификатом, проверенным по server.tls.client_ca_file, доверенные
+	// всегда.
+	TrustedNetworks []string `yaml:"trusted_networks" toml:"trusted_networks" env:"TENANCY_TRUSTED_NETWORKS"`
+	// StatusCacheTTL - сколько кэшируется состояние арендатора
+	StatusCacheTTL Duration `yaml:"status_cache_ttl" toml:"status_cache_ttl" env:"TENANCY_STATUS_CACHE_TTL"`
+}
+
+// ValidationConfig содержит правила для email пользователей. Домены
+// действуют вместе с поддоменами.
+type ValidationConfig struct {
+	// BlockDisposable запрещает адреса одноразовой почты (встроенный список
+	// и DisposableDomainsFile)
+	BlockDisposable bool `yaml:"block_disposable" toml:"block_disposable" env:"VALIDATION_BLOCK_DISPOSABLE"`
+	// DisposableDomainsFile - файл с дополнительными доменами одноразовой
+	// почты, по одному в строке
+	DisposableDomainsFile string   `yaml:"disposable_domains_file" toml:"disposable_domains_file" env:"VALIDATION_DISPOSABLE_DOMAINS_FILE"`
+	BlockedDomains        []string `yaml:"blocked_domains" toml:"blocked_domains" env:"VALIDATION_BLOCKED_DOMAINS"`
+	BlockedEmails         []string `yaml:"blocked_emails" toml:"blocked_emails" env:"VALIDATION_BLOCKED_EMAILS"`
+}
+
+// Default возвращает конфигурацию по умолчанию
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Host:            "0.0.0.0",
+			Port:            8080,
+			ReadTimeout:     Duration(15 * time.Second),
+			WriteTimeout:    Duration(15 * time.Second),
+			ShutdownTimeout: Duration(10 * time.Second),
+			TLS: TLSConfig{
+				ClientAuth:     "none",
+				ReloadInterval: Duration(30 * time.Second),
+			},
+		},
+		Database: DatabaseConfig{
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Password: "postgres",
+			Name:     "userdb",
+			SSLMode:  "disable",
+			Pool: PoolConfig{
+				MaxOpenConns:    25,
+				MaxIdleConns:    10,
+				ConnMaxLifetime: Duration(5 * time.Minute),
+			},
+			Replicas: ReplicasConfig{
+				MaxLag:        Duration(5 * time.Second),
+				CheckInterval: Duration(5 * time.Second),
+			},
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:       true,
+			Playground:    true,
+			MaxDepth:      5,
+			MaxComplexity: 1000,
+		},
+		Cache: CacheConfig{
+			Enabled: true,
+			Size:    10000,
+			TTL:     Duration(5 * time.Minute),
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:     true,
+			TTL:         Duration(24 * time.Hour),
+			LockTimeout: Duration(30 * time.Second),
+			MaxBodySize: 1 << 20,
+		},
+		Verification: VerificationConfig{
+			URL:      "http://localhost:8080/api/v1/verify-email",
+			TokenTTL: Duration(24 * time.Hour),
+		},
+		PasswordReset: PasswordResetConfig{
+			URL:         "http://localhost:8080/reset-password",
+			TokenTTL:    Duration(1 * time.Hour),
+			EmailLimit:  3,
+			IPLimit:     20,
+			LimitWindow: Duration(1 * time.Hour),
+		},
+		Mailer: MailerConfig{
+			Driver:  "log",
+			From:    "User API <no-reply@localhost>",
+			Timeout: Duration(10 * time.Second),
+			SMTP: SMTPConfig{
+				Port: 587,
+			},
+		},
+		Avatar: AvatarConfig{
+			MaxSize: 5 << 20,
+		},
+		Blob: BlobConfig{
+			Driver:  "local",
+			Dir:     "./data/blobs",
+			BaseURL: "/media",
+			S3: S3Config{
+				Region: "us-east-1",
+			},
+		},
+		Outbox: OutboxConfig{
+			RelayEnabled:    true,
+			Listen:          true,
+			BatchSize:       100,
+			PollInterval:    Duration(1 * time.Second),
+			Retention:       Duration(24 * time.Hour),
+			CleanupInterval: Duration(1 * time.Hour),
+		},
+		SSE: SSEConfig{
+			LogSize:      1000,
+			ClientBuffer: 64,
+			Heartbeat:    Duration(15 * time.Second),
+		},
+		Stats: StatsConfig{
+			CacheTTL: Duration(30 * time.Second),
+		},
+		Webhooks: WebhooksConfig{
+			Enabled:      true,
+			Workers:      4,
+			BatchSize:    50,
+			PollInterval: Duration(5 * time.Second),
+			Timeout:      Duration(10 * time.Second),
+			MaxAttempts:  8,
+			BackoffBase:  Duration(10 * time.Second),
+			BackoffMax:   Duration(1 * time.Hour),
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "text",
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  Duration(15 * time.Minute),
+			RefreshTokenTTL: Duration(7 * 24 * time.Hour),
+		},
+		Tenancy: TenancyConfig{
+			Header:         "X-Tenant-ID",
+			StatusCacheTTL: Duration(30 * time.Second),
+		},
+	}
+}
+
+var (
+	sslModes    = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+	clientAuths = []string{"none", "optional", "require"}
+	logLevels   = []string{"debug", "info", "warn", "error"}
+	logFormats  = []string{"text", "json"}
+	mailDrivers = []string{"log", "file", "smtp"}
+	blobDrivers = []string{"local", "s3"}
+)
+
+// Validate проверяет конфигурацию и возвращает все найденные ошибки
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.Server.Port), "server.port: must be between 1 and 65535, got %d", c.Server.Port)
+	check(c.Server.ReadTimeout >= 0, "server.read_timeout: must not be negative")
+	check(c.Server.WriteTimeout >= 0, "server.write_timeout: must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout: must be positive")
+	_, proxiesErr := tenant.ParseNetworks(c.Server.TrustedProxies)
+	check(proxiesErr == nil, "server.trusted_proxies: %v", proxiesErr)
+
+	tlsCfg := c.Server.TLS
+	check((tlsCfg.CertFile == "") == (tlsCfg.KeyFile == ""), "server.tls: cert_file and key_file must be set together")
+	check(fileExists(tlsCfg.CertFile), "server.tls.cert_file: file %q does not exist", tlsCfg.CertFile)
+	check(fileExists(tlsCfg.KeyFile), "server.tls.key_file: file %q does not exist", tlsCfg.KeyFile)
+	check(fileExists(tlsCfg.ClientCAFile), "server.tls.client_ca_file: file %q does not exist", tlsCfg.ClientCAFile)
+	check(oneOf(tlsCfg.ClientAuth, clientAuths), "server.tls.client_auth: must be one of %s, got %q", strings.Join(clientAuths, ", "), tlsCfg.ClientAuth)
+	check(tlsCfg.ClientAuth == "none" || tlsCfg.ClientCAFile != "", "server.tls.client_auth: %q requires client_ca_file", tlsCfg.ClientAuth)
+	check(tlsCfg.ClientCAFile == "" || tlsCfg.Enabled(), "server.tls.client_ca_file: requires cert_file and key_file")
+	check(tlsCfg.RedirectPort == 0 || validPort(tlsCfg.RedirectPort), "server.tls.redirect_port: must be between 1 and 65535, got %d", tlsCfg.RedirectPort)
+	check(tlsCfg.RedirectPort == 0 || tlsCfg.Enabled(), "server.tls.redirect_port: requires cert_file and key_file")
+	check(tlsCfg.RedirectPort != c.Server.Port, "server.tls.redirect_port: must differ from server.port")
+	check(tlsCfg.ReloadInterval > 0, "server.tls.reload_interval: must be positive")
+
+	grpcCfg := c.Server.GRPC
+	check(grpcCfg.Port == 0 || validPort(grpcCfg.Port), "server.grpc.port: must be between 1 and 65535, got %d", grpcCfg.Port)
+	check(grpcCfg.Port == 0 || (grpcCfg.Port != c.Server.Port && grpcCfg.Port != tlsCfg.RedirectPort),
+		"server.grpc.port: must differ from server.port and server.tls.redirect_port")
+
+	check(c.Database.Host != "", "database.host: must not be empty")
+	check(validPort(c.Database.Port), "database.port: must be between 1 and 65535, got %d", c.Database.Port)
+	check(c.Database.User != "", "database.user: must not be empty")
+	check(c.Database.Name != "", "database.name: must not be empty")
+	check(oneOf(c.Database.SSLMode, sslModes), "database.sslmode: must be one of %s, got %q", strings.Join(sslModes, ", "), c.Database.SSLMode)
+	check(fileExists(c.Database.SSLRootCert), "database.sslrootcert: file %q does not exist", c.Database.SSLRootCert)
+	check(fileExists(c.Database.SSLCert), "database.sslcert: file %q does not exist", c.Database.SSLCert)
+	check(fileExists(c.Database.SSLKey), "database.sslkey: file %q does not exist", c.Database.SSLKey)
+	check(c.Database.System.User != c.Database.User, "database.system.user: must differ from database.user")
+
+	pool := c.Database.Pool
+	check(pool.MaxOpenConns >= 0, "database.pool.max_open_conns: must not be negative")
+	check(pool.MaxIdleConns >= 0, "database.pool.max_idle_conns: must not be negative")
+	check(pool.MaxOpenConns == 0 || pool.MaxIdleConns <= pool.MaxOpenConns,
+		"database.pool.max_idle_conns: must not exceed max_open_conns (%d > %d)", pool.MaxIdleConns, pool.MaxOpenConns)
+	check(pool.ConnMaxLifetime >= 0, "database.pool.conn_max_lifetime: must not be negative")
+	check(pool.ConnMaxIdleTime >= 0, "database.pool.conn_max_idle_time: must not be negative")
+
+	replicas := c.Database.Replicas
+	for i, dsn := range replicas.DSNs {
+		check(strings.TrimSpace(dsn) != "", "database.replicas.dsns[%d]: must not be empty", i)
+	}
+	check(replicas.MaxLag >= 0, "database.replicas.max_lag: must not be negative")
+	check(len(replicas.DSNs) == 0 || replicas.CheckInterval > 0, "database.replicas.check_interval: must be positive")
+
+	check(c.GraphQL.MaxDepth > 0, "graphql.max_depth: must be positive")
+	check(c.GraphQL.MaxComplexity > 0, "graphql.max_complexity: must be positive")
+
+	if c.Cache.Enabled {
+		check(c.Cache.Size > 0, "cache.size: must be positive")
+		check(c.Cache.TTL > 0, "cache.ttl: must be positive")
+	}
+
+	if c.Idempotency.Enabled {
+		check(c.Idempotency.TTL > 0, "idempotency.ttl: must be positive")
+		check(c.Idempotency.LockTimeout > 0, "idempotency.lock_timeout: must be positive")
+		check(c.Idempotency.MaxBodySize > 0, "idempotency.max_body_size: must be positive")
+	}
+
+	if c.Verification.Enabled {
+		check(len(c.Verification.Secret) >= 32, "verification.secret: must be at least 32 bytes when verification is enabled")
+		check(c.Verification.TokenTTL > 0, "verification.token_ttl: must be positive")
+		check(validURL(c.Verification.URL), "verification.url: must be an absolute http(s) URL, got %q", c.Verification.URL)
+	}
+
+	if c.PasswordReset.Enabled {
+		pr := c.PasswordReset
+		check(validURL(pr.URL), "password_reset.url: must be an absolute http(s) URL, got %q", pr.URL)
+		check(pr.TokenTTL > 0, "password_reset.token_ttl: must be positive")
+		check(pr.EmailLimit > 0, "password_reset.email_limit: must be positive")
+		check(pr.IPLimit > 0, "password_reset.ip_limit: must be positive")
+		check(pr.LimitWindow > 0, "password_reset.limit_window: must be positive")
+	}
+
+	m := c.Mailer
+	check(oneOf(m.Driver, mailDrivers), "mailer.driver: must be one of %s, got %q", strings.Join(mailDrivers, ", "), m.Driver)
+	_, err := mail.ParseAddress(m.From)
+	check(err == nil, "mailer.from: invalid address %q", m.From)
+	check(m.Timeout > 0, "mailer.timeout: must be positive")
+	check(m.Driver != "file" || m.File != "", "mailer.file: must be set for driver file")
+	if m.Driver == "smtp" {
+		check(m.SMTP.Host != "", "mailer.smtp.host: must be set for driver smtp")
+		check(validPort(m.SMTP.Port), "mailer.smtp.port: must be between 1 and 65535, got %d", m.SMTP.Port)
+	}
+
+	check(c.Avatar.MaxSize > 0, "avatar.max_size: must be positive")
+
+	b := c.Blob
+	check(oneOf(b.Driver, blobDrivers), "blob.driver: must be one of %s, got %q", strings.Join(blobDrivers, ", "), b.Driver)
+	switch b.Driver {
+	case "local":
+		check(b.Dir != "", "blob.dir: must be set for driver local")
+		check(strings.HasPrefix(b.BaseURL, "/") || validURL(b.BaseURL), "blob.base_url: must be a path or an absolute http(s) URL, got %q", b.BaseURL)
+	case "s3":
+		check(validURL(b.S3.Endpoint), "blob.s3.endpoint: must be an absolute http(s) URL, got %q", b.S3.Endpoint)
+		check(b.S3.Region != "", "blob.s3.region: must not be empty")
+		check(b.S3.Bucket != "", "blob.s3.bucket: must be set for driver s3")
+		check(b.S3.AccessKey != "" && b.S3.SecretKey != "", "blob.s3: access_key and secret_key must be set for driver s3")
+		check(b.S3.PublicURL == "" || validURL(b.S3.PublicURL), "blob.s3.public_url: must be an absolute http(s) URL, got %q", b.S3.PublicURL)
+	}
+
+	ob := c.Outbox
+	check(ob.BatchSize > 0, "outbox.batch_size: must be positive")
+	check(ob.PollInterval > 0, "outbox.poll_interval: must be positive")
+	check(ob.Retention >= 0, "outbox.retention: must not be negative")
+	check(ob.CleanupInterval > 0, "outbox.cleanup_interval: must be positive")
+
+	check(c.SSE.LogSize >= 0, "sse.log_size: must not be negative")
+	check(c.SSE.ClientBuffer > 0, "sse.client_buffer: must be positive")
+	check(c.SSE.Heartbeat > 0, "sse.heartbeat: must be positive")
+
+	check(c.Stats.CacheTTL >= 0, "stats.cache_ttl: must not be negative")
+
+	wh := c.Webhooks
+	check(wh.Workers > 0, "webhooks.workers: must be positive")
+	check(wh.BatchSize > 0, "webhooks.batch_size: must be positive")
+	check(wh.PollInterval > 0, "webhooks.poll_interval: must be positive")
+	check(wh.Timeout > 0, "webhooks.timeout: must be positive")
+	check(wh.MaxAttempts > 0, "webhooks.max_attempts: must be positive")
+	check(wh.BackoffBase > 0, "webhooks.backoff_base: must be positive")
+	check(wh.BackoffMax >= wh.BackoffBase, "webhooks.backoff_max: must not be less than backoff_base")
+
+	check(oneOf(c.Log.Level, logLevels), "log.level: must be one of %s, got %q", strings.Join(logLevels, ", "), c.Log.Level)
+	check(oneOf(c.Log.Format, logFormats), "log.format: must be one of %s, got %q", strings.Join(logFormats, ", "), c.Log.Format)
+
+	if c.Auth.Enabled {
+		check(len(c.Auth.JWTSecret) >= 32, "auth.jwt_secret: must be at least 32 bytes when auth is enabled")
+		check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl: must be positive")
+		check(c.Auth.RefreshTokenTTL > c.Auth.AccessTokenTTL, "auth.refresh_token_ttl: must be longer than access_token_ttl")
+	}
+
+	if c.Tenancy.Enabled {
+		_, err := tenant.ParseNetworks(c.Tenancy.TrustedNetworks)
+		check(err == nil, "tenancy.trusted_networks: %v", err)
+		check(c.Tenancy.Header != "", "tenancy.header: must not be empty")
+		check(c.Tenancy.StatusCacheTTL >= 0, "tenancy.status_cache_ttl: must not be negative")
+		check(c.Auth.Enabled || len(c.Tenancy.TrustedNetworks) > 0 || c.Server.TLS.ClientCAFile != "",
+			"tenancy: requires auth.enabled, tenancy.trusted_networks or server.tls.client_ca_file to identify tenants")
+		// Роль приложения при разделении не обходит row-level security,
+		// поэтому операциям по токенам из писем нужна отдельная роль
+		check(!(c.Verification.Enabled || c.PasswordReset.Enabled) || c.Database.System.User != "",
+			"database.system.user: required with tenancy for verification and password_reset")
+	}
+
+	check(fileExists(c.Validation.DisposableDomainsFile), "validation.disposable_domains_file: file %q does not exist", c.Validation.DisposableDomainsFile)
+
+	return errors.Join(errs...)
+}
+
+// DB возвращает настройки в формате пакета database
+func (c DatabaseConfig) DB() database.Config {
+	return database.Config{
+		Host:            c.Host,
+		Port:            c.Port,
+		User:            c.User,
+		Password:        c.Password,
+		DBName:          c.Name,
+		SSLMode:         c.SSLMode,
+		SSLRootCert:     c.SSLRootCert,
+		SSLCert:         c.SSLCert,
+		SSLKey:          c.SSLKey,
+		MaxOpenConns:    c.Pool.MaxOpenConns,
+		MaxIdleConns:    c.Pool.MaxIdleConns,
+		ConnMaxLifetime: c.Pool.ConnMaxLifetime.Std(),
+		ConnMaxIdleTime: c.Pool.ConnMaxIdleTime.Std(),
+		ReplicaDSNs:     c.Replicas.DSNs,
+		MaxReplicaLag:   c.Replicas.MaxLag.Std(),
+	}
+}
+
+// SystemDB возвращает настройки подключения к основной БД под ролью
+// database.system
+func (c DatabaseConfig) SystemDB() database.Config {
+	cfg := c.DB()
+	cfg.User, cfg.Password = c.System.User, c.System.Password
+	cfg.ReplicaDSNs = nil
+	return cfg
+}
+
+// Addr возвращает адрес, на котором слушает HTTP сервер
+func (c ServerConfig) Addr() string {
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+// Enabled сообщает, нужно ли обслуживать HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// ClientAuthType возвращает режим проверки клиентских сертификатов
+func (c TLSConfig) ClientAuthType() tls.ClientAuthType {
+	switch c.ClientAuth {
+	case "optional":
+		return tls.VerifyClientCertIfGiven
+	case "require":
+		return tls.RequireAndVerifyClientCert
+	default:
+		return tls.NoClientCert
+	}
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+// fileExists возвращает true и для пустого пути, чтобы необязательные
+// файлы проверялись только если заданы
+func fileExists(path string) bool {
+	if path == "" {
+		return true
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+func oneOf(value string, allowed []string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+	return false
+}
+
+// Duration - time.Duration, который читается из строк вида "5s" или "10m"
+type Duration time.Duration
+
+// Std возвращает значение как time.Duration
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String реализует fmt.Stringer
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText реализует encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText реализует encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
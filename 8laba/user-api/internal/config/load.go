@@ -0,0 +1,251 @@
+package config
+
+import (
+	"bytes"
+	"encoding"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "******"
+
+// Load собирает конфигурацию из значений по умолчанию, файла, переменных
+// окружения и флагов командной строки (в порядке возрастания приоритета).
+// Путь к файлу задается флагом -config или переменной CONFIG_FILE.
+// Второе возвращаемое значение сообщает, был ли передан флаг -print-config.
+func Load(args []string) (*Config, bool, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("user-api", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to YAML or TOML config file (env CONFIG_FILE)")
+	printConfig := fs.Bool("print-config", false, "print effective configuration with secrets redacted and exit")
+
+	// Значения флагов применяются после файла и окружения, поэтому сначала
+	// только запоминаем их
+	type flagValue struct{ path, value string }
+	var flagValues []flagValue
+	err := walk(reflect.ValueOf(cfg).Elem(), "", func(_ reflect.Value, sf reflect.StructField, path string) error {
+		usage := "sets " + path
+		if env := sf.Tag.Get("env"); env != "" {
+			usage += " (env " + env + ")"
+		}
+		fs.Func(path, usage, func(s string) error {
+			flagValues = append(flagValues, flagValue{path: path, value: s})
+			return nil
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, false, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(cfg, *configFile); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := loadEnv(cfg); err != nil {
+		return nil, false, err
+	}
+
+	for _, fv := range flagValues {
+		err := walk(reflect.ValueOf(cfg).Elem(), "", func(field reflect.Value, _ reflect.StructField, path string) error {
+			if path != fv.path {
+				return nil
+			}
+			if err := setValue(field, fv.value); err != nil {
+				return fmt.Errorf("flag -%s: invalid value %q: %w", path, fv.value, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, false, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return cfg, *printConfig, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(cfg); err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (want .yaml, .yml or .toml)", ext)
+	}
+
+	return nil
+}
+
+// loadEnv применяет переменные окружения. Для каждой переменной KEY
+// поддерживается вариант KEY_FILE с путем к файлу, содержащему значение
+// (например, Docker secrets); он имеет приоритет над KEY.
+func loadEnv(cfg *Config) error {
+	return walk(reflect.ValueOf(cfg).Elem(), "", func(field reflect.Value, sf reflect.StructField, _ string) error {
+		key := sf.Tag.Get("env")
+		if key == "" {
+			return nil
+		}
+
+		if file := os.Getenv(key + "_FILE"); file != "" {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return fmt.Errorf("env %s_FILE: %w", key, err)
+			}
+			value := strings.TrimRight(string(data), "\r\n")
+			if err := setValue(field, value); err != nil {
+				return fmt.Errorf("env %s_FILE: invalid value in %s: %w", key, file, err)
+			}
+			return nil
+		}
+
+		if value, ok := os.LookupEnv(key); ok && value != "" {
+			if err := setValue(field, value); err != nil {
+				return fmt.Errorf("env %s: invalid value %q: %w", key, value, err)
+			}
+		}
+		return nil
+	})
+}
+
+// walk обходит все конечные поля конфигурации, передавая путь вида
+// "database.pool.max_open_conns"
+func walk(v reflect.Value, prefix string, fn func(field reflect.Value, sf reflect.StructField, path string) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name := strings.Split(sf.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct {
+			if err := walk(field, path, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(field, sf, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setValue(field reflect.Value, s string) error {
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		if field.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported slice type %s", field.Type())
+		}
+		var items []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+	return nil
+}
+
+// Redacted возвращает копию конфигурации со скрытыми секретами
+func (c *Config) Redacted() *Config {
+	cp := *c
+	_ = walk(reflect.ValueOf(&cp).Elem(), "", func(field reflect.Value, sf reflect.StructField, _ string) error {
+		if sf.Tag.Get("secret") != "true" {
+			return nil
+		}
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() != "" {
+				field.SetString(redacted)
+			}
+		case reflect.Slice:
+			masked := make([]string, field.Len())
+			for i := range masked {
+				masked[i] = redacted
+			}
+			field.Set(reflect.ValueOf(masked))
+		}
+		return nil
+	})
+	return &cp
+}
+
+// String возвращает конфигурацию в формате YAML со скрытыми секретами
+func (c *Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<failed to marshal config: %v>", err)
+	}
+	return string(out)
+}
@@ -0,0 +1,330 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Probe проверяет реплику и возвращает ее отставание от основной БД
+type Probe func(ctx context.Context, primary, replica *sqlx.DB) (time.Duration, error)
+
+// ClusterOptions - настройки маршрутизации чтения по репликам
+type ClusterOptions struct {
+	// MaxLag - наибольшее допустимое отставание реплики; реплика с большим
+	// отставанием исключается до следующей проверки. 0 - без ограничения.
+	MaxLag time.Duration
+	// Probe проверяет реплику; nil - ReplicationLag
+	Probe Probe
+}
+
+// Cluster - основная БД и реплики для чтения. Запись и чтение, которым
+// нужны только что записанные данные, идут в основную БД (Writer), прочее
+// чтение - по кругу в исправные реплики (Reader). Реплика считается
+// исправной после успешной проверки (Check) с отставанием не больше
+// MaxLag; пока исправных реплик нет, читается основная БД.
+type Cluster struct {
+	primary  *sqlx.DB
+	replicas []*replica
+	opts     ClusterOptions
+	next     atomic.Uint64
+}
+
+type replica struct {
+	name string
+	db   *sqlx.DB
+
+	mu      sync.Mutex
+	healthy bool
+	lag     time.Duration
+	err     error
+	checked time.Time
+}
+
+// ReplicaStatus - состояние реплики после последней проверки
+type ReplicaStatus struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Lag     time.Duration `json:"lag_ns"`
+	Error   string        `json:"error,omitempty"`
+	Checked time.Time     `json:"checked_at"`
+}
+
+// NewCluster объединяет основную БД и реплики. Реплики не используются до
+// первой успешной проверки.
+func NewCluster(primary *sqlx.DB, replicas []*sqlx.DB, opts ClusterOptions) *Cluster {
+	if opts.Probe == nil {
+		opts.Probe = ReplicationLag
+	}
+	c := &Cluster{primary: primary, opts: opts}
+	for i, db := range replicas {
+		c.replicas = append(c.replicas, &replica{name: fmt.Sprintf("replica-%d", i+1), db: db})
+	}
+	return c
+}
+
+// NewPostgresCluster подключается к основной БД из cfg и к репликам из
+// cfg.ReplicaDSNs с теми же настройками пула. Недоступная при запуске
+// реплика не мешает старту: она включится после успешной проверки. С
+// cfg.RequireRLS проверка реплики отклоняет роль, обходящую row-level
+// security.
+func NewPostgresCluster(cfg Config) (*Cluster, error) {
+	primary, err := NewPostgresDB(cfg)
+	if err != nil {
+		return nil, err
+	}
+	replicas := make([]*sqlx.DB, 0, len(cfg.ReplicaDSNs))
+	for i, dsn := range cfg.ReplicaDSNs {
+		db, err := sqlx.Open("postgres", dsn)
+		if err != nil {
+			for _, opened := range replicas {
+				opened.Close()
+			}
+			primary.Close()
+			return nil, fmt.Errorf("failed to open replica %d: %w", i+1, err)
+		}
+		configurePool(db, cfg)
+		replicas = append(replicas, db)
+	}
+	probe := ReplicationLag
+	if cfg.RequireRLS {
+		probe = WithoutRLSBypass(probe)
+	}
+	return NewCluster(primary, replicas, ClusterOptions{MaxLag: cfg.MaxReplicaLag, Probe: probe}), nil
+}
+
+// Primary возвращает основную БД
+func (c *Cluster) Primary() *sqlx.DB {
+	return c.primary
+}
+
+// Writer возвращает основную БД для записи и отмечает сессию ctx (см.
+// WithSession): дальнейшее чтение в ней тоже пойдет в основную БД
+func (c *Cluster) Writer(ctx context.Context) *sqlx.DB {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+	return c.primary
+}
+
+// Reader возвращает БД для чтения: следующую исправную реплику или
+// основную БД, если сессия ctx уже писала, чтение из основной БД
+// запрошено явно (WithPrimary) или исправных реплик нет
+func (c *Cluster) Reader(ctx context.Context) *sqlx.DB {
+	if len(c.replicas) == 0 || usesPrimary(ctx) {
+		return c.primary
+	}
+	start := c.next.Add(1)
+	for i := range c.replicas {
+		r := c.replicas[(start+uint64(i))%uint64(len(c.replicas))]
+		r.mu.Lock()
+		healthy := r.healthy
+		r.mu.Unlock()
+		if healthy {
+			return r.db
+		}
+	}
+	return c.primary
+}
+
+// Check проверяет все реплики одновременно и исключает те, что не
+// отвечают или отстают больше MaxLag
+func (c *Cluster) Check(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, r := range c.replicas {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.check(ctx, r)
+		}()
+	}
+	wg.Wait()
+}
+
+func (c *Cluster) check(ctx context.Context, r *replica) {
+	lag, err := c.opts.Probe(ctx, c.primary, r.db)
+	if err == nil && c.opts.MaxLag > 0 && lag > c.opts.MaxLag {
+		err = fmt.Errorf("replication lag %s exceeds %s", lag.Round(time.Millisecond), c.opts.MaxLag)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if healthy := err == nil; healthy != r.healthy {
+		if healthy {
+			log.Printf("Database: %s is back in rotation", r.name)
+		} else {
+			log.Printf("Database: %s removed from rotation: %v", r.name, err)
+		}
+	}
+	r.healthy, r.lag, r.err, r.checked = err == nil, lag, err, time.Now()
+}
+
+// Run проверяет реплики сразу и затем каждые interval до отмены ctx
+func (c *Cluster) Run(ctx context.Context, interval time.Duration) {
+	if len(c.replicas) == 0 {
+		return
+	}
+	c.Check(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Check(ctx)
+		}
+	}
+}
+
+// Replicas возвращает состояние реплик
+func (c *Cluster) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(c.replicas))
+	for i, r := range c.replicas {
+		r.mu.Lock()
+		statuses[i] = ReplicaStatus{Name: r.name, Healthy: r.healthy, Lag: r.lag, Checked: r.checked}
+		if r.err != nil {
+			statuses[i].Error = r.err.Error()
+		}
+		r.mu.Unlock()
+	}
+	return statuses
+}
+
+// Close закрывает основную БД и реплики
+func (c *Cluster) Close() error {
+	errs := []error{c.primary.Close()}
+	for _, r := range c.replicas {
+		errs = append(errs, r.db.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ReplicationLag проверяет, что replica - реплика PostgreSQL, и возвращает
+// ее отставание от primary (см. ReplayLag). Позиция WAL основной БД
+// читается до состояния реплики, поэтому реплика, успевшая ее догнать,
+// не считается отстающей.
+func ReplicationLag(ctx context.Context, primary, replica *sqlx.DB) (time.Duration, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var current string
+	if err := primary.GetContext(ctx, &current, "SELECT pg_current_wal_lsn()::text"); err != nil {
+		return 0, fmt.Errorf("failed to read primary WAL position: %w", err)
+	}
+	written, err := ParseLSN(current)
+	if err != nil {
+		return 0, err
+	}
+
+	var state struct {
+		Recovery   bool           `db:"recovery"`
+		Replayed   sql.NullString `db:"replayed"`
+		ReplayedAt sql.NullTime   `db:"replayed_at"`
+		Now        time.Time      `db:"now"`
+	}
+	err = replica.GetContext(ctx, &state, `
+        SELECT pg_is_in_recovery() AS recovery,
+               pg_last_wal_replay_lsn()::text AS replayed,
+               pg_last_xact_replay_timestamp() AS replayed_at,
+               now() AS now
+    `)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check replica: %w", err)
+	}
+	if !state.Recovery {
+		return 0, errors.New("server is not a replica (not in recovery)")
+	}
+	if !state.Replayed.Valid {
+		return 0, errors.New("replica has not replayed any WAL")
+	}
+	replayed, err := ParseLSN(state.Replayed.String)
+	if err != nil {
+		return 0, err
+	}
+	return ReplayLag(written, replayed, state.ReplayedAt, state.Now)
+}
+
+// LSN - позиция в журнале WAL
+type LSN uint64
+
+// ParseLSN разбирает LSN в текстовом виде PostgreSQL ("16/B374D848")
+func ParseLSN(s string) (LSN, error) {
+	hi, lo, ok := strings.Cut(s, "/")
+	if ok {
+		h, errHi := strconv.ParseUint(hi, 16, 32)
+		l, errLo := strconv.ParseUint(lo, 16, 32)
+		if errHi == nil && errLo == nil {
+			return LSN(h<<32 | l), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid LSN %q", s)
+}
+
+// ReplayLag возвращает отставание реплики, применившей WAL до replayed,
+// от основной БД, записавшей его до written. Реплика, применившая все
+// записанное, не отстает, сколько бы основная БД ни простаивала. Иначе
+// отставание - время с последней примененной транзакции (replayedAt) по
+// часам реплики now: оно растет и тогда, когда реплика перестала получать
+// WAL, а не только когда не успевает его применять.
+func ReplayLag(written, replayed LSN, replayedAt sql.NullTime, now time.Time) (time.Duration, error) {
+	if replayed >= written {
+		return 0, nil
+	}
+	if !replayedAt.Valid {
+		return 0, errors.New("replica is behind and has not replayed any transaction")
+	}
+	return max(now.Sub(replayedAt.Time), 0), nil
+}
+
+// WithoutRLSBypass дополняет probe проверкой роли подключения: реплика
+// под суперпользователем или ролью с BYPASSRLS отдала бы пользователей
+// всех арендаторов, поэтому не считается исправной
+func WithoutRLSBypass(probe Probe) Probe {
+	return func(ctx context.Context, primary, replica *sqlx.DB) (time.Duration, error) {
+		bypass, err := BypassesRLS(ctx, replica)
+		if err != nil {
+			return 0, err
+		}
+		if bypass {
+			return 0, errors.New("database role bypasses row-level security (SUPERUSER or BYPASSRLS)")
+		}
+		return probe(ctx, primary, replica)
+	}
+}
+
+type sessionKey struct{}
+
+// session отмечает, что в ней уже была запись
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession начинает сессию (обычно - один HTTP запрос): после первой
+// записи через Writer чтение в ней идет в основную БД, чтобы запрос видел
+// свои изменения
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// WithPrimary направляет все чтение ctx в основную БД
+func WithPrimary(ctx context.Context) context.Context {
+	s := &session{}
+	s.wrote.Store(true)
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+func usesPrimary(ctx context.Context) bool {
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	return ok && s.wrote.Load()
+}
@@ -1,9 +1,10 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"log"
-	"os"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -13,28 +14,59 @@ import (
 // Config содержит настройки подключения к БД
 type Config struct {
 	Host     string
-	Port     string
+	Port     int
 	User     string
 	Password string
 	DBName   string
+	SSLMode  string
+
+	// Пути к файлам для sslmode=verify-ca/verify-full и клиентской
+	// аутентификации по сертификату
+	SSLRootCert string
+	SSLCert     string
+	SSLKey      string
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// ReplicaDSNs - строки подключения к репликам для чтения (см.
+	// NewPostgresCluster); MaxReplicaLag - их наибольшее отставание
+	ReplicaDSNs   []string
+	MaxReplicaLag time.Duration
+	// RequireRLS не пускает в ротацию реплики, роль которых обходит
+	// row-level security (см. BypassesRLS). Включается вместе с
+	// разделением арендаторов.
+	RequireRLS bool
 }
 
-// NewPostgresDB создает новое подключение к PostgreSQL
-func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
+// DSN возвращает строку подключения для lib/pq
+func (cfg Config) DSN() string {
 	dsn := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName,
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+		cfg.Host, cfg.Port, cfg.User, quote(cfg.Password), cfg.DBName, cfg.SSLMode,
 	)
+	if cfg.SSLRootCert != "" {
+		dsn += " sslrootcert=" + quote(cfg.SSLRootCert)
+	}
+	if cfg.SSLCert != "" {
+		dsn += " sslcert=" + quote(cfg.SSLCert)
+	}
+	if cfg.SSLKey != "" {
+		dsn += " sslkey=" + quote(cfg.SSLKey)
+	}
+	return dsn
+}
 
-	db, err := sqlx.Connect("postgres", dsn)
+// NewPostgresDB создает новое подключение к PostgreSQL
+func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
+	db, err := sqlx.Connect("postgres", cfg.DSN())
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Настройка пула соединений
-	db.SetMaxOpenConns(25)
-	db.SetMaxIdleConns(10)
-	db.SetConnMaxLifetime(5 * time.Minute)
+	configurePool(db, cfg)
 
 	// Проверка соединения
 	if err := db.Ping(); err != nil {
@@ -45,20 +77,29 @@ func NewPostgresDB(cfg Config) (*sqlx.DB, error) {
 	return db, nil
 }
 
-// GetConfigFromEnv получает конфигурацию из переменных окружения
-func GetConfigFromEnv() Config {
-	return Config{
-		Host:     getEnv("DB_HOST", "localhost"),
-		Port:     getEnv("DB_PORT", "5432"),
-		User:     getEnv("DB_USER", "postgres"),
-		Password: getEnv("DB_PASSWORD", "postgres"),
-		DBName:   getEnv("DB_NAME", "userdb"),
-	}
+// configurePool применяет к db настройки пула соединений
+func configurePool(db *sqlx.DB, cfg Config) {
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
+// BypassesRLS сообщает, игнорирует ли роль подключения row-level
+// security (суперпользователь или BYPASSRLS). Под такой ролью политики
+// разделения арендаторов не действуют.
+func BypassesRLS(ctx context.Context, db *sqlx.DB) (bool, error) {
+	var bypass bool
+	err := db.GetContext(ctx, &bypass, "SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user")
+	if err != nil {
+		return false, fmt.Errorf("failed to check database role: %w", err)
 	}
-	return defaultValue
+	return bypass, nil
+}
+
+var dsnEscaper = strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+
+// quote экранирует значение для DSN в формате key=value
+func quote(value string) string {
+	return "'" + dsnEscaper.Replace(value) + "'"
 }
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+	"user-api/internal/models"
+)
+
+// Type - тип события жизненного цикла пользователя
+type Type string
+
+const (
+	UserCreated Type = "user.created"
+	UserUpdated Type = "user.updated"
+	UserDeleted Type = "user.deleted"
+)
+
+// Types - все известные типы событий
+var Types = []Type{UserCreated, UserUpdated, UserDeleted}
+
+// Event описывает изменение пользователя
+type Event struct {
+	ID         string       `json:"id"`
+	Type       Type         `json:"type"`
+	UserID     int          `json:"user_id"`
+	User       *models.User `json:"user,omitempty"`
+	OccurredAt time.Time    `json:"occurred_at"`
+}
+
+// New создает событие с уникальным ID
+func New(eventType Type, userID int, user *models.User) Event {
+	return Event{
+		ID:         newID(),
+		Type:       eventType,
+		UserID:     userID,
+		User:       user,
+		OccurredAt: time.Now().UTC(),
+	}
+}
+
+// EventPublisher публикует события. Если Publish вернул ошибку, событие
+// считается не отправленным и будет опубликовано повторно, поэтому
+// получатели должны быть готовы к дубликатам (по ID события).
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Multi публикует событие во все publishers по очереди и возвращает первую
+// ошибку. При повторе событие снова получат и те, кто уже принял его.
+func Multi(publishers ...EventPublisher) EventPublisher {
+	return multi(publishers)
+}
+
+type multi []EventPublisher
+
+func (m multi) Publish(ctx context.Context, event Event) error {
+	for _, p := range m {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Valid проверяет, что тип события известен
+func (t Type) Valid() bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,69 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// FilePublisher записывает события в файл в формате JSON Lines. Это
+// локальная замена брокеру сообщений (NATS и т.п.): каждая строка содержит
+// subject, по которому событие было бы опубликовано, и само событие.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Message - строка файла FilePublisher
+type Message struct {
+	Subject string `json:"subject"`
+	Event   Event  `json:"event"`
+}
+
+// NewFilePublisher открывает файл path на дозапись
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &FilePublisher{file: file}, nil
+}
+
+// Subject возвращает subject события в стиле NATS, например users.created
+func Subject(event Event) string {
+	switch event.Type {
+	case UserCreated:
+		return "users.created"
+	case UserUpdated:
+		return "users.updated"
+	case UserDeleted:
+		return "users.deleted"
+	}
+	return "users." + string(event.Type)
+}
+
+// Publish дописывает событие в файл и сбрасывает его на диск
+func (p *FilePublisher) Publish(_ context.Context, event Event) error {
+	line, err := json.Marshal(Message{Subject: Subject(event), Event: event})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := p.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event: %w", err)
+	}
+	if err := p.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync event file: %w", err)
+	}
+	return nil
+}
+
+// Close закрывает файл
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,375 @@
+package feed
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"user-api/internal/events"
+)
+
+// ErrUnknownEvent возвращается, если события с номером из Last-Event-ID
+// нет ни в журнале, ни в истории: оно удалено по сроку хранения или
+// номер не из этой ленты. Клиенту нужно заново загрузить данные.
+var ErrUnknownEvent = errors.New("unknown event ID")
+
+// historyBatch - сколько событий читается из истории за один запрос
+const historyBatch = 500
+
+// Entry - событие с порядковым номером в журнале. Номер используется как
+// ID события Server-Sent Events.
+type Entry struct {
+	Seq   uint64
+	Event events.Event
+}
+
+// History - опубликованные события с номерами, общими для всех
+// экземпляров API (outbox). Номера растут в порядке публикации.
+type History interface {
+	// Last возвращает номер последнего события (0, если событий нет)
+	Last(ctx context.Context) (uint64, error)
+	// After возвращает до limit событий с номером больше seq в порядке
+	// номеров
+	After(ctx context.Context, seq uint64, limit int) ([]Entry, error)
+	// Contains сообщает, хранится ли событие с номером seq
+	Contains(ctx context.Context, seq uint64) (bool, error)
+}
+
+// Filter отбирает события для подписчика. Пустые множества означают
+// "без ограничений".
+type Filter struct {
+	UserIDs map[int]bool
+	Types   map[events.Type]bool
+	// TenantID - только события пользователей арендатора; 0 - всех
+	TenantID int
+}
+
+// Match проверяет, подходит ли событие под фильтр
+func (f Filter) Match(event events.Event) bool {
+	if f.TenantID != 0 && (event.User == nil || event.User.TenantID != f.TenantID) {
+		return false
+	}
+	if len(f.UserIDs) > 0 && !f.UserIDs[event.UserID] {
+		return false
+	}
+	if len(f.Types) > 0 && !f.Types[event.Type] {
+		return false
+	}
+	return true
+}
+
+// Feed хранит последние события в журнале ограниченного размера и
+// рассылает новые события подписчикам. Журнал позволяет клиенту после
+// переподключения получить пропущенные события по Last-Event-ID; если
+// их там уже нет, они читаются из истории.
+type Feed struct {
+	buffer  int
+	history History
+	wake    chan struct{}
+	// ready закрывается, когда известен номер последнего события истории
+	// (или лента закрыта): до этого подписка не знает, с какого события
+	// начинаются новые
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	mu     sync.Mutex
+	log    []Entry // кольцевой буфер
+	head   int     // индекс самой старой записи
+	seq    uint64
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// New создает ленту с журналом на size событий и буфером buffer событий
+// на подписчика. С history лента получает события через Follow и берет
+// номера из нее; без history события передаются в Publish и нумеруются
+// лентой, а пропущенное клиент получает только из журнала.
+func New(size, buffer int, history History) *Feed {
+	f := &Feed{
+		buffer:  buffer,
+		history: history,
+		wake:    make(chan struct{}, 1),
+		ready:   make(chan struct{}),
+		log:     make([]Entry, 0, size),
+		subs:    make(map[*Subscription]struct{}),
+	}
+	if history == nil {
+		// Номера начинаются со времени запуска, поэтому продолжают расти
+		// после перезапуска и старый Last-Event-ID не скрывает новые события
+		f.seq = uint64(time.Now().UnixMicro())
+		f.markReady()
+	}
+	return f
+}
+
+func (f *Feed) markReady() {
+	f.readyOnce.Do(func() { close(f.ready) })
+}
+
+// Publish добавляет событие в журнал под следующим номером и рассылает
+// его подписчикам. Используется лентой без истории.
+func (f *Feed) Publish(event events.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.publish(Entry{Seq: f.seq + 1, Event: event})
+}
+
+// publish добавляет entry в журнал и рассылает подписчикам. Подписчик,
+// который не успевает читать, отключается: клиент переподключится и
+// получит пропущенное из журнала или истории. Вызывается под f.mu.
+func (f *Feed) publish(entry Entry) {
+	if f.closed {
+		return
+	}
+
+	f.seq = entry.Seq
+	if len(f.log) < cap(f.log) {
+		f.log = append(f.log, entry)
+	} else if cap(f.log) > 0 {
+		f.log[f.head] = entry
+		f.head = (f.head + 1) % cap(f.log)
+	}
+
+	for sub := range f.subs {
+		if entry.Seq <= sub.after || !sub.filter.Match(entry.Event) {
+			continue
+		}
+		select {
+		case sub.ch <- entry:
+		default:
+			f.remove(sub)
+		}
+	}
+}
+
+// Notify будит Follow, не дожидаясь следующего опроса
+func (f *Feed) Notify() {
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Follow рассылает новые события истории до отмены ctx, начиная с
+// последнего на момент запуска. История проверяется после Notify и
+// каждые poll.
+func (f *Feed) Follow(ctx context.Context, poll time.Duration) {
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	var last uint64
+	started := false
+	for ctx.Err() == nil {
+		if !started {
+			seq, err := f.history.Last(ctx)
+			if err != nil {
+				log.Printf("Feed: %v", err)
+			} else {
+				last, started = seq, true
+				f.mu.Lock()
+				f.seq = seq
+				f.mu.Unlock()
+				f.markReady()
+			}
+		}
+
+		for started {
+			entries, err := f.history.After(ctx, last, historyBatch)
+			if err != nil {
+				log.Printf("Feed: %v", err)
+				break
+			}
+			f.mu.Lock()
+			for _, entry := range entries {
+				f.publish(entry)
+			}
+			f.mu.Unlock()
+			if len(entries) > 0 {
+				last = entries[len(entries)-1].Seq
+			}
+			if len(entries) < historyBatch {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-f.wake:
+		}
+	}
+}
+
+// Subscribe подписывает на новые события, подходящие под filter. Если
+// resume = true, вместе с подпиской возвращаются события с номером
+// больше lastSeq: из журнала, а если часть из них из него уже выпала -
+// из истории. Если события lastSeq нет ни там, ни там, возвращается
+// подписка на новые события и ErrUnknownEvent. Лента с историей
+// принимает подписки после запуска Follow.
+func (f *Feed) Subscribe(ctx context.Context, filter Filter, lastSeq uint64, resume bool) (*Subscription, []Entry, error) {
+	select {
+	case <-f.ready:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	sub := &Subscription{
+		feed:   f,
+		filter: filter,
+		ch:     make(chan Entry, f.buffer),
+	}
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		close(sub.ch)
+		return sub, nil, nil
+	}
+	f.subs[sub] = struct{}{}
+	head := f.seq
+	sub.seq = head
+	sub.after = head
+	if !resume {
+		f.mu.Unlock()
+		return sub, nil, nil
+	}
+	// Клиент мог прочитать событие на экземпляре, который опередил этот
+	sub.after = max(head, lastSeq)
+	backlog, found := f.logAfter(filter, lastSeq)
+	f.mu.Unlock()
+
+	if found {
+		return sub, backlog, nil
+	}
+	var err error
+	if f.history == nil {
+		err = ErrUnknownEvent
+	} else {
+		backlog, err = f.replay(ctx, filter, lastSeq, head)
+	}
+	if errors.Is(err, ErrUnknownEvent) {
+		f.mu.Lock()
+		sub.after = head
+		f.mu.Unlock()
+		return sub, nil, err
+	}
+	if err != nil {
+		sub.Close()
+		return nil, nil, err
+	}
+	return sub, backlog, nil
+}
+
+// logAfter возвращает события журнала с номером больше lastSeq,
+// подходящие под filter. found = false, если события lastSeq в журнале
+// нет: часть событий после него могла из журнала выпасть. Вызывается под
+// f.mu.
+func (f *Feed) logAfter(filter Filter, lastSeq uint64) ([]Entry, bool) {
+	found := lastSeq == f.seq
+	var backlog []Entry
+	for i := range f.log {
+		entry := f.log[(f.head+i)%len(f.log)]
+		if entry.Seq == lastSeq {
+			found = true
+		}
+		if entry.Seq > lastSeq && filter.Match(entry.Event) {
+			backlog = append(backlog, entry)
+		}
+	}
+	if !found {
+		return nil, false
+	}
+	return backlog, true
+}
+
+// replay читает из истории события с номерами больше lastSeq и не больше
+// head, подходящие под filter
+func (f *Feed) replay(ctx context.Context, filter Filter, lastSeq, head uint64) ([]Entry, error) {
+	known, err := f.history.Contains(ctx, lastSeq)
+	if err != nil {
+		return nil, err
+	}
+	if !known {
+		return nil, ErrUnknownEvent
+	}
+
+	var backlog []Entry
+	for seq := lastSeq; seq < head; {
+		entries, err := f.history.After(ctx, seq, historyBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Seq > head {
+				return backlog, nil
+			}
+			if filter.Match(entry.Event) {
+				backlog = append(backlog, entry)
+			}
+			seq = entry.Seq
+		}
+		if len(entries) < historyBatch {
+			break
+		}
+	}
+	return backlog, nil
+}
+
+// Subscribers возвращает число активных подписчиков
+func (f *Feed) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+// Close отключает всех подписчиков и прекращает прием событий
+func (f *Feed) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.closed = true
+	for sub := range f.subs {
+		f.remove(sub)
+	}
+	f.markReady()
+}
+
+// remove удаляет подписчика и закрывает его канал. Вызывается под f.mu.
+func (f *Feed) remove(sub *Subscription) {
+	if _, ok := f.subs[sub]; ok {
+		delete(f.subs, sub)
+		close(sub.ch)
+	}
+}
+
+// Subscription - подписка на ленту. Канал C закрывается, когда подписчик
+// отключен (не успевал читать или лента закрыта).
+type Subscription struct {
+	feed   *Feed
+	filter Filter
+	ch     chan Entry
+	// seq - номер последнего события ленты на момент подписки; after -
+	// номер, после которого подписчику рассылаются новые события
+	seq   uint64
+	after uint64
+}
+
+// C возвращает канал новых событий
+func (s *Subscription) C() <-chan Entry {
+	return s.ch
+}
+
+// Seq возвращает номер последнего события ленты на момент подписки
+func (s *Subscription) Seq() uint64 {
+	return s.seq
+}
+
+// Close отменяет подписку
+func (s *Subscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s)
+}
@@ -0,0 +1,191 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+)
+
+// xmlName - имя, которое можно использовать как имя элемента XML
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// EncodeXML записывает v в XML с корневым элементом root. Поле объекта
+// становится элементом, элемент массива - элементом с именем массива без
+// окончания "s" (users → user) или item. null передается атрибутом
+// nil="true". Ключ, который не может быть именем элемента, записывается
+// как <entry key="...">.
+func EncodeXML(w io.Writer, root string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	if err := writeXML(enc, dec, root); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func writeXML(enc *xml.Encoder, dec *json.Decoder, name string) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if !xmlName.MatchString(name) || strings.HasPrefix(strings.ToLower(name), "xml") {
+		start = xml.StartElement{
+			Name: xml.Name{Local: "entry"},
+			Attr: []xml.Attr{{Name: xml.Name{Local: "key"}, Value: name}},
+		}
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		if err := enc.EncodeToken(start); err != nil {
+			return err
+		}
+		item := itemName(name)
+		for dec.More() {
+			child := item
+			if t == '{' {
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child = key.(string)
+			}
+			if err := writeXML(enc, dec, child); err != nil {
+				return err
+			}
+		}
+		// Закрывающая скобка
+		if _, err := dec.Token(); err != nil {
+			return err
+		}
+		return enc.EncodeToken(start.End())
+	case nil:
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "nil"}, Value: "true"})
+		return enc.EncodeElement("", start)
+	default:
+		return enc.EncodeElement(fmt.Sprint(t), start)
+	}
+}
+
+func itemName(array string) string {
+	if item, ok := strings.CutSuffix(array, "s"); ok && item != "" {
+		return item
+	}
+	return "item"
+}
+
+// msgpackHandle кодирует строки по актуальной спецификации (str8, bin) и
+// декодирует словари с ключами-строками, чтобы результат переводился в JSON
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]interface{}(nil))
+	return h
+}()
+
+// EncodeMsgPack записывает v в MessagePack
+func EncodeMsgPack(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return err
+	}
+	return codec.NewEncoder(w, msgpackHandle).Encode(numbers(value))
+}
+
+// numbers заменяет json.Number на int64 или float64
+func numbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	}
+	return value
+}
+
+// MsgPackToJSON переводит тело в MessagePack в JSON, чтобы декодировать
+// и проверять его так же, как JSON запрос
+func MsgPackToJSON(r io.Reader) ([]byte, error) {
+	var value interface{}
+	if err := codec.NewDecoder(r, msgpackHandle).Decode(&value); err != nil {
+		return nil, fmt.Errorf("invalid MessagePack body: %w", err)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid MessagePack body: %w", err)
+	}
+	return data, nil
+}
+
+// EncodeCSV записывает строки rows (JSON объекты) в CSV с заголовком
+// columns. Строки выводятся без кавычек JSON, null - пустой ячейкой,
+// объекты и массивы - JSON текстом. Строка, которая начинается с =, +, -
+// или @, получает префикс ', чтобы табличные редакторы не выполнили ее
+// как формулу.
+func EncodeCSV(w io.Writer, columns []string, rows []map[string]json.RawMessage) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return err
+	}
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, column := range columns {
+			record[i] = csvValue(row[column])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvValue(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return string(raw)
+	}
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
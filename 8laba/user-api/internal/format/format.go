@@ -0,0 +1,102 @@
+// Package format выбирает формат ответа по заголовку Accept и кодирует
+// ответы и тела запросов в XML, MessagePack и CSV. Все форматы строятся из
+// JSON представления значения, поэтому имена и состав полей совпадают с
+// JSON ответом.
+package format
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Поддерживаемые форматы
+const (
+	JSON    = "application/json"
+	XML     = "application/xml"
+	MsgPack = "application/msgpack"
+	CSV     = "text/csv"
+)
+
+// Object - форматы ответа с одним объектом
+var Object = []string{JSON, XML, MsgPack}
+
+// List - форматы ответа со списком
+var List = []string{JSON, XML, MsgPack, CSV}
+
+// aliases - другие распространенные обозначения форматов
+var aliases = map[string]string{
+	"text/xml":                XML,
+	"application/x-msgpack":   MsgPack,
+	"application/vnd.msgpack": MsgPack,
+}
+
+// Normalize приводит тип из Content-Type или Accept к одной из констант
+// пакета (или к типу без параметров в нижнем регистре, если он неизвестен)
+func Normalize(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(contentType))
+	}
+	if alias, ok := aliases[mediaType]; ok {
+		return alias
+	}
+	return mediaType
+}
+
+// ContentType возвращает значение заголовка Content-Type для format
+func ContentType(format string) string {
+	switch format {
+	case XML, CSV:
+		return format + "; charset=utf-8"
+	}
+	return format
+}
+
+// Negotiate выбирает из offers формат по заголовку Accept с учетом
+// q-значений и масок вида type/*. Пустой Accept означает offers[0].
+func Negotiate(accept string, offers []string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType: Normalize(mediaType), q: q})
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+
+	for _, r := range ranges {
+		for _, offer := range offers {
+			if matches(r.mediaType, offer) {
+				return offer, true
+			}
+		}
+	}
+	return "", false
+}
+
+func matches(mediaRange, offer string) bool {
+	if mediaRange == "*/*" || mediaRange == offer {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(mediaRange, "/*")
+	return ok && strings.HasPrefix(offer, prefix+"/")
+}
@@ -0,0 +1,111 @@
+package graphqlapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Request - тело GraphQL запроса
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Handler обработчик GraphQL запросов
+type Handler struct {
+	schema graphql.Schema
+	limits Limits
+}
+
+// NewHandler создает новый обработчик GraphQL
+func NewHandler(schema graphql.Schema, limits Limits) *Handler {
+	return &Handler{schema: schema, limits: limits}
+}
+
+// Query выполняет GraphQL запрос, переданный в теле POST или параметрах GET
+func (h *Handler) Query(c *gin.Context) {
+	var req Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if vars := c.Query("variables"); vars != "" {
+			if err := json.Unmarshal([]byte(vars), &req.Variables); err != nil {
+				respondErrors(c, http.StatusBadRequest, gqlerrors.NewFormattedError("invalid variables: "+err.Error()))
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		respondErrors(c, http.StatusBadRequest, gqlerrors.NewFormattedError("invalid request body: "+err.Error()))
+		return
+	}
+
+	if req.Query == "" {
+		respondErrors(c, http.StatusBadRequest, gqlerrors.NewFormattedError("query is required"))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query)})})
+	if err != nil {
+		respondErrors(c, http.StatusBadRequest, gqlerrors.FormatError(err))
+		return
+	}
+
+	if result := graphql.ValidateDocument(&h.schema, doc, graphql.SpecifiedRules); !result.IsValid {
+		respondErrors(c, http.StatusBadRequest, result.Errors...)
+		return
+	}
+
+	if err := h.limits.check(doc, req.Variables); err != nil {
+		respondErrors(c, http.StatusBadRequest, gqlerrors.NewFormattedError(err.Error()))
+		return
+	}
+
+	// Мутации через GET запрещены, чтобы их нельзя было вызвать ссылкой
+	if c.Request.Method == http.MethodGet && hasMutation(doc, req.OperationName) {
+		respondErrors(c, http.StatusMethodNotAllowed, gqlerrors.NewFormattedError("mutations are only allowed via POST"))
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       c.Request.Context(),
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// Playground отдает GraphiQL для выполнения запросов из браузера
+func (h *Handler) Playground(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(playgroundHTML))
+}
+
+func respondErrors(c *gin.Context, status int, errs ...gqlerrors.FormattedError) {
+	c.JSON(status, graphql.Result{Errors: errs})
+}
+
+// hasMutation сообщает, является ли выполняемая операция мутацией
+func hasMutation(doc *ast.Document, operationName string) bool {
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (op.Name == nil || op.Name.Value != operationName) {
+			continue
+		}
+		if op.Operation == ast.OperationTypeMutation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package graphqlapi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+const defaultPageSize = 10
+
+// paginatedFields - корневые поля, возвращающие страницу списка. Стоимость
+// их вложенных полей умножается на размер страницы.
+var paginatedFields = map[string]bool{
+	"users": true,
+}
+
+// Limits ограничивает глубину и сложность запросов
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// check проверяет все операции документа. Служебные поля интроспекции
+// (__schema, __type) не учитываются, чтобы работал GraphiQL.
+func (l Limits) check(doc *ast.Document, variables map[string]interface{}) error {
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, def := range doc.Definitions {
+		if frag, ok := def.(*ast.FragmentDefinition); ok {
+			fragments[frag.Name.Value] = frag
+		}
+	}
+
+	a := &analyzer{fragments: fragments, variables: variables}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+
+		depth, complexity := a.selectionSet(op.SelectionSet, 1)
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return fmt.Errorf("query depth %d exceeds limit %d", depth, l.MaxDepth)
+		}
+		if l.MaxComplexity > 0 && complexity > l.MaxComplexity {
+			return fmt.Errorf("query complexity %d exceeds limit %d", complexity, l.MaxComplexity)
+		}
+	}
+	return nil
+}
+
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// selectionSet возвращает глубину и стоимость набора полей на уровне level
+func (a *analyzer) selectionSet(set *ast.SelectionSet, level int) (depth, complexity int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	for _, sel := range set.Selections {
+		var d, c int
+		switch s := sel.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			childDepth, childComplexity := a.selectionSet(s.SelectionSet, level+1)
+			d = 1 + childDepth
+			c = 1 + a.multiplier(s, level)*childComplexity
+		case *ast.InlineFragment:
+			d, c = a.selectionSet(s.SelectionSet, level)
+		case *ast.FragmentSpread:
+			if frag, ok := a.fragments[s.Name.Value]; ok {
+				d, c = a.selectionSet(frag.SelectionSet, level)
+			}
+		}
+		if d > depth {
+			depth = d
+		}
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (a *analyzer) multiplier(field *ast.Field, level int) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "pageSize" {
+			continue
+		}
+		if n := a.intValue(arg.Value); n > 0 {
+			return n
+		}
+	}
+	if level == 1 && paginatedFields[field.Name.Value] {
+		return defaultPageSize
+	}
+	return 1
+}
+
+func (a *analyzer) intValue(value ast.Value) int {
+	switch v := value.(type) {
+	case *ast.IntValue:
+		n, _ := strconv.Atoi(v.Value)
+		return n
+	case *ast.Variable:
+		switch n := a.variables[v.Name.Value].(type) {
+		case int:
+			return n
+		case float64:
+			return int(n)
+		}
+	}
+	return 0
+}
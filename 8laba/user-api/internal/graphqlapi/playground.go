@@ -0,0 +1,29 @@
+package graphqlapi
+
+// playgroundHTML - страница GraphiQL, отправляющая запросы на /graphql
+const playgroundHTML = `<!DOCTYPE html>
+<html lang="ru">
+<head>
+    <meta charset="UTF-8">
+    <title>User API - GraphiQL</title>
+    <style>
+        body { height: 100vh; margin: 0; overflow: hidden; }
+        #graphiql { height: 100vh; }
+    </style>
+    <link rel="stylesheet" href="https://unpkg.com/graphiql@3.8.3/graphiql.min.css" />
+</head>
+<body>
+    <div id="graphiql">Загрузка...</div>
+    <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+    <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+    <script crossorigin src="https://unpkg.com/graphiql@3.8.3/graphiql.min.js"></script>
+    <script>
+        const fetcher = GraphiQL.createFetcher({ url: "/graphql" });
+        const defaultQuery = "query {\n  users(page: 1, pageSize: 10, sort: {field: CREATED_AT, direction: DESC}) {\n    total\n    users { id name email age }\n  }\n}\n";
+        ReactDOM.createRoot(document.getElementById("graphiql")).render(
+            React.createElement(GraphiQL, { fetcher, defaultQuery })
+        );
+    </script>
+</body>
+</html>
+`
@@ -0,0 +1,258 @@
+package graphqlapi
+
+import (
+	"errors"
+	"fmt"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/graphql-go/graphql"
+)
+
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id":              &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"name":            &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"email":           &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"age":             &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"createdAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: resolveUser(func(u *models.User) interface{} { return u.CreatedAt })},
+		"updatedAt":       &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime), Resolve: resolveUser(func(u *models.User) interface{} { return u.UpdatedAt })},
+		"emailVerifiedAt": &graphql.Field{Type: graphql.DateTime, Resolve: resolveUser(emailVerifiedAt)},
+	},
+})
+
+// emailVerifiedAt возвращает nil для неподтвержденного email, чтобы поле было null
+func emailVerifiedAt(u *models.User) interface{} {
+	if u.EmailVerifiedAt == nil {
+		return nil
+	}
+	return *u.EmailVerifiedAt
+}
+
+var userPageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "UserPage",
+	Fields: graphql.Fields{
+		"users":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(userType)))},
+		"total":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"page":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"pageSize":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolvePage(func(p *models.UserListResponse) interface{} { return p.PageSize })},
+		"totalPages": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolvePage(func(p *models.UserListResponse) interface{} { return p.TotalPages })},
+	},
+})
+
+var userFilterInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UserFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":   &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Часть имени, без учета регистра"},
+		"email":  &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Часть email, без учета регистра"},
+		"minAge": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"maxAge": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+	},
+})
+
+var userSortFieldEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "UserSortField",
+	Values: graphql.EnumValueConfigMap{
+		"ID":         &graphql.EnumValueConfig{Value: "id"},
+		"NAME":       &graphql.EnumValueConfig{Value: "name"},
+		"EMAIL":      &graphql.EnumValueConfig{Value: "email"},
+		"AGE":        &graphql.EnumValueConfig{Value: "age"},
+		"CREATED_AT": &graphql.EnumValueConfig{Value: "created_at"},
+		"UPDATED_AT": &graphql.EnumValueConfig{Value: "updated_at"},
+	},
+})
+
+var sortDirectionEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "SortDirection",
+	Values: graphql.EnumValueConfigMap{
+		"ASC":  &graphql.EnumValueConfig{Value: "asc"},
+		"DESC": &graphql.EnumValueConfig{Value: "desc"},
+	},
+})
+
+var userSortInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UserSort",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"field":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(userSortFieldEnum)},
+		"direction": &graphql.InputObjectFieldConfig{Type: sortDirectionEnum, DefaultValue: "asc"},
+	},
+})
+
+var createUserInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "CreateUserInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"email": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"age":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var updateUserInput = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "UpdateUserInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"email": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"age":   &graphql.InputObjectFieldConfig{Type: graphql.Int},
+	},
+})
+
+// resolvers содержит резолверы, работающие через service.UserService
+type resolvers struct {
+	service  service.UserService
+	validate *validator.Validate
+}
+
+// NewSchema строит GraphQL схему поверх service.UserService
+func NewSchema(userService service.UserService, rules *validation.EmailRules) (graphql.Schema, error) {
+	// Правила валидации берутся из тех же тегов binding, что и в HTTP API
+	r := &resolvers{service: userService, validate: validation.New(rules)}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"user": &graphql.Field{
+				Type: userType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.user,
+			},
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(userPageType),
+				Args: graphql.FieldConfigArgument{
+					"filter":   &graphql.ArgumentConfig{Type: userFilterInput},
+					"sort":     &graphql.ArgumentConfig{Type: userSortInput},
+					"page":     &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"pageSize": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+				},
+				Resolve: r.users,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createUser": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(createUserInput)},
+				},
+				Resolve: r.createUser,
+			},
+			"updateUser": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(updateUserInput)},
+				},
+				Resolve: r.updateUser,
+			},
+			"deleteUser": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+				},
+				Resolve: r.deleteUser,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func (r *resolvers) user(p graphql.ResolveParams) (interface{}, error) {
+	user, err := r.service.GetUser(p.Context, p.Args["id"].(int))
+	if errors.Is(err, models.ErrUserNotFound) {
+		// Отсутствующий пользователь - null, а не ошибка
+		return nil, nil
+	}
+	return user, err
+}
+
+func (r *resolvers) users(p graphql.ResolveParams) (interface{}, error) {
+	filters := make(map[string]interface{})
+
+	if filter, ok := p.Args["filter"].(map[string]interface{}); ok {
+		if name, ok := filter["name"].(string); ok && name != "" {
+			filters["name"] = name
+		}
+		if email, ok := filter["email"].(string); ok && email != "" {
+			filters["email"] = email
+		}
+		if minAge, ok := filter["minAge"].(int); ok && minAge > 0 {
+			filters["min_age"] = minAge
+		}
+		if maxAge, ok := filter["maxAge"].(int); ok && maxAge > 0 {
+			filters["max_age"] = maxAge
+		}
+	}
+	if sort, ok := p.Args["sort"].(map[string]interface{}); ok {
+		filters["sort_by"] = sort["field"]
+		filters["sort_order"] = sort["direction"]
+	}
+
+	return r.service.GetUsers(p.Context, p.Args["page"].(int), p.Args["pageSize"].(int), filters)
+}
+
+func (r *resolvers) createUser(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	req := &models.CreateUserRequest{
+		Name:  input["name"].(string),
+		Email: input["email"].(string),
+		Age:   input["age"].(int),
+	}
+	if err := r.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	return r.service.CreateUser(p.Context, req)
+}
+
+func (r *resolvers) updateUser(p graphql.ResolveParams) (interface{}, error) {
+	input := p.Args["input"].(map[string]interface{})
+	req := &models.UpdateUserRequest{}
+	if name, ok := input["name"].(string); ok {
+		req.Name = name
+	}
+	if email, ok := input["email"].(string); ok {
+		req.Email = email
+	}
+	if age, ok := input["age"].(int); ok {
+		req.Age = age
+	}
+	if err := r.validate.Struct(req); err != nil {
+		return nil, fmt.Errorf("validation error: %w", err)
+	}
+	return r.service.UpdateUser(p.Context, p.Args["id"].(int), req)
+}
+
+func (r *resolvers) deleteUser(p graphql.ResolveParams) (interface{}, error) {
+	if err := r.service.DeleteUser(p.Context, p.Args["id"].(int)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func resolveUser(get func(*models.User) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		switch u := p.Source.(type) {
+		case *models.User:
+			return get(u), nil
+		case models.User:
+			return get(&u), nil
+		}
+		return nil, nil
+	}
+}
+
+func resolvePage(get func(*models.UserListResponse) interface{}) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if page, ok := p.Source.(*models.UserListResponse); ok {
+			return get(page), nil
+		}
+		return nil, nil
+	}
+}
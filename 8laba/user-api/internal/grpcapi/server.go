@@ -0,0 +1,22 @@
+package grpcapi
+
+import (
+	"user-api/internal/service"
+	"user-api/internal/validation"
+	userv1 "user-api/pkg/pb/user/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer создает gRPC сервер с зарегистрированным UserService
+func NewServer(userService service.UserService, rules *validation.EmailRules, enableReflection bool, opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	userv1.RegisterUserServiceServer(srv, NewUserServer(userService, rules))
+
+	if enableReflection {
+		reflection.Register(srv)
+	}
+
+	return srv
+}
@@ -0,0 +1,25 @@
+package grpcapi
+
+import (
+	"context"
+	"user-api/internal/database"
+
+	"google.golang.org/grpc"
+)
+
+// SessionInterceptors делает каждый вызов сессией database.Cluster так
+// же, как middleware.DatabaseSession для HTTP
+func SessionInterceptors() []grpc.ServerOption {
+	unary := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(database.WithSession(ctx), req)
+	}
+
+	stream := func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextStream{ServerStream: ss, ctx: database.WithSession(ss.Context())})
+	}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary),
+		grpc.ChainStreamInterceptor(stream),
+	}
+}
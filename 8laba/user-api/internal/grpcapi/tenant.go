@@ -0,0 +1,90 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// TenantInterceptors возвращает опции сервера, которые определяют
+// арендатора каждого вызова так же, как middleware.Tenant для HTTP:
+// по метаданным authorization и header
+func TenantInterceptors(resolver *tenant.Resolver, header string) []grpc.ServerOption {
+	header = strings.ToLower(header)
+
+	unary := func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := resolveTenant(ctx, resolver, header)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	stream := func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := resolveTenant(ss.Context(), resolver, header)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &contextStream{ServerStream: ss, ctx: ctx})
+	}
+
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unary),
+		grpc.ChainStreamInterceptor(stream),
+	}
+}
+
+// contextStream подменяет контекст потока
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+func resolveTenant(ctx context.Context, resolver *tenant.Resolver, header string) (context.Context, error) {
+	id, err := resolver.Resolve(ctx, grpcCredentials(ctx, header))
+	switch {
+	case err == nil:
+		return tenant.WithID(ctx, id), nil
+	case errors.Is(err, models.ErrTenantRequired), errors.Is(err, models.ErrInvalidAuthToken):
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, models.ErrUntrustedCaller), errors.Is(err, models.ErrTenantNotFound),
+		errors.Is(err, models.ErrTenantSuspended):
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+}
+
+// grpcCredentials собирает данные вызова для определения арендатора
+func grpcCredentials(ctx context.Context, header string) tenant.Credentials {
+	var cred tenant.Credentials
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		cred.Bearer = tenant.BearerToken(values[0])
+	}
+	if values := md.Get(header); len(values) > 0 {
+		cred.Header = values[0]
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			cred.RemoteAddr = p.Addr.String()
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			cred.VerifiedCert = len(info.State.VerifiedChains) > 0
+		}
+	}
+	return cred
+}
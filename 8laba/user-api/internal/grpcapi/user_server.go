@@ -0,0 +1,190 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/validation"
+	userv1 "user-api/pkg/pb/user/v1"
+
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const defaultStreamBatchSize = 100
+
+// UserServer реализует gRPC UserService поверх того же service.UserService,
+// что использует UserHandler
+type UserServer struct {
+	userv1.UnimplementedUserServiceServer
+
+	service  service.UserService
+	validate *validator.Validate
+}
+
+// NewUserServer создает новый gRPC сервер пользователей; rules - правила
+// для email (nil - без ограничений)
+func NewUserServer(service service.UserService, rules *validation.EmailRules) *UserServer {
+	// Правила валидации берутся из тех же тегов binding, что и в HTTP API
+	return &UserServer{service: service, validate: validation.New(rules)}
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *userv1.CreateUserRequest) (*userv1.User, error) {
+	create := &models.CreateUserRequest{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Age:   int(req.GetAge()),
+	}
+	if err := s.validate.Struct(create); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user, err := s.service.CreateUser(ctx, create)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *userv1.GetUserRequest) (*userv1.User, error) {
+	user, err := s.service.GetUser(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *userv1.ListUsersRequest) (*userv1.ListUsersResponse, error) {
+	response, err := s.service.GetUsers(ctx, int(req.GetPage()), int(req.GetPageSize()), toFilters(req.GetFilter()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	users := make([]*userv1.User, 0, len(response.Users))
+	for i := range response.Users {
+		users = append(users, toProto(&response.Users[i]))
+	}
+
+	return &userv1.ListUsersResponse{
+		Users:      users,
+		Total:      int32(response.Total),
+		Page:       int32(response.Page),
+		PageSize:   int32(response.PageSize),
+		TotalPages: int32(response.TotalPages),
+	}, nil
+}
+
+// StreamUsers читает пользователей порциями в порядке ID и отправляет их
+// клиенту по одному, не загружая весь результат в память. Каждая порция
+// начинается после последнего отправленного ID, а не со смещения, поэтому
+// пользователи, созданные или удаленные во время потока, не сдвигают
+// остальных: ни один не пропускается и не отправляется дважды, даже если
+// порции читаются из разных реплик.
+func (s *UserServer) StreamUsers(req *userv1.StreamUsersRequest, stream userv1.UserService_StreamUsersServer) error {
+	batchSize := int(req.GetBatchSize())
+	if batchSize < 1 || batchSize > 100 {
+		batchSize = defaultStreamBatchSize
+	}
+	filters := toFilters(req.GetFilter())
+	filters["sort_by"] = "id"
+	filters["sort_order"] = "asc"
+
+	for {
+		if err := stream.Context().Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		response, err := s.service.GetUsers(stream.Context(), 1, batchSize, filters)
+		if err != nil {
+			return toStatus(err)
+		}
+
+		for i := range response.Users {
+			if err := stream.Send(toProto(&response.Users[i])); err != nil {
+				return err
+			}
+		}
+
+		if len(response.Users) < batchSize {
+			return nil
+		}
+		filters["after_id"] = response.Users[len(response.Users)-1].ID
+	}
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *userv1.UpdateUserRequest) (*userv1.User, error) {
+	update := &models.UpdateUserRequest{
+		Name:  req.GetName(),
+		Email: req.GetEmail(),
+		Age:   int(req.GetAge()),
+	}
+	if err := s.validate.Struct(update); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	user, err := s.service.UpdateUser(ctx, int(req.GetId()), update)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toProto(user), nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *userv1.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteUser(ctx, int(req.GetId())); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// toStatus сопоставляет доменные ошибки кодам gRPC
+func toStatus(err error) error {
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, models.ErrEmailTaken):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, models.ErrInvalidEmail), errors.Is(err, models.ErrInvalidName),
+		errors.Is(err, models.ErrInvalidMetadata):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func toFilters(f *userv1.UserFilter) map[string]interface{} {
+	filters := make(map[string]interface{})
+	if f == nil {
+		return filters
+	}
+
+	if f.GetName() != "" {
+		filters["name"] = f.GetName()
+	}
+	if f.GetEmail() != "" {
+		filters["email"] = f.GetEmail()
+	}
+	if f.GetMinAge() > 0 {
+		filters["min_age"] = int(f.GetMinAge())
+	}
+	if f.GetMaxAge() > 0 {
+		filters["max_age"] = int(f.GetMaxAge())
+	}
+	return filters
+}
+
+func toProto(user *models.User) *userv1.User {
+	return &userv1.User{
+		Id:        int64(user.ID),
+		Name:      user.Name,
+		Email:     user.Email,
+		Age:       int32(user.Age),
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
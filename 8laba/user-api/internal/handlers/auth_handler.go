@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuthHandler обработчик запросов восстановления пароля
+type AuthHandler struct {
+	service service.PasswordService
+}
+
+// NewAuthHandler создает новый обработчик
+func NewAuthHandler(service service.PasswordService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// ForgotPassword godoc
+// @Summary Запросить сброс пароля
+// @Description Отправка письма со ссылкой сброса пароля. Ответ одинаков для существующих и несуществующих адресов. Арендатор не требуется: письмо получает учетная запись с этим email у каждого арендатора.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ForgotPasswordRequest true "Email пользователя"
+// @Success 202 {object} models.MessageResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 429 {object} models.ErrorResponse "Превышен лимит запросов"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req models.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	if err := h.service.ForgotPassword(c.Request.Context(), req.Email, c.ClientIP()); err != nil {
+		respondAuthError(c, "Failed to request password reset", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.MessageResponse{
+		Message: text(c, "messages.password_reset_sent"),
+	})
+}
+
+// ResetPassword godoc
+// @Summary Сбросить пароль
+// @Description Установка нового пароля по одноразовому токену из письма. Access токены пользователя, выданные (iat) до сброса, перестают приниматься.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body models.ResetPasswordRequest true "Токен и новый пароль"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse "Токен недействителен, истек или уже использован"
+// @Failure 429 {object} models.ErrorResponse "Превышен лимит запросов"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req models.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	if err := h.service.ResetPassword(req.Token, req.Password, c.ClientIP()); err != nil {
+		respondAuthError(c, "Failed to reset password", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondAuthError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	var rateLimit *models.RateLimitError
+	switch {
+	case errors.As(err, &rateLimit):
+		status = http.StatusTooManyRequests
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(rateLimit.RetryAfter.Seconds()))))
+	case errors.Is(err, models.ErrInvalidResetToken):
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   message,
+		Message: localize(c, err),
+	})
+}
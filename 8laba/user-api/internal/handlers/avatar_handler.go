@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"user-api/internal/avatar"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartOverhead - запас на границы и заголовки multipart сверх
+// размера самого файла
+const multipartOverhead = 64 << 10
+
+// AvatarHandler обработчик запросов аватаров пользователей
+type AvatarHandler struct {
+	service service.AvatarService
+	maxSize int64
+}
+
+// NewAvatarHandler создает новый обработчик; maxSize - максимальный размер
+// загружаемого файла в байтах
+func NewAvatarHandler(service service.AvatarService, maxSize int64) *AvatarHandler {
+	return &AvatarHandler{service: service, maxSize: maxSize}
+}
+
+// UploadAvatar godoc
+// @Summary Загрузить аватар
+// @Description Загрузка изображения (JPEG, PNG, GIF) в поле avatar. Тип определяется по содержимому файла; изображение обрезается до квадрата и сохраняется в нескольких размерах (small, medium, large).
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "User ID"
+// @Param avatar formData file true "Изображение"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 413 {object} models.ErrorResponse "Файл слишком большой"
+// @Failure 415 {object} models.ErrorResponse "Неподдерживаемый тип изображения"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/{id}/avatar [put]
+func (h *AvatarHandler) UploadAvatar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+multipartOverhead)
+	data, err := h.readFile(c)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) || errors.Is(err, errFileTooLarge) {
+			c.JSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+				Error:   "File too large",
+				Message: text(c, "messages.avatar_too_large", strconv.FormatInt(h.maxSize, 10)),
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid upload",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	user, err := h.service.SetAvatar(c.Request.Context(), id, data)
+	if err != nil {
+		respondAvatarError(c, "Failed to upload avatar", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+// DeleteAvatar godoc
+// @Summary Удалить аватар
+// @Description Удаление аватара пользователя и его файлов
+// @Tags users
+// @Produce json
+// @Param id path int true "User ID"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/{id}/avatar [delete]
+func (h *AvatarHandler) DeleteAvatar(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return
+	}
+
+	user, err := h.service.DeleteAvatar(c.Request.Context(), id)
+	if err != nil {
+		respondAvatarError(c, "Failed to delete avatar", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
+var errFileTooLarge = errors.New("file too large")
+
+// readFile читает файл из поля avatar, не больше maxSize байт
+func (h *AvatarHandler) readFile(c *gin.Context) ([]byte, error) {
+	file, _, err := c.Request.FormFile("avatar")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > h.maxSize {
+		return nil, errFileTooLarge
+	}
+	return data, nil
+}
+
+func respondAvatarError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrUserNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, avatar.ErrUnsupportedType):
+		status = http.StatusUnsupportedMediaType
+	case errors.Is(err, avatar.ErrInvalidImage), errors.Is(err, avatar.ErrTooLarge):
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, models.ErrorResponse{
+		Error:   message,
+		Message: localize(c, err),
+	})
+}
@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GroupHandler обработчик HTTP запросов для групп
+type GroupHandler struct {
+	service service.GroupService
+}
+
+// NewGroupHandler создает новый обработчик групп
+func NewGroupHandler(service service.GroupService) *GroupHandler {
+	return &GroupHandler{service: service}
+}
+
+// CreateGroup godoc
+// @Summary Создать группу
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param group body models.CreateGroupRequest true "Данные группы"
+// @Param Idempotency-Key header string false "Ключ для безопасного повтора запроса" maxLength(255)
+// @Success 201 {object} models.Group
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Группа с таким именем уже есть"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /groups [post]
+func (h *GroupHandler) CreateGroup(c *gin.Context) {
+	var req models.CreateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	group, err := h.service.CreateGroup(c.Request.Context(), &req)
+	if err != nil {
+		respondGroupError(c, "Failed to create group", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetGroups godoc
+// @Summary Получить список групп
+// @Tags groups
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+// @Success 200 {object} models.GroupListResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /groups [get]
+func (h *GroupHandler) GetGroups(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.service.GetGroups(c.Request.Context(), page, pageSize)
+	if err != nil {
+		respondGroupError(c, "Failed to get groups", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetGroup godoc
+// @Summary Получить группу по ID
+// @Tags groups
+// @Produce json
+// @Param id path int true "Group ID"
+// @Success 200 {object} models.Group
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /groups/{id} [get]
+func (h *GroupHandler) GetGroup(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+
+	group, err := h.service.GetGroup(c.Request.Context(), id)
+	if err != nil {
+		respondGroupError(c, "Group not found", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// UpdateGroup godoc
+// @Summary Обновить группу
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param group body models.UpdateGroupRequest true "Обновленные данные"
+// @Success 200 {object} models.Group
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Группа с таким именем уже есть"
+// @Router /groups/{id} [put]
+func (h *GroupHandler) UpdateGroup(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateGroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	group, err := h.service.UpdateGroup(c.Request.Context(), id, &req)
+	if err != nil {
+		respondGroupError(c, "Failed to update group", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, group)
+}
+
+// DeleteGroup godoc
+// @Summary Удалить группу
+// @Description Удаление группы вместе со всеми членствами. Пользователи не удаляются.
+// @Tags groups
+// @Param id path int true "Group ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /groups/{id} [delete]
+func (h *GroupHandler) DeleteGroup(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteGroup(c.Request.Context(), id); err != nil {
+		respondGroupError(c, "Failed to delete group", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddMember godoc
+// @Summary Добавить участника группы
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param member body models.AddGroupMemberRequest true "Пользователь и роль"
+// @Success 201 {object} models.GroupMember
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse "Группа или пользователь не найдены"
+// @Failure 409 {object} models.ErrorResponse "Пользователь уже в группе"
+// @Router /groups/{id}/members [post]
+func (h *GroupHandler) AddMember(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+
+	var req models.AddGroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	member, err := h.service.AddMember(c.Request.Context(), id, &req)
+	if err != nil {
+		respondGroupError(c, "Failed to add group member", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, member)
+}
+
+// GetMembers godoc
+// @Summary Участники группы
+// @Description Участники группы: сначала владельцы, затем администраторы, затем остальные по имени
+// @Tags groups
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+// @Success 200 {object} models.GroupMemberListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /groups/{id}/members [get]
+func (h *GroupHandler) GetMembers(c *gin.Context) {
+	id, ok := groupID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.service.GetMembers(c.Request.Context(), id, page, pageSize)
+	if err != nil {
+		respondGroupError(c, "Failed to get group members", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// UpdateMember godoc
+// @Summary Изменить роль участника группы
+// @Tags groups
+// @Accept json
+// @Produce json
+// @Param id path int true "Group ID"
+// @Param user_id path int true "User ID"
+// @Param member body models.UpdateGroupMemberRequest true "Новая роль"
+// @Success 200 {object} models.GroupMember
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /groups/{id}/members/{user_id} [put]
+func (h *GroupHandler) UpdateMember(c *gin.Context) {
+	id, userID, ok := memberIDs(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateGroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	member, err := h.service.UpdateMember(c.Request.Context(), id, userID, &req)
+	if err != nil {
+		respondGroupError(c, "Failed to update group member", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, member)
+}
+
+// RemoveMember godoc
+// @Summary Удалить участника из группы
+// @Tags groups
+// @Param id path int true "Group ID"
+// @Param user_id path int true "User ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /groups/{id}/members/{user_id} [delete]
+func (h *GroupHandler) RemoveMember(c *gin.Context) {
+	id, userID, ok := memberIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Request.Context(), id, userID); err != nil {
+		respondGroupError(c, "Failed to remove group member", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func groupID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid group ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func memberIDs(c *gin.Context) (int, int, bool) {
+	id, ok := groupID(c)
+	if !ok {
+		return 0, 0, false
+	}
+	userID, err := strconv.Atoi(c.Param("user_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return 0, 0, false
+	}
+	return id, userID, true
+}
+
+func respondGroupError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrGroupNotFound), errors.Is(err, models.ErrMemberNotFound), errors.Is(err, models.ErrUserNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrGroupNameTaken), errors.Is(err, models.ErrAlreadyGroupMember):
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   message,
+		Message: localize(c, err),
+	})
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"user-api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// localize возвращает текст ошибки err на языке запроса (см. middleware.Localize)
+func localize(c *gin.Context, err error) string {
+	return i18n.FromContext(c.Request.Context()).Error(err)
+}
+
+// text возвращает сообщение key на языке запроса
+func text(c *gin.Context, key string, params ...string) string {
+	return i18n.FromContext(c.Request.Context()).Text(key, params...)
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetadataHandler обработчик запросов администрирования схем metadata
+type MetadataHandler struct {
+	service service.MetadataService
+}
+
+// NewMetadataHandler создает новый обработчик
+func NewMetadataHandler(service service.MetadataService) *MetadataHandler {
+	return &MetadataHandler{service: service}
+}
+
+// GetSchemas godoc
+// @Summary Список схем metadata
+// @Description Зарегистрированные JSON Schema ключей metadata пользователей
+// @Tags admin
+// @Produce json
+// @Success 200 {array} models.MetadataSchema
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/metadata/schemas [get]
+func (h *MetadataHandler) GetSchemas(c *gin.Context) {
+	schemas, err := h.service.GetSchemas()
+	if err != nil {
+		respondMetadataError(c, "Failed to get metadata schemas", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schemas)
+}
+
+// GetSchema godoc
+// @Summary Получить схему ключа metadata
+// @Tags admin
+// @Produce json
+// @Param key path string true "Ключ metadata"
+// @Success 200 {object} models.MetadataSchema
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/metadata/schemas/{key} [get]
+func (h *MetadataHandler) GetSchema(c *gin.Context) {
+	schema, err := h.service.GetSchema(c.Param("key"))
+	if err != nil {
+		respondMetadataError(c, "Failed to get metadata schema", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// PutSchema godoc
+// @Summary Зарегистрировать схему ключа metadata
+// @Description Регистрация или замена JSON Schema (draft 2020-12 по умолчанию) для значения ключа. Новые и измененные значения ключа проверяются по схеме; уже сохраненные значения не перепроверяются. Внешние $ref не загружаются.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param key path string true "Ключ metadata"
+// @Param schema body models.PutMetadataSchemaRequest true "Схема"
+// @Success 200 {object} models.MetadataSchema
+// @Failure 400 {object} models.ErrorResponse "Недопустимый ключ или схема"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /admin/metadata/schemas/{key} [put]
+func (h *MetadataHandler) PutSchema(c *gin.Context) {
+	var req models.PutMetadataSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	schema, err := h.service.PutSchema(c.Param("key"), &req)
+	if err != nil {
+		respondMetadataError(c, "Failed to save metadata schema", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, schema)
+}
+
+// DeleteSchema godoc
+// @Summary Удалить схему ключа metadata
+// @Description После удаления ключ принимает любые значения
+// @Tags admin
+// @Param key path string true "Ключ metadata"
+// @Success 204
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/metadata/schemas/{key} [delete]
+func (h *MetadataHandler) DeleteSchema(c *gin.Context) {
+	if err := h.service.DeleteSchema(c.Param("key")); err != nil {
+		respondMetadataError(c, "Failed to delete metadata schema", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func respondMetadataError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrMetadataSchemaNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrInvalidMetadataSchema):
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   message,
+		Message: localize(c, err),
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"user-api/internal/format"
+	"user-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// formatKey - ключ контекста gin с форматом ответа, выбранным negotiate
+const formatKey = "response_format"
+
+// negotiate выбирает формат ответа из offers по заголовку Accept. Если
+// подходящего формата нет, отвечает 406 и возвращает false.
+func negotiate(c *gin.Context, offers []string) bool {
+	c.Writer.Header().Add("Vary", "Accept")
+	f, ok := format.Negotiate(c.GetHeader("Accept"), offers)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusNotAcceptable, models.ErrorResponse{
+			Error:   "Not acceptable",
+			Message: text(c, "messages.not_acceptable", strings.Join(offers, ", ")),
+		})
+		return false
+	}
+	c.Set(formatKey, f)
+	return true
+}
+
+// respond отдает obj в формате, выбранном negotiate (по умолчанию JSON).
+// Ответы, которые нельзя представить в CSV (ошибки), отдаются в JSON.
+func respond(c *gin.Context, status int, obj interface{}) {
+	f := c.GetString(formatKey)
+	var buf bytes.Buffer
+	var err error
+	switch f {
+	case format.XML:
+		err = format.EncodeXML(&buf, xmlRoot(obj), obj)
+	case format.MsgPack:
+		err = format.EncodeMsgPack(&buf, obj)
+	default:
+		c.JSON(status, obj)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to encode response",
+			Message: localize(c, err),
+		})
+		return
+	}
+	c.Data(status, format.ContentType(f), buf.Bytes())
+}
+
+// respondCSV отдает строки rows таблицей с колонками columns. Общее
+// число записей передается в заголовке X-Total-Count.
+func respondCSV(c *gin.Context, columns []string, rows []map[string]json.RawMessage, total int) {
+	var buf bytes.Buffer
+	if err := format.EncodeCSV(&buf, columns, rows); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to encode response",
+			Message: localize(c, err),
+		})
+		return
+	}
+	c.Header("X-Total-Count", strconv.Itoa(total))
+	c.Data(http.StatusOK, format.ContentType(format.CSV), buf.Bytes())
+}
+
+// responseFormat возвращает формат, выбранный negotiate
+func responseFormat(c *gin.Context) string {
+	if f := c.GetString(formatKey); f != "" {
+		return f
+	}
+	return format.JSON
+}
+
+// xmlRoot возвращает имя корневого элемента XML для obj
+func xmlRoot(obj interface{}) string {
+	switch obj.(type) {
+	case *models.User, models.User:
+		return "user"
+	case *models.UserListResponse, models.PartialUserListResponse:
+		return "user_list"
+	case *models.UserStats:
+		return "user_stats"
+	case models.ErrorResponse:
+		return "error"
+	}
+	return "response"
+}
+
+// bind декодирует тело запроса в obj по Content-Type (XML, MessagePack,
+// иначе JSON) и проверяет его по тегам binding
+func bind(c *gin.Context, obj interface{}) error {
+	switch format.Normalize(c.ContentType()) {
+	case format.XML:
+		return c.ShouldBindWith(obj, binding.XML)
+	case format.MsgPack:
+		data, err := format.MsgPackToJSON(c.Request.Body)
+		if err != nil {
+			return err
+		}
+		return binding.JSON.BindBody(data, obj)
+	}
+	return c.ShouldBindJSON(obj)
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"bytes"
+	_ "embed"
+	"html/template"
+	"user-api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed pages/verify_email.html
+var verifyEmailHTML string
+
+// verifyEmailPage - страница подтверждения email, на которую ведет ссылка
+// из письма
+var verifyEmailPage = template.Must(template.New("verify_email").Parse(verifyEmailHTML))
+
+// renderPage отправляет HTML страницу tmpl с данными data. Страницы
+// содержат одноразовые токены, поэтому не кэшируются.
+func renderPage(c *gin.Context, status int, tmpl *template.Template, data any) {
+	var page bytes.Buffer
+	if err := tmpl.Execute(&page, data); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(status, "text/html; charset=utf-8", page.Bytes())
+}
+
+// verifyEmailPageData - тексты страницы подтверждения email на языке запроса
+func verifyEmailPageData(c *gin.Context, token string) gin.H {
+	return gin.H{
+		"Language": i18n.FromContext(c.Request.Context()).Language(),
+		"Title":    text(c, "messages.verify_email_title"),
+		"Prompt":   text(c, "messages.verify_email_prompt"),
+		"Button":   text(c, "messages.verify_email_button"),
+		"Success":  text(c, "messages.email_verified"),
+		"Token":    token,
+	}
+}
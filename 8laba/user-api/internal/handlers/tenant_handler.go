@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TenantHandler обработчик запросов администрирования арендаторов
+type TenantHandler struct {
+	service service.TenantService
+}
+
+// NewTenantHandler создает новый обработчик арендаторов
+func NewTenantHandler(service service.TenantService) *TenantHandler {
+	return &TenantHandler{service: service}
+}
+
+// CreateTenant godoc
+// @Summary Создать арендатора
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param tenant body models.CreateTenantRequest true "Данные арендатора"
+// @Success 201 {object} models.Tenant
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 403 {object} models.ErrorResponse "Запрос не от доверенного вызывающего"
+// @Failure 409 {object} models.ErrorResponse "Арендатор с таким slug уже есть"
+// @Router /admin/tenants [post]
+func (h *TenantHandler) CreateTenant(c *gin.Context) {
+	var req models.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	t, err := h.service.CreateTenant(&req)
+	if err != nil {
+		respondTenantError(c, "Failed to create tenant", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, t)
+}
+
+// GetTenants godoc
+// @Summary Список арендаторов
+// @Tags admin
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+// @Success 200 {object} models.TenantListResponse
+// @Failure 403 {object} models.ErrorResponse "Запрос не от доверенного вызывающего"
+// @Router /admin/tenants [get]
+func (h *TenantHandler) GetTenants(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.service.GetTenants(page, pageSize)
+	if err != nil {
+		respondTenantError(c, "Failed to get tenants", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetTenant godoc
+// @Summary Получить арендатора по ID
+// @Tags admin
+// @Produce json
+// @Param id path int true "Tenant ID"
+// @Success 200 {object} models.Tenant
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/tenants/{id} [get]
+func (h *TenantHandler) GetTenant(c *gin.Context) {
+	id, ok := tenantID(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.service.GetTenant(id)
+	if err != nil {
+		respondTenantError(c, "Tenant not found", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// SuspendTenant godoc
+// @Summary Приостановить арендатора
+// @Description Запросы от имени приостановленного арендатора отклоняются с кодом 403; его пользователи и группы сохраняются
+// @Tags admin
+// @Produce json
+// @Param id path int true "Tenant ID"
+// @Success 200 {object} models.Tenant
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/tenants/{id}/suspend [post]
+func (h *TenantHandler) SuspendTenant(c *gin.Context) {
+	id, ok := tenantID(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.service.SuspendTenant(id)
+	if err != nil {
+		respondTenantError(c, "Failed to suspend tenant", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+// ActivateTenant godoc
+// @Summary Возобновить работу арендатора
+// @Tags admin
+// @Produce json
+// @Param id path int true "Tenant ID"
+// @Success 200 {object} models.Tenant
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /admin/tenants/{id}/activate [post]
+func (h *TenantHandler) ActivateTenant(c *gin.Context) {
+	id, ok := tenantID(c)
+	if !ok {
+		return
+	}
+
+	t, err := h.service.ActivateTenant(id)
+	if err != nil {
+		respondTenantError(c, "Failed to activate tenant", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, t)
+}
+
+func tenantID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid tenant ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondTenantError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, models.ErrTenantNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, models.ErrTenantSlugTaken):
+		status = http.StatusConflict
+	case errors.Is(err, models.ErrInvalidTenantSlug):
+		status = http.StatusBadRequest
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   message,
+		Message: localize(c, err),
+	})
+}
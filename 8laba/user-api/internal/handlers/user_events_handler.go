@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/feed"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseRetry - через сколько миллисекунд браузер переподключается после обрыва
+const sseRetry = 3000
+
+// UserEventsHandler отдает изменения пользователей как Server-Sent Events
+type UserEventsHandler struct {
+	feed      *feed.Feed
+	heartbeat time.Duration
+}
+
+// NewUserEventsHandler создает обработчик потока событий. Комментарий
+// heartbeat отправляется каждые heartbeat, чтобы прокси не закрывали
+// простаивающее соединение.
+func NewUserEventsHandler(feed *feed.Feed, heartbeat time.Duration) *UserEventsHandler {
+	return &UserEventsHandler{feed: feed, heartbeat: heartbeat}
+}
+
+// StreamUserEvents godoc
+// @Summary Поток изменений пользователей
+// @Description Server-Sent Events: user.created, user.updated, user.deleted. Приходят только события пользователей арендатора запроса. Поле id события можно передать в заголовке Last-Event-ID (браузер делает это сам при переподключении), чтобы получить пропущенные события; номера общие для всех экземпляров API, пропущенное читается из outbox. Если события с этим ID уже нет (удалено по outbox.retention), первым приходит событие reset: клиенту нужно заново загрузить данные.
+// @Tags users
+// @Produce text/event-stream
+// @Param user_id query []int false "Только события этих пользователей (через запятую)" collectionFormat(csv)
+// @Param type query []string false "Только события этих типов (через запятую)" collectionFormat(csv) Enums(user.created, user.updated, user.deleted)
+// @Param Last-Event-ID header string false "ID последнего полученного события"
+// @Success 200 {string} string "Поток событий"
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/events [get]
+func (h *UserEventsHandler) StreamUserEvents(c *gin.Context) {
+	filter, err := parseEventFilter(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	filter.TenantID, _ = tenant.FromContext(c.Request.Context())
+
+	var lastSeq uint64
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID != "" {
+		lastSeq, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid Last-Event-ID",
+				Message: text(c, "messages.invalid_last_event_id"),
+			})
+			return
+		}
+	}
+
+	sub, backlog, err := h.feed.Subscribe(c.Request.Context(), filter, lastSeq, lastEventID != "")
+	reset := errors.Is(err, feed.ErrUnknownEvent)
+	if err != nil && !reset {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to replay events",
+			Message: localize(c, err),
+		})
+		return
+	}
+	defer sub.Close()
+
+	// Соединение живет дольше WriteTimeout сервера
+	_ = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetry)
+	if reset {
+		// Пропущенные события восстановить нельзя. ID - последнее событие
+		// ленты, чтобы следующее переподключение продолжило с него.
+		fmt.Fprintf(c.Writer, "id: %d\nevent: reset\ndata: {}\n\n", sub.Seq())
+	}
+	for _, entry := range backlog {
+		if err := writeEvent(c.Writer, entry); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case entry, ok := <-sub.C():
+			if !ok {
+				// Клиент не успевал читать или сервер завершается
+				return
+			}
+			if err := writeEvent(c.Writer, entry); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeEvent(w io.Writer, entry feed.Entry) error {
+	data, err := json.Marshal(entry.Event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", entry.Seq, entry.Event.Type, data)
+	return err
+}
+
+// parseEventFilter читает фильтры user_id и type. Значения передаются
+// через запятую или повторением параметра.
+func parseEventFilter(c *gin.Context) (feed.Filter, error) {
+	filter := feed.Filter{
+		UserIDs: make(map[int]bool),
+		Types:   make(map[events.Type]bool),
+	}
+
+	for _, value := range splitQuery(c.QueryArray("user_id")) {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return filter, fmt.Errorf("user_id: %q is not a number", value)
+		}
+		filter.UserIDs[id] = true
+	}
+
+	for _, value := range splitQuery(c.QueryArray("type")) {
+		eventType := events.Type(value)
+		if !eventType.Valid() {
+			return filter, fmt.Errorf("type: unknown event type %q", value)
+		}
+		filter.Types[eventType] = true
+	}
+
+	return filter, nil
+}
+
+func splitQuery(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
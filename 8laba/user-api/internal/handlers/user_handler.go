@@ -1,8 +1,12 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
+	"user-api/internal/format"
 	"user-api/internal/models"
 	"user-api/internal/service"
 
@@ -23,183 +27,486 @@ func NewUserHandler(service service.UserService) *UserHandler {
 // @Summary Создать нового пользователя
 // @Description Создание нового пользователя с указанными данными
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param user body models.CreateUserRequest true "Данные пользователя"
+// @Param Idempotency-Key header string false "Ключ для безопасного повтора запроса" maxLength(255)
 // @Success 201 {object} models.User
 // @Failure 400 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
+// @Failure 409 {object} models.ErrorResponse "Email уже занят или запрос с этим Idempotency-Key еще выполняется"
+// @Failure 422 {object} models.ErrorResponse "Idempotency-Key использован с другим запросом"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users [post]
 func (h *UserHandler) CreateUser(c *gin.Context) {
+	if !negotiate(c, format.Object) {
+		return
+	}
+
 	var req models.CreateUserRequest
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+	if err := bind(c, &req); err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Validation error",
-			Message: err.Error(),
+			Message: localize(c, err),
 		})
 		return
 	}
 
-	user, err := h.service.CreateUser(&req)
+	user, err := h.service.CreateUser(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		status := http.StatusInternalServerError
+		switch {
+		case isInvalidUser(err):
+			status = http.StatusBadRequest
+		case errors.Is(err, models.ErrEmailTaken):
+			status = http.StatusConflict
+		}
+		respond(c, status, models.ErrorResponse{
 			Error:   "Failed to create user",
-			Message: err.Error(),
+			Message: localize(c, err),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, user)
+	respond(c, http.StatusCreated, user)
 }
 
 // GetUser godoc
 // @Summary Получить пользователя по ID
 // @Description Получение информации о конкретном пользователе
 // @Tags users
-// @Produce json
+// @Produce json,xml,application/msgpack
 // @Param id path int true "User ID"
 // @Success 200 {object} models.User
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
 // @Router /users/{id} [get]
 func (h *UserHandler) GetUser(c *gin.Context) {
+	if !negotiate(c, format.Object) {
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid user ID",
-			Message: "ID must be a number",
+			Message: text(c, "messages.invalid_id"),
 		})
 		return
 	}
 
-	user, err := h.service.GetUser(id)
+	user, err := h.service.GetUser(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+		respond(c, http.StatusNotFound, models.ErrorResponse{
 			Error:   "User not found",
-			Message: err.Error(),
+			Message: localize(c, err),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	respond(c, http.StatusOK, user)
 }
 
 // GetUsers godoc
 // @Summary Получить список пользователей
-// @Description Получение списка пользователей с пагинацией и фильтрацией
+// @Description Получение списка пользователей с пагинацией и фильтрацией. Поля metadata фильтруются параметрами metadata.<ключ>=<значение>, например metadata.department=sales; значение сравнивается как строка, а если это число или true/false - и как JSON литерал.
 // @Tags users
-// @Produce json
+// @Produce json,xml,application/msgpack,text/csv
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(10)
 // @Param name query string false "Filter by name"
 // @Param email query string false "Filter by email"
 // @Param min_age query int false "Minimum age"
 // @Param max_age query int false "Maximum age"
-// @Success 200 {object} models.UserListResponse
+// @Param verified query bool false "Filter by email verification status"
+// @Param group query int false "Filter by group membership (group ID)"
+// @Param sort query string false "Sort field" Enums(id, name, email, age, created_at, updated_at)
+// @Param order query string false "Sort order" Enums(asc, desc) default(asc)
+// @Param fields query string false "Поля пользователей через запятую: id, tenant_id, name, email, age, email_verified_at, avatar, metadata, created_at, updated_at" example(id,name,email)
+// @Param expand query string false "Встроить связанные ресурсы через запятую: groups - группы пользователя с ролью" example(groups)
+// @Success 200 {object} models.UserListResponse "С fields в пользователях есть только выбранные поля; expand=groups добавляет groups (пустой список, если групп нет)"
+// @Failure 400 {object} models.ErrorResponse "Недопустимый ключ metadata, поле или ресурс"
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
 // @Failure 500 {object} models.ErrorResponse
 // @Router /users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
+	if !negotiate(c, format.List) {
+		return
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
 
-	filters := make(map[string]interface{})
-
-	if name := c.Query("name"); name != "" {
-		filters["name"] = name
+	filters, err := service.ParseUserFilters(c.Request.URL.Query())
+	if err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: localize(c, err),
+		})
+		return
 	}
-	if email := c.Query("email"); email != "" {
-		filters["email"] = email
+	fields, err := models.ParseUserFields(c.Query("fields"))
+	if err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid fields",
+			Message: localize(c, err),
+		})
+		return
+	}
+	if len(fields) > 0 {
+		filters["fields"] = fields
+	}
+	expand, err := models.ParseUserExpand(c.Query("expand"))
+	if err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid expand",
+			Message: localize(c, err),
+		})
+		return
 	}
-	if minAge, err := strconv.Atoi(c.Query("min_age")); err == nil && minAge > 0 {
-		filters["min_age"] = minAge
+	if len(expand) > 0 {
+		filters["expand"] = expand
 	}
-	if maxAge, err := strconv.Atoi(c.Query("max_age")); err == nil && maxAge > 0 {
-		filters["max_age"] = maxAge
+
+	response, err := h.service.GetUsers(c.Request.Context(), page, pageSize, filters)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get users",
+			Message: localize(c, err),
+		})
+		return
 	}
 
-	response, err := h.service.GetUsers(page, pageSize, filters)
+	csv := responseFormat(c) == format.CSV
+	if len(fields) == 0 && len(expand) == 0 && !csv {
+		respond(c, http.StatusOK, response)
+		return
+	}
+	users, err := projectUsers(response.Users, fields, expand)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		respond(c, http.StatusInternalServerError, models.ErrorResponse{
 			Error:   "Failed to get users",
-			Message: err.Error(),
+			Message: localize(c, err),
+		})
+		return
+	}
+	if csv {
+		columns := fields
+		if len(columns) == 0 {
+			columns = models.UserFields
+		}
+		respondCSV(c, append(columns[:len(columns):len(columns)], expand...), users, response.Total)
+		return
+	}
+	respond(c, http.StatusOK, models.PartialUserListResponse{
+		Users:      users,
+		Total:      response.Total,
+		Page:       response.Page,
+		PageSize:   response.PageSize,
+		TotalPages: response.TotalPages,
+	})
+}
+
+// GetUserStats godoc
+// @Summary Статистика пользователей
+// @Description Число пользователей, гистограмма и средний и медианный возраст, регистрации по дням, неделям или месяцам (по created_at в UTC). Фильтры те же, что у списка пользователей, включая metadata.<ключ>=<значение>. Результат кэшируется на сервере и клиентом на stats.cache_ttl, поэтому может отставать от данных.
+// @Tags users
+// @Produce json,xml,application/msgpack
+// @Param name query string false "Filter by name"
+// @Param email query string false "Filter by email"
+// @Param min_age query int false "Minimum age"
+// @Param max_age query int false "Maximum age"
+// @Param verified query bool false "Filter by email verification status"
+// @Param group query int false "Filter by group membership (group ID)"
+// @Param age_buckets query string false "Возрастающие границы групп возраста через запятую (до 20, от 1 до 150): группы младше первой границы, между соседними и от последней" default(18,25,35,45,55,65)
+// @Param interval query string false "Период графика регистраций" Enums(day, week, month) default(day)
+// @Param from query string false "Начало окна графика регистраций: дата (2026-01-01) или время RFC 3339; по умолчанию to минус год"
+// @Param to query string false "Конец окна графика регистраций (не включая): дата или время RFC 3339; по умолчанию текущий момент. Окно - не больше 1000 периодов interval"
+// @Success 200 {object} models.UserStats
+// @Failure 400 {object} models.ErrorResponse "Недопустимый ключ metadata, границы групп, период или окно графика регистраций"
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/stats [get]
+func (h *UserHandler) GetUserStats(c *gin.Context) {
+	if !negotiate(c, format.Object) {
+		return
+	}
+
+	filters, err := service.ParseUserFilters(c.Request.URL.Query())
+	if err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid filter",
+			Message: localize(c, err),
+		})
+		return
+	}
+	// Сортировка на статистику не влияет и не должна дробить кэш
+	delete(filters, "sort_by")
+	delete(filters, "sort_order")
+
+	var opts models.UserStatsOptions
+	if opts.AgeBuckets, err = models.ParseAgeBuckets(c.Query("age_buckets")); err == nil {
+		opts.Interval, err = models.ParseStatsInterval(c.Query("interval"))
+	}
+	if err == nil {
+		opts.From, opts.To, err = models.ParseStatsRange(c.Query("from"), c.Query("to"), opts.Interval, time.Now())
+	}
+	if err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid stats parameters",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	stats, err := h.service.GetUserStats(c.Request.Context(), filters, opts)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get user stats",
+			Message: localize(c, err),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, response)
+	respond(c, http.StatusOK, stats)
 }
 
 // UpdateUser godoc
 // @Summary Обновить пользователя
 // @Description Обновление информации о пользователе
 // @Tags users
-// @Accept json
-// @Produce json
+// @Accept json,xml,application/msgpack
+// @Produce json,xml,application/msgpack
 // @Param id path int true "User ID"
 // @Param user body models.UpdateUserRequest true "Обновленные данные"
 // @Success 200 {object} models.User
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
+// @Failure 409 {object} models.ErrorResponse "Email уже занят"
 // @Router /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
+	if !negotiate(c, format.Object) {
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid user ID",
-			Message: "ID must be a number",
+			Message: text(c, "messages.invalid_id"),
 		})
 		return
 	}
 
 	var req models.UpdateUserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+	if err := bind(c, &req); err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Validation error",
-			Message: err.Error(),
+			Message: localize(c, err),
 		})
 		return
 	}
 
-	user, err := h.service.UpdateUser(id, &req)
+	user, err := h.service.UpdateUser(c.Request.Context(), id, &req)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+		status := http.StatusNotFound
+		switch {
+		case isInvalidUser(err):
+			status = http.StatusBadRequest
+		case errors.Is(err, models.ErrEmailTaken):
+			status = http.StatusConflict
+		}
+		respond(c, status, models.ErrorResponse{
 			Error:   "Failed to update user",
-			Message: err.Error(),
+			Message: localize(c, err),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
+	respond(c, http.StatusOK, user)
 }
 
 // DeleteUser godoc
 // @Summary Удалить пользователя
 // @Description Удаление пользователя по ID
 // @Tags users
-// @Produce json
+// @Produce json,xml,application/msgpack
 // @Param id path int true "User ID"
 // @Success 204
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 404 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
 // @Router /users/{id} [delete]
 func (h *UserHandler) DeleteUser(c *gin.Context) {
+	if !negotiate(c, format.Object) {
+		return
+	}
+
 	id, err := strconv.Atoi(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
 			Error:   "Invalid user ID",
-			Message: "ID must be a number",
+			Message: text(c, "messages.invalid_id"),
 		})
 		return
 	}
 
-	if err := h.service.DeleteUser(id); err != nil {
-		c.JSON(http.StatusNotFound, models.ErrorResponse{
+	if err := h.service.DeleteUser(c.Request.Context(), id); err != nil {
+		respond(c, http.StatusNotFound, models.ErrorResponse{
 			Error:   "Failed to delete user",
-			Message: err.Error(),
+			Message: localize(c, err),
 		})
 		return
 	}
 
 	c.Status(http.StatusNoContent)
 }
+
+// VerifyEmailPage godoc
+// @Summary Страница подтверждения email
+// @Description Страница, на которую ведет ссылка из письма. Токен расходуется не при открытии страницы, а по нажатию кнопки (POST /verify-email): почтовые сервисы, проверяющие ссылки, его не используют.
+// @Tags users
+// @Produce html
+// @Param token query string true "Токен подтверждения"
+// @Success 200 {string} string "HTML страница"
+// @Router /verify-email [get]
+func (h *UserHandler) VerifyEmailPage(c *gin.Context) {
+	renderPage(c, http.StatusOK, verifyEmailPage, verifyEmailPageData(c, c.Query("token")))
+}
+
+// VerifyEmail godoc
+// @Summary Подтвердить email
+// @Description Подтверждение email пользователя по одноразовому токену из письма
+// @Tags users
+// @Accept json
+// @Produce json,xml,application/msgpack
+// @Param request body models.VerifyEmailRequest true "Токен подтверждения"
+// @Success 200 {object} models.User
+// @Failure 400 {object} models.ErrorResponse "Токен недействителен, уже использован или истек"
+// @Failure 404 {object} models.ErrorResponse "Подтверждение email отключено"
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
+// @Router /verify-email [post]
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	if !negotiate(c, format.Object) {
+		return
+	}
+
+	var req models.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	user, err := h.service.VerifyEmail(c.Request.Context(), req.Token)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, models.ErrInvalidToken), errors.Is(err, models.ErrTokenExpired):
+			status = http.StatusBadRequest
+		case errors.Is(err, models.ErrVerificationDisabled):
+			status = http.StatusNotFound
+		}
+		respond(c, status, models.ErrorResponse{
+			Error:   "Failed to verify email",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, user)
+}
+
+// ResendVerification godoc
+// @Summary Отправить письмо с подтверждением повторно
+// @Description Выдача нового токена подтверждения email и отправка письма
+// @Tags users
+// @Produce json,xml,application/msgpack
+// @Param id path int true "User ID"
+// @Success 202
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Failure 406 {object} models.ErrorResponse "Формат из Accept не поддерживается"
+// @Failure 409 {object} models.ErrorResponse "Email уже подтвержден"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /users/{id}/verification [post]
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	if !negotiate(c, format.Object) {
+		return
+	}
+
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		respond(c, http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid user ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return
+	}
+
+	if err := h.service.ResendVerification(c.Request.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, models.ErrUserNotFound), errors.Is(err, models.ErrVerificationDisabled):
+			status = http.StatusNotFound
+		case errors.Is(err, models.ErrEmailAlreadyVerified):
+			status = http.StatusConflict
+		}
+		respond(c, status, models.ErrorResponse{
+			Error:   "Failed to send verification email",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// projectUsers оставляет в пользователях только поля fields (все, если
+// fields пуст) и ресурсы expand. Запрошенное поле без значения выводится
+// как null, ресурс без записей - как пустой список.
+func projectUsers(users []models.User, fields, expand []string) ([]map[string]json.RawMessage, error) {
+	result := make([]map[string]json.RawMessage, len(users))
+	for i := range users {
+		data, err := json.Marshal(&users[i])
+		if err != nil {
+			return nil, err
+		}
+		var all map[string]json.RawMessage
+		if err := json.Unmarshal(data, &all); err != nil {
+			return nil, err
+		}
+
+		view := all
+		if len(fields) > 0 {
+			view = make(map[string]json.RawMessage, len(fields)+len(expand))
+			for _, field := range fields {
+				view[field] = json.RawMessage("null")
+				if value, ok := all[field]; ok {
+					view[field] = value
+				}
+			}
+		}
+		for _, name := range expand {
+			view[name] = json.RawMessage("[]")
+			if value, ok := all[name]; ok {
+				view[name] = value
+			}
+		}
+		result[i] = view
+	}
+	return result, nil
+}
+
+// isInvalidUser проверяет, что сервис отклонил данные пользователя
+// (после нормализации или по схеме metadata)
+func isInvalidUser(err error) bool {
+	return errors.Is(err, models.ErrInvalidMetadata) ||
+		errors.Is(err, models.ErrInvalidEmail) ||
+		errors.Is(err, models.ErrInvalidName)
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler обработчик HTTP запросов для вебхуков
+type WebhookHandler struct {
+	service service.WebhookService
+}
+
+// NewWebhookHandler создает новый обработчик вебхуков
+func NewWebhookHandler(service service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// CreateWebhook godoc
+// @Summary Создать вебхук
+// @Description Подписка на события пользователей. Секрет для проверки подписи возвращается только в этом ответе.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body models.CreateWebhookRequest true "Данные вебхука"
+// @Param Idempotency-Key header string false "Ключ для безопасного повтора запроса" maxLength(255)
+// @Success 201 {object} models.Webhook
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 409 {object} models.ErrorResponse "Запрос с этим Idempotency-Key еще выполняется"
+// @Failure 422 {object} models.ErrorResponse "Idempotency-Key использован с другим запросом"
+// @Failure 500 {object} models.ErrorResponse
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req models.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	webhook, err := h.service.CreateWebhook(&req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to create webhook",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// GetWebhooks godoc
+// @Summary Получить список вебхуков
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} models.Webhook
+// @Failure 500 {object} models.ErrorResponse
+// @Router /webhooks [get]
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.service.GetWebhooks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Error:   "Failed to get webhooks",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhooks)
+}
+
+// GetWebhook godoc
+// @Summary Получить вебхук по ID
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	webhook, err := h.service.GetWebhook(id)
+	if err != nil {
+		respondWebhookError(c, "Webhook not found", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// UpdateWebhook godoc
+// @Summary Обновить вебхук
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param webhook body models.UpdateWebhookRequest true "Обновленные данные"
+// @Success 200 {object} models.Webhook
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Validation error",
+			Message: localize(c, err),
+		})
+		return
+	}
+
+	webhook, err := h.service.UpdateWebhook(id, &req)
+	if err != nil {
+		respondWebhookError(c, "Failed to update webhook", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// DeleteWebhook godoc
+// @Summary Удалить вебхук
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteWebhook(id); err != nil {
+		respondWebhookError(c, "Failed to delete webhook", err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetDeliveries godoc
+// @Summary Журнал доставок вебхука
+// @Description Попытки доставки событий, начиная с последних
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(20)
+// @Success 200 {object} models.WebhookDeliveryListResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) GetDeliveries(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	response, err := h.service.GetDeliveries(id, page, pageSize)
+	if err != nil {
+		respondWebhookError(c, "Failed to get deliveries", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Redeliver godoc
+// @Summary Повторно отправить событие
+// @Description Ставит событие из журнала в очередь заново (в том числе из dead-letter)
+// @Tags webhooks
+// @Produce json
+// @Param id path int true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} models.WebhookDelivery
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 404 {object} models.ErrorResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, ok := webhookID(c)
+	if !ok {
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid delivery ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return
+	}
+
+	delivery, err := h.service.Redeliver(id, deliveryID)
+	if err != nil {
+		respondWebhookError(c, "Failed to redeliver", err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func webhookID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "Invalid webhook ID",
+			Message: text(c, "messages.invalid_id"),
+		})
+		return 0, false
+	}
+	return id, true
+}
+
+func respondWebhookError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, models.ErrWebhookNotFound) || errors.Is(err, models.ErrDeliveryNotFound) {
+		status = http.StatusNotFound
+	}
+
+	c.JSON(status, models.ErrorResponse{
+		Error:   message,
+		Message: localize(c, err),
+	})
+}
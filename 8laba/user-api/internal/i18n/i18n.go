@@ -0,0 +1,130 @@
+// Package i18n переводит сообщения об ошибках API на язык клиента
+// (заголовок Accept-Language). Сообщения хранятся в locales/<язык>.yaml и
+// загружаются в universal-translator; язык по умолчанию - английский.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var bundles embed.FS
+
+// DefaultLanguage - язык, если клиент не указал поддерживаемый
+const DefaultLanguage = "en"
+
+// Languages - поддерживаемые языки
+var Languages = []string{"en", "ru"}
+
+// Translator хранит переводы сообщений на все поддерживаемые языки
+type Translator struct {
+	uni *ut.UniversalTranslator
+}
+
+// New загружает переводы из locales/*.yaml
+func New() (*Translator, error) {
+	supported := map[string]locales.Translator{"en": en.New(), "ru": ru.New()}
+	uni := ut.New(supported[DefaultLanguage], supported["en"], supported["ru"])
+
+	for _, lang := range Languages {
+		data, err := bundles.ReadFile("locales/" + lang + ".yaml")
+		if err != nil {
+			return nil, err
+		}
+		var bundle map[string]map[string]string
+		if err := yaml.Unmarshal(data, &bundle); err != nil {
+			return nil, fmt.Errorf("locales/%s.yaml: %w", lang, err)
+		}
+		trans, _ := uni.GetTranslator(lang)
+		for section, messages := range bundle {
+			for key, text := range messages {
+				if err := trans.Add(section+"."+key, text, false); err != nil {
+					return nil, fmt.Errorf("locales/%s.yaml: %w", lang, err)
+				}
+			}
+		}
+	}
+	return &Translator{uni: uni}, nil
+}
+
+// Localizer возвращает переводчик на язык из заголовка Accept-Language
+func (t *Translator) Localizer(acceptLanguage string) *Localizer {
+	// Если ни один язык не поддерживается, возвращается язык по умолчанию
+	trans, _ := t.uni.FindTranslator(preferredLanguages(acceptLanguage)...)
+	return &Localizer{trans: trans}
+}
+
+// preferredLanguages возвращает основные языки из Accept-Language в
+// порядке убывания q ("ru-RU, en;q=0.8" → ru, en)
+func preferredLanguages(acceptLanguage string) []string {
+	type language struct {
+		tag string
+		q   float64
+	}
+	var languages []language
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			var err error
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if base != "" && base != "*" && q > 0 {
+			languages = append(languages, language{tag: base, q: q})
+		}
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].q > languages[j].q })
+
+	tags := make([]string, len(languages))
+	for i, l := range languages {
+		tags[i] = l.tag
+	}
+	return tags
+}
+
+// fallback - английский Localizer для сообщений вне запроса с
+// Localizer (см. Text)
+var fallback = sync.OnceValue(func() *Localizer {
+	t, err := New()
+	if err != nil {
+		panic(err)
+	}
+	return t.Localizer(DefaultLanguage)
+})
+
+type ctxKey struct{}
+
+// WithLocalizer возвращает контекст запроса с языком сообщений l
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext возвращает Localizer запроса или nil
+func FromContext(ctx context.Context) *Localizer {
+	l, _ := ctx.Value(ctxKey{}).(*Localizer)
+	return l
+}
+
+// text возвращает сообщение key с параметрами; если перевода нет - key
+func text(trans ut.Translator, key string, params ...string) string {
+	message, err := trans.T(key, params...)
+	if err != nil {
+		return key
+	}
+	return message
+}
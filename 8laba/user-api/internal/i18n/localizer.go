@@ -0,0 +1,306 @@
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"user-api/internal/avatar"
+	"user-api/internal/models"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// Localizer переводит сообщения на язык одного запроса. Методы можно
+// вызывать у nil: тогда сообщения возвращаются на английском, а ошибки -
+// без изменений (err.Error()).
+type Localizer struct {
+	trans ut.Translator
+}
+
+// Language возвращает язык сообщений
+func (l *Localizer) Language() string {
+	if l == nil {
+		return DefaultLanguage
+	}
+	return l.trans.Locale()
+}
+
+// Text возвращает сообщение key (например, "messages.invalid_id") с
+// параметрами params
+func (l *Localizer) Text(key string, params ...string) string {
+	if l == nil {
+		l = fallback()
+	}
+	return text(l.trans, key, params...)
+}
+
+// domainErrors - ключи переводов доменных ошибок
+var domainErrors = []struct {
+	err error
+	key string
+}{
+	{models.ErrUserNotFound, "errors.user_not_found"},
+	{models.ErrEmailTaken, "errors.email_taken"},
+	{models.ErrInvalidEmail, "errors.invalid_email"},
+	{models.ErrInvalidName, "errors.invalid_name"},
+	{models.ErrInvalidToken, "errors.invalid_token"},
+	{models.ErrTokenExpired, "errors.token_expired"},
+	{models.ErrEmailAlreadyVerified, "errors.email_already_verified"},
+	{models.ErrVerificationDisabled, "errors.verification_disabled"},
+	{models.ErrInvalidResetToken, "errors.invalid_reset_token"},
+	{models.ErrGroupNotFound, "errors.group_not_found"},
+	{models.ErrGroupNameTaken, "errors.group_name_taken"},
+	{models.ErrMemberNotFound, "errors.member_not_found"},
+	{models.ErrAlreadyGroupMember, "errors.already_group_member"},
+	{models.ErrTenantNotFound, "errors.tenant_not_found"},
+	{models.ErrTenantSlugTaken, "errors.tenant_slug_taken"},
+	{models.ErrInvalidTenantSlug, "errors.invalid_tenant_slug"},
+	{models.ErrTenantSuspended, "errors.tenant_suspended"},
+	{models.ErrTenantRequired, "errors.tenant_required"},
+	{models.ErrInvalidAuthToken, "errors.invalid_auth_token"},
+	{models.ErrUntrustedCaller, "errors.untrusted_caller"},
+	{models.ErrWebhookNotFound, "errors.webhook_not_found"},
+	{models.ErrDeliveryNotFound, "errors.delivery_not_found"},
+	{models.ErrDeliveryExists, "errors.delivery_exists"},
+	{models.ErrInvalidMetadata, "errors.invalid_metadata"},
+	{models.ErrInvalidMetadataSchema, "errors.invalid_metadata_schema"},
+	{models.ErrMetadataSchemaNotFound, "errors.metadata_schema_not_found"},
+	{models.ErrUnknownField, "errors.unknown_field"},
+	{models.ErrUnknownExpansion, "errors.unknown_expansion"},
+	{models.ErrInvalidAgeBuckets, "errors.invalid_age_buckets"},
+	{models.ErrInvalidInterval, "errors.invalid_interval"},
+	{models.ErrInvalidStatsRange, "errors.invalid_stats_range"},
+	{models.ErrTooManyPeriods, "errors.too_many_periods"},
+	{avatar.ErrUnsupportedType, "errors.unsupported_image"},
+	{avatar.ErrInvalidImage, "errors.invalid_image"},
+	{avatar.ErrTooLarge, "errors.image_too_large"},
+}
+
+// Error возвращает текст ошибки err на языке запроса. Переводятся ошибки
+// проверки запроса (теги binding и схема OpenAPI), ошибки разбора JSON и
+// доменные ошибки; остальные возвращаются как есть.
+func (l *Localizer) Error(err error) string {
+	if l == nil || err == nil {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	}
+
+	// MultiError.As находит вложенные ошибки, поэтому список ошибок
+	// проверяется до остальных типов
+	if multi, ok := err.(openapi3.MultiError); ok {
+		return l.join(multi)
+	}
+	var requestErr *openapi3filter.RequestError
+	if errors.As(err, &requestErr) {
+		return l.requestError(requestErr)
+	}
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		messages := make([]string, len(validationErrs))
+		for i, fe := range validationErrs {
+			messages[i] = fe.Translate(l.trans)
+		}
+		return strings.Join(messages, "; ")
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var rateErr *models.RateLimitError
+	switch {
+	case errors.Is(err, io.EOF):
+		return l.Text("body.empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return l.Text("body.invalid")
+	case errors.As(err, &syntaxErr):
+		return l.Text("body.syntax", strconv.FormatInt(syntaxErr.Offset, 10))
+	case errors.As(err, &typeErr):
+		return l.Text("validation.type", l.field(typeErr.Field), l.Text("types."+jsonType(typeErr.Type.Kind().String())))
+	case errors.As(err, &rateErr):
+		return l.Text("errors.rate_limited", strconv.Itoa(int(rateErr.RetryAfter.Seconds()+0.5)))
+	}
+
+	for _, d := range domainErrors {
+		if errors.Is(err, d.err) {
+			// Уточнение после текста ошибки ("invalid metadata: ...")
+			// сохраняется: обычно это имя поля или ключа
+			detail, _ := strings.CutPrefix(err.Error(), d.err.Error())
+			if detail == err.Error() {
+				detail = ""
+			}
+			return l.Text(d.key) + detail
+		}
+	}
+	return err.Error()
+}
+
+// join переводит каждую ошибку из errs и объединяет сообщения через "; "
+func (l *Localizer) join(errs []error) string {
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		if message := l.Error(e); message != "" {
+			messages = append(messages, message)
+		}
+	}
+	return strings.Join(messages, "; ")
+}
+
+// requestError переводит ошибку проверки параметра или тела по схеме OpenAPI
+func (l *Localizer) requestError(err *openapi3filter.RequestError) string {
+	param := ""
+	if err.Parameter != nil {
+		param = err.Parameter.Name
+	}
+
+	var schemaErr *openapi3.SchemaError
+	var multi openapi3.MultiError
+	var parseErr *openapi3filter.ParseError
+	switch {
+	case errors.Is(err.Err, openapi3filter.ErrInvalidRequired), errors.Is(err.Err, openapi3filter.ErrInvalidEmptyValue):
+		if param == "" {
+			return l.Text("body.empty")
+		}
+		return l.Text("validation.required", l.field(param))
+	case errors.As(err.Err, &multi) && len(multi) > 0:
+		messages := make([]string, 0, len(multi))
+		for _, e := range multi {
+			if errors.As(e, &schemaErr) {
+				messages = append(messages, l.schemaError(param, schemaErr))
+			} else {
+				messages = append(messages, l.Error(e))
+			}
+		}
+		return strings.Join(messages, "; ")
+	case errors.As(err.Err, &schemaErr):
+		return l.schemaError(param, schemaErr)
+	case errors.As(err.Err, &parseErr):
+		if param == "" {
+			return l.Text("body.invalid")
+		}
+		if schema := err.Parameter.Schema; schema != nil && schema.Value != nil && schema.Value.Type != nil {
+			if types := schema.Value.Type.Slice(); len(types) > 0 {
+				return l.Text("validation.type", l.field(param), l.Text("types."+types[0]))
+			}
+		}
+		return l.Text("validation.invalid", l.field(param))
+	}
+	return err.Error()
+}
+
+// schemaError переводит нарушение схемы значением параметра param или
+// полем тела запроса (путь берется из ошибки)
+func (l *Localizer) schemaError(param string, err *openapi3.SchemaError) string {
+	field := param
+	if path := err.JSONPointer(); len(path) > 0 {
+		field = strings.Join(path, ".")
+	}
+	if field == "" {
+		return l.Text("body.invalid")
+	}
+	label := l.field(field)
+
+	schema := err.Schema
+	if schema == nil {
+		schema = openapi3.NewSchema()
+	}
+	switch err.SchemaField {
+	case "required":
+		return l.Text("validation.required", label)
+	case "minLength":
+		return l.Text("validation.min-string", label, strconv.FormatUint(schema.MinLength, 10))
+	case "maxLength":
+		return l.Text("validation.max-string", label, formatUint(schema.MaxLength))
+	case "minimum":
+		return l.Text("validation.min-number", label, formatFloat(schema.Min))
+	case "maximum":
+		return l.Text("validation.max-number", label, formatFloat(schema.Max))
+	case "minItems":
+		return l.Text("validation.min-items", label, strconv.FormatUint(schema.MinItems, 10))
+	case "maxItems":
+		return l.Text("validation.max-items", label, formatUint(schema.MaxItems))
+	case "enum":
+		values := make([]string, len(schema.Enum))
+		for i, v := range schema.Enum {
+			values[i] = strings.Trim(string(mustMarshal(v)), `"`)
+		}
+		return l.Text("validation.oneof", label, strings.Join(values, ", "))
+	case "format":
+		switch schema.Format {
+		case "email":
+			return l.Text("validation.email", label)
+		case "uri", "url":
+			return l.Text("validation.url", label)
+		}
+	case "pattern":
+		return l.Text("validation.pattern", label)
+	case "type":
+		if schema.Type != nil && len(schema.Type.Slice()) > 0 {
+			return l.Text("validation.type", label, l.Text("types."+schema.Type.Slice()[0]))
+		}
+	}
+	return l.Text("validation.invalid", label)
+}
+
+// field возвращает название поля на языке запроса. Для вложенного поля
+// ("metadata.team") переводится первая часть, остальные сохраняются.
+func (l *Localizer) field(name string) string {
+	return fieldLabel(l.trans, name)
+}
+
+func fieldLabel(trans ut.Translator, name string) string {
+	head, rest, nested := strings.Cut(name, ".")
+	base, index, indexed := strings.Cut(head, "[")
+	label, err := trans.T("fields." + base)
+	if err != nil || label == "" {
+		return name
+	}
+	if indexed {
+		label += "[" + index
+	}
+	if nested {
+		label += "." + rest
+	}
+	return label
+}
+
+// jsonType возвращает тип JSON для типа Go (reflect.Kind)
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"):
+		return "integer"
+	case strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "array"
+	case kind == "map", kind == "struct":
+		return "object"
+	}
+	return "string"
+}
+
+func formatUint(v *uint64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatUint(*v, 10)
+}
+
+func formatFloat(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*v, 'g', -1, 64)
+}
+
+func mustMarshal(v interface{}) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"reflect"
+	"strings"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+)
+
+// validationTags - теги binding, сообщения для которых берутся из
+// locales/*.yaml; для остальных тегов используются переводы validator
+var validationTags = []string{"required", "email", "url", "oneof", "min", "max", "not_disposable", "not_blocked"}
+
+// RegisterValidator регистрирует переводы сообщений v. В сообщениях поле
+// называется по тегу json (и переводится по разделу fields), а не по имени
+// поля структуры. Для одного Translator вызывается один раз.
+func (t *Translator) RegisterValidator(v *validator.Validate) error {
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	defaults := map[string]func(*validator.Validate, ut.Translator) error{
+		"en": en_translations.RegisterDefaultTranslations,
+		"ru": ru_translations.RegisterDefaultTranslations,
+	}
+	for _, lang := range Languages {
+		trans, _ := t.uni.GetTranslator(lang)
+		if err := defaults[lang](v, trans); err != nil {
+			return err
+		}
+		for _, tag := range validationTags {
+			err := v.RegisterTranslation(tag, trans, func(ut.Translator) error { return nil }, translateField)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// translateField возвращает сообщение validation.<тег> для поля fe. Для
+// min и max ключ зависит от типа поля: длина строки, число элементов или
+// значение.
+func translateField(trans ut.Translator, fe validator.FieldError) string {
+	key := "validation." + fe.Tag()
+	param := fe.Param()
+	switch fe.Tag() {
+	case "min", "max":
+		switch fe.Kind() {
+		case reflect.String:
+			key += "-string"
+		case reflect.Slice, reflect.Array, reflect.Map:
+			key += "-items"
+		default:
+			key += "-number"
+		}
+	case "oneof":
+		param = strings.Join(strings.Fields(param), ", ")
+	}
+	return text(trans, key, fieldLabel(trans, fe.Field()), param)
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CacheControl разрешает клиенту кэшировать успешные ответы на maxAge.
+// Ответы зависят от арендатора и токена, поэтому кэш - только private.
+// Ответы с ошибкой не кэшируются; maxAge меньше секунды отключает
+// заголовок.
+func CacheControl(maxAge time.Duration) gin.HandlerFunc {
+	seconds := int(maxAge / time.Second)
+	return func(c *gin.Context) {
+		if seconds > 0 {
+			c.Writer = &cacheControlWriter{ResponseWriter: c.Writer, value: fmt.Sprintf("private, max-age=%d", seconds)}
+		}
+		c.Next()
+	}
+}
+
+// cacheControlWriter добавляет Cache-Control перед отправкой заголовков
+// успешного ответа
+type cacheControlWriter struct {
+	gin.ResponseWriter
+	value string
+}
+
+func (w *cacheControlWriter) setHeader() {
+	if !w.Written() && w.Status() < http.StatusBadRequest {
+		w.Header().Set("Cache-Control", w.value)
+	}
+}
+
+func (w *cacheControlWriter) WriteHeaderNow() {
+	w.setHeader()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheControlWriter) WriteString(s string) (int, error) {
+	w.setHeader()
+	return w.ResponseWriter.WriteString(s)
+}
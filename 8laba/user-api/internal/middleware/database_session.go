@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"user-api/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DatabaseSession делает каждый запрос сессией database.Cluster: после
+// первой записи чтение в запросе идет в основную БД, а не в реплики
+func DatabaseSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(database.WithSession(c.Request.Context()))
+		c.Next()
+	}
+}
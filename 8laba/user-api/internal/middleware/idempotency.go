@@ -0,0 +1,268 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+	"user-api/internal/format"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Заголовки идемпотентных запросов
+const (
+	HeaderIdempotencyKey     = "Idempotency-Key"
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+)
+
+const (
+	maxIdempotencyKeyLength = 255
+	idempotencyPollInterval = 50 * time.Millisecond
+)
+
+// IdempotencyConfig содержит настройки обработки Idempotency-Key
+type IdempotencyConfig struct {
+	// TTL - сколько хранится ответ на запрос
+	TTL time.Duration
+	// LockTimeout - сколько повтор ждет завершения первого запроса. Пока
+	// запрос выполняется, блокировка ключа продлевается каждую треть
+	// LockTimeout; ключ запроса, переставшего ее продлевать (например,
+	// из-за падения процесса), может занять следующий запрос.
+	LockTimeout time.Duration
+	// MaxBodySize - наибольший размер тела запроса с ключом в байтах. Тело
+	// читается в память целиком, чтобы сравнить его с телом первого
+	// запроса; запросы больше отклоняются с кодом 413.
+	MaxBodySize int64
+}
+
+// Idempotency middleware для POST запросов с заголовком Idempotency-Key.
+// Ответ на первый запрос сохраняется, и повторы с тем же ключом и телом
+// получают его без повторного выполнения. Повтор с тем же ключом, но
+// другим телом, Content-Type или форматом ответа из Accept отклоняется с
+// кодом 422. Повтор, пришедший во время
+// выполнения первого запроса, ждет его завершения. Ключи разных
+// арендаторов не пересекаются, если middleware стоит после Tenant.
+func Idempotency(repo repository.IdempotencyRepository, cfg IdempotencyConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid Idempotency-Key",
+				Message: localizer(c).Text("messages.idempotency_key_too_long"),
+			})
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, cfg.MaxBodySize))
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, models.ErrorResponse{
+				Error:   "Request body too large",
+				Message: localizer(c).Text("messages.idempotency_body_too_large", strconv.FormatInt(cfg.MaxBodySize, 10)),
+			})
+			return
+		}
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Invalid request body",
+				Message: err.Error(),
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash := requestHash(c.Request, body)
+		key = scopedKey(c, key)
+
+		owner := newLockOwner()
+		deadline := time.Now().Add(cfg.LockTimeout)
+		for {
+			acquired, record, err := repo.Acquire(key, owner, hash, cfg.TTL, cfg.LockTimeout)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, models.ErrorResponse{
+					Error:   "Internal server error",
+					Message: err.Error(),
+				})
+				return
+			}
+
+			switch {
+			case acquired:
+				executeIdempotent(c, repo, key, owner, cfg.LockTimeout)
+				return
+			case record == nil:
+				// Ключ освободили между попытками - пробуем занять снова
+				continue
+			case record.RequestHash != hash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, models.ErrorResponse{
+					Error:   "Idempotency-Key reused",
+					Message: localizer(c).Text("messages.idempotency_key_reused"),
+				})
+				return
+			case record.StatusCode != nil:
+				replay(c, record)
+				return
+			}
+
+			// Первый запрос еще выполняется
+			if time.Now().After(deadline) {
+				c.AbortWithStatusJSON(http.StatusConflict, models.ErrorResponse{
+					Error:   "Request in progress",
+					Message: localizer(c).Text("messages.idempotency_in_progress"),
+				})
+				return
+			}
+			select {
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+	}
+}
+
+// executeIdempotent выполняет запрос и сохраняет ответ. Ответы 5xx не
+// сохраняются: ключ освобождается, и повтор выполнит запрос заново. Если
+// блокировку перехватил другой запрос, его запись не меняется.
+func executeIdempotent(c *gin.Context, repo repository.IdempotencyRepository, key, owner string, lockTimeout time.Duration) {
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go extendLock(repo, key, owner, lockTimeout, stop)
+
+	completed := false
+	defer func() {
+		if !completed {
+			// Обработчик запаниковал: ответ неизвестен
+			if err := repo.Release(key, owner); err != nil {
+				log.Printf("Idempotency: %v", err)
+			}
+		}
+	}()
+
+	c.Next()
+	completed = true
+
+	status := recorder.Status()
+	if status >= http.StatusInternalServerError {
+		if err := repo.Release(key, owner); err != nil {
+			log.Printf("Idempotency: %v", err)
+		}
+		return
+	}
+	if err := repo.Complete(key, owner, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+		log.Printf("Idempotency: %v", err)
+	}
+}
+
+// extendLock продлевает блокировку key каждую треть lockTimeout до
+// закрытия stop, чтобы долгий запрос не выполнился повторно. Если
+// продлить не удается дольше lockTimeout (например, недоступна БД), ключ
+// может занять повтор.
+func extendLock(repo repository.IdempotencyRepository, key, owner string, lockTimeout time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(lockTimeout / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := repo.Extend(key, owner); err != nil {
+				log.Printf("Idempotency: %v", err)
+			}
+		}
+	}
+}
+
+func replay(c *gin.Context, record *models.IdempotencyRecord) {
+	if record.ContentType != nil && *record.ContentType != "" {
+		c.Header("Content-Type", *record.ContentType)
+	}
+	c.Header(HeaderIdempotentReplayed, "true")
+	c.Status(*record.StatusCode)
+	_, _ = c.Writer.Write(record.ResponseBody)
+	c.Abort()
+}
+
+// newLockOwner возвращает случайный идентификатор запроса, занимающего ключ
+func newLockOwner() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// scopedKey добавляет к ключу арендатора запроса, чтобы одинаковые ключи
+// разных арендаторов не пересекались
+func scopedKey(c *gin.Context, key string) string {
+	if id, ok := tenant.FromContext(c.Request.Context()); ok {
+		return strconv.Itoa(id) + ":" + key
+	}
+	return key
+}
+
+// requestHash считает хэш того, от чего зависит ответ: метода, пути,
+// формата тела и формата ответа. Accept сводится к формату, который
+// выберет обработчик, чтобы, например, пустой Accept и */* не различались.
+func requestHash(r *http.Request, body []byte) string {
+	accept, ok := format.Negotiate(r.Header.Get("Accept"), format.List)
+	if !ok {
+		accept = r.Header.Get("Accept")
+	}
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	h.Write([]byte(format.Normalize(r.Header.Get("Content-Type")) + "\n"))
+	h.Write([]byte(accept + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder копирует тело ответа, передавая его клиенту
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// CleanupIdempotencyKeys периодически удаляет истекшие ключи до отмены ctx
+func CleanupIdempotencyKeys(ctx context.Context, repo repository.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := repo.DeleteExpired(); err != nil {
+				log.Printf("Idempotency: %v", err)
+			}
+		}
+	}
+}
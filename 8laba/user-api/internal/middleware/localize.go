@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"user-api/internal/i18n"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Localize middleware выбирает язык сообщений об ошибках по заголовку
+// Accept-Language и сохраняет его в контексте запроса
+func Localize(translator *i18n.Translator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		l := translator.Localizer(c.GetHeader("Accept-Language"))
+		c.Request = c.Request.WithContext(i18n.WithLocalizer(c.Request.Context(), l))
+		c.Header("Content-Language", l.Language())
+		c.Writer.Header().Add("Vary", "Accept-Language")
+		c.Next()
+	}
+}
+
+// localizer возвращает Localizer запроса (nil, если Localize не подключен)
+func localizer(c *gin.Context) *i18n.Localizer {
+	return i18n.FromContext(c.Request.Context())
+}
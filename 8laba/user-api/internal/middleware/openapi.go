@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"user-api/internal/format"
+	"user-api/internal/models"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/gin-gonic/gin"
+)
+
+// OpenAPIValidator middleware для проверки параметров и тела запроса по
+// спецификации OpenAPI. Запросы к путям, которых нет в спецификации,
+// пропускаются без проверки.
+func OpenAPIValidator(doc *openapi3.T) (gin.HandlerFunc, error) {
+	router, err := legacy.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build OpenAPI router: %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         true,
+	}
+	// Тело multipart запросов (загрузка файлов) проверяет обработчик: иначе
+	// валидатор прочитал бы весь файл в память до проверки его размера.
+	// Тела в XML и MessagePack валидатор разобрать не может, их тоже
+	// проверяет обработчик (теги binding).
+	bodylessOptions := *options
+	bodylessOptions.ExcludeRequestBody = true
+
+	return func(c *gin.Context) {
+		route, pathParams, err := router.FindRoute(c.Request)
+		if err != nil {
+			// Маршрута нет в спецификации: ответ сформирует сам gin
+			c.Next()
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    c.Request,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    options,
+		}
+		switch contentType := c.ContentType(); {
+		case strings.HasPrefix(contentType, "multipart/"),
+			format.Normalize(contentType) == format.XML,
+			format.Normalize(contentType) == format.MsgPack:
+			input.Options = &bodylessOptions
+		}
+		if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, models.ErrorResponse{
+				Error:   "Validation error",
+				Message: localizer(c).Error(err),
+			})
+			return
+		}
+
+		c.Next()
+	}, nil
+}
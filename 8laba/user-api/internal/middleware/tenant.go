@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Tenant определяет арендатора запроса (см. tenant.Resolver) и передает
+// его дальше в контексте запроса. header - заголовок с ID арендатора для
+// доверенных вызывающих.
+func Tenant(resolver *tenant.Resolver, header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, err := resolver.Resolve(c.Request.Context(), Credentials(c.Request, header))
+		if err != nil {
+			respondTenantError(c, err)
+			return
+		}
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
+
+// TrustedOnly пропускает только доверенных вызывающих. Защищает
+// администрирование, общее для всех арендаторов.
+func TrustedOnly(resolver *tenant.Resolver) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !resolver.Trusted(Credentials(c.Request, "")) {
+			c.AbortWithStatusJSON(http.StatusForbidden, models.ErrorResponse{
+				Error:   "Forbidden",
+				Message: localizer(c).Text("messages.trusted_only"),
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// Credentials собирает данные запроса для определения арендатора. Адрес
+// клиента берется из соединения, а не из X-Forwarded-For, который может
+// подделать любой клиент.
+func Credentials(r *http.Request, header string) tenant.Credentials {
+	cred := tenant.Credentials{
+		Bearer:     tenant.BearerToken(r.Header.Get("Authorization")),
+		RemoteAddr: r.RemoteAddr,
+	}
+	if header != "" {
+		cred.Header = r.Header.Get(header)
+	}
+	cred.VerifiedCert = r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+	return cred
+}
+
+func respondTenantError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	message := "Failed to resolve tenant"
+	switch {
+	case errors.Is(err, models.ErrTenantRequired), errors.Is(err, models.ErrInvalidAuthToken):
+		status = http.StatusUnauthorized
+		message = "Unauthorized"
+		c.Header("WWW-Authenticate", "Bearer")
+	case errors.Is(err, models.ErrUntrustedCaller), errors.Is(err, models.ErrTenantNotFound),
+		errors.Is(err, models.ErrTenantSuspended):
+		status = http.StatusForbidden
+		message = "Forbidden"
+	}
+
+	c.AbortWithStatusJSON(status, models.ErrorResponse{
+		Error:   message,
+		Message: localizer(c).Error(err),
+	})
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// AvatarURLs - адреса аватара пользователя по размерам (small, medium, large)
+type AvatarURLs map[string]string
+
+// Scan реализует sql.Scanner для колонки JSONB
+func (a *AvatarURLs) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, a)
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	}
+	return fmt.Errorf("unsupported avatar value %T", src)
+}
+
+// Value реализует driver.Valuer
+func (a AvatarURLs) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	return json.Marshal(a)
+}
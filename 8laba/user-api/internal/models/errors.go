@@ -0,0 +1,46 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Доменные ошибки, по которым транспортные слои выбирают код ответа
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrEmailTaken   = errors.New("email already exists")
+	ErrInvalidEmail = errors.New("invalid email address")
+	ErrInvalidName  = errors.New("name must contain at least 2 characters besides spaces")
+
+	ErrInvalidToken         = errors.New("verification token is invalid or already used")
+	ErrTokenExpired         = errors.New("verification token has expired")
+	ErrEmailAlreadyVerified = errors.New("email already verified")
+	ErrVerificationDisabled = errors.New("email verification is disabled")
+	ErrInvalidResetToken    = errors.New("reset token is invalid, expired or already used")
+
+	ErrGroupNotFound      = errors.New("group not found")
+	ErrGroupNameTaken     = errors.New("group name already exists")
+	ErrMemberNotFound     = errors.New("user is not a member of the group")
+	ErrAlreadyGroupMember = errors.New("user is already a member of the group")
+
+	ErrTenantNotFound    = errors.New("tenant not found")
+	ErrTenantSlugTaken   = errors.New("tenant slug already exists")
+	ErrInvalidTenantSlug = errors.New("tenant slug must consist of lowercase letters, digits and hyphens")
+	ErrTenantSuspended   = errors.New("tenant is suspended")
+	ErrTenantRequired    = errors.New("tenant is not specified")
+	ErrInvalidAuthToken  = errors.New("access token is invalid or expired")
+	ErrUntrustedCaller   = errors.New("tenant header is accepted only from trusted callers")
+
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrDeliveryExists   = errors.New("event already queued for webhook")
+)
+
+// RateLimitError - превышен лимит запросов; повторить можно через RetryAfter
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return "too many requests"
+}
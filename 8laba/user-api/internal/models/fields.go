@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Ошибки параметров fields и expand
+var (
+	ErrUnknownField     = errors.New("unknown field")
+	ErrUnknownExpansion = errors.New("unknown expansion")
+)
+
+// UserFields - поля пользователя, которые можно выбрать параметром fields.
+// Имя поля совпадает с колонкой users.
+var UserFields = []string{
+	"id", "tenant_id", "name", "email", "age", "email_verified_at",
+	"avatar", "metadata", "created_at", "updated_at",
+}
+
+// Связанные ресурсы пользователя для параметра expand
+const (
+	// ExpandGroups - группы пользователя с его ролью
+	ExpandGroups = "groups"
+)
+
+// UserExpansions - допустимые значения параметра expand
+var UserExpansions = []string{ExpandGroups}
+
+// UserGroup - группа, в которой состоит пользователь (expand=groups)
+type UserGroup struct {
+	ID   int    `json:"id" db:"id" example:"1"`
+	Name string `json:"name" db:"name" example:"Backend"`
+	Role string `json:"role" db:"role" example:"member"`
+}
+
+// PartialUserListResponse - список пользователей, в котором есть только
+// поля fields и ресурсы expand
+type PartialUserListResponse struct {
+	Users      []map[string]json.RawMessage `json:"users" swaggertype:"array,object"`
+	Total      int                          `json:"total"`
+	Page       int                          `json:"page"`
+	PageSize   int                          `json:"page_size"`
+	TotalPages int                          `json:"total_pages"`
+}
+
+// ParseUserFields разбирает список полей через запятую. Пустое значение
+// означает все поля (nil).
+func ParseUserFields(value string) ([]string, error) {
+	return parseList(value, UserFields, ErrUnknownField)
+}
+
+// ParseUserExpand разбирает список связанных ресурсов через запятую
+func ParseUserExpand(value string) ([]string, error) {
+	return parseList(value, UserExpansions, ErrUnknownExpansion)
+}
+
+// parseList разбирает список через запятую, проверяя каждое значение по
+// allowed. Повторы отбрасываются, порядок сохраняется.
+func parseList(value string, allowed []string, unknown error) ([]string, error) {
+	var list []string
+	seen := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		if !slices.Contains(allowed, item) {
+			return nil, fmt.Errorf("%w %q, allowed: %s", unknown, item, strings.Join(allowed, ", "))
+		}
+		seen[item] = true
+		list = append(list, item)
+	}
+	return list, nil
+}
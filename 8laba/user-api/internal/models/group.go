@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// Роли участника группы
+const (
+	GroupRoleOwner  = "owner"
+	GroupRoleAdmin  = "admin"
+	GroupRoleMember = "member"
+)
+
+// Group представляет группу (команду) пользователей
+type Group struct {
+	ID          int       `json:"id" db:"id" example:"1"`
+	Name        string    `json:"name" db:"name" example:"Backend"`
+	Description string    `json:"description" db:"description" example:"Команда серверной разработки"`
+	MemberCount int       `json:"member_count" db:"member_count" example:"5"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateGroupRequest представляет запрос на создание группы
+type CreateGroupRequest struct {
+	Name        string `json:"name" binding:"required,min=2,max=100" minLength:"2" maxLength:"100" example:"Backend"`
+	Description string `json:"description" binding:"max=500" maxLength:"500" example:"Команда серверной разработки"`
+}
+
+// UpdateGroupRequest представляет запрос на обновление группы
+type UpdateGroupRequest struct {
+	Name        string  `json:"name" binding:"omitempty,min=2,max=100" minLength:"2" maxLength:"100" example:"Platform"`
+	Description *string `json:"description" binding:"omitempty,max=500" maxLength:"500"`
+}
+
+// GroupListResponse представляет ответ со списком групп
+type GroupListResponse struct {
+	Groups     []Group `json:"groups"`
+	Total      int     `json:"total"`
+	Page       int     `json:"page"`
+	PageSize   int     `json:"page_size"`
+	TotalPages int     `json:"total_pages"`
+}
+
+// GroupMember представляет участие пользователя в группе
+type GroupMember struct {
+	GroupID   int       `json:"group_id" db:"group_id" example:"1"`
+	UserID    int       `json:"user_id" db:"user_id" example:"1"`
+	Name      string    `json:"name" db:"name" example:"John Doe"`
+	Email     string    `json:"email" db:"email" example:"john@example.com"`
+	Role      string    `json:"role" db:"role" example:"member" enums:"owner,admin,member"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AddGroupMemberRequest представляет запрос на добавление участника.
+// По умолчанию роль - member.
+type AddGroupMemberRequest struct {
+	UserID int    `json:"user_id" binding:"required,min=1" minimum:"1" example:"1"`
+	Role   string `json:"role" binding:"omitempty,oneof=owner admin member" enums:"owner,admin,member" example:"member"`
+}
+
+// UpdateGroupMemberRequest представляет запрос на смену роли участника
+type UpdateGroupMemberRequest struct {
+	Role string `json:"role" binding:"required,oneof=owner admin member" enums:"owner,admin,member" example:"admin"`
+}
+
+// GroupMemberListResponse представляет ответ со списком участников группы
+type GroupMemberListResponse struct {
+	Members    []GroupMember `json:"members"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page"`
+	PageSize   int           `json:"page_size"`
+	TotalPages int           `json:"total_pages"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrIdempotencyLockLost - ключ занял другой запрос, пока выполнялся
+// запрос, занявший его раньше
+var ErrIdempotencyLockLost = errors.New("idempotency key lock is held by another request")
+
+// IdempotencyRecord - сохраненный результат запроса с заголовком
+// Idempotency-Key. Пока запрос выполняется, StatusCode равен nil.
+type IdempotencyRecord struct {
+	Key          string    `db:"key"`
+	RequestHash  string    `db:"request_hash"`
+	StatusCode   *int      `db:"status_code"`
+	ContentType  *string   `db:"content_type"`
+	ResponseBody []byte    `db:"response_body"`
+	LockedAt     time.Time `db:"locked_at"`
+	ExpiresAt    time.Time `db:"expires_at"`
+}
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Ошибки произвольных полей пользователя
+var (
+	ErrInvalidMetadata        = errors.New("invalid metadata")
+	ErrInvalidMetadataSchema  = errors.New("invalid metadata schema")
+	ErrMetadataSchemaNotFound = errors.New("metadata schema not found")
+)
+
+// Metadata - произвольные поля пользователя (отдел, табельный номер и т.п.).
+// Значения хранятся как есть, чтобы не терять точность чисел.
+type Metadata map[string]json.RawMessage
+
+// Scan реализует sql.Scanner для колонки JSONB
+func (m *Metadata) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, m)
+	case string:
+		return json.Unmarshal([]byte(v), m)
+	}
+	return fmt.Errorf("unsupported metadata value %T", src)
+}
+
+// Value реализует driver.Valuer
+func (m Metadata) Value() (driver.Value, error) {
+	if m == nil {
+		return []byte("{}"), nil
+	}
+	return json.Marshal(m)
+}
+
+// UnmarshalXML читает metadata из XML запроса: каждый дочерний элемент -
+// ключ (или <entry key="...">), его текст - значение. Текст, который
+// является JSON (число, true, "строка в кавычках", объект), берется как
+// JSON, остальной - как строка; атрибут nil="true" означает null.
+func (m *Metadata) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var element struct {
+		Values []struct {
+			XMLName  xml.Name
+			Key      string     `xml:"key,attr"`
+			Nil      bool       `xml:"nil,attr"`
+			Text     string     `xml:",chardata"`
+			Children []xml.Name `xml:",any"`
+		} `xml:",any"`
+	}
+	if err := d.DecodeElement(&element, &start); err != nil {
+		return err
+	}
+
+	metadata := make(Metadata, len(element.Values))
+	for _, value := range element.Values {
+		key := value.XMLName.Local
+		if key == "entry" && value.Key != "" {
+			key = value.Key
+		}
+		if len(value.Children) > 0 {
+			return fmt.Errorf("%w: nested elements in key %q, use JSON text instead", ErrInvalidMetadata, key)
+		}
+
+		text := bytes.TrimSpace([]byte(value.Text))
+		switch {
+		case value.Nil:
+			metadata[key] = json.RawMessage("null")
+		case len(text) > 0 && json.Valid(text):
+			metadata[key] = json.RawMessage(text)
+		default:
+			raw, _ := json.Marshal(value.Text)
+			metadata[key] = raw
+		}
+	}
+	*m = metadata
+	return nil
+}
+
+// IsNull сообщает, что значение ключа - JSON null (в обновлении - удалить ключ)
+func IsNull(value json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+}
+
+// MetadataSchema - JSON Schema, которой должно соответствовать значение
+// ключа metadata
+type MetadataSchema struct {
+	Key         string          `json:"key" db:"key" example:"department"`
+	Schema      json.RawMessage `json:"schema" db:"schema" swaggertype:"object"`
+	Description string          `json:"description,omitempty" db:"description" example:"Отдел сотрудника"`
+	CreatedAt   time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// PutMetadataSchemaRequest представляет запрос на регистрацию схемы ключа
+type PutMetadataSchemaRequest struct {
+	Schema      json.RawMessage `json:"schema" binding:"required" swaggertype:"object"`
+	Description string          `json:"description" binding:"max=500" maxLength:"500" example:"Отдел сотрудника"`
+}
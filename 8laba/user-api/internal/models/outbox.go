@@ -0,0 +1,20 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// OutboxMessage - событие, записанное в outbox в одной транзакции с
+// изменением пользователя и ожидающее публикации
+type OutboxMessage struct {
+	ID          int64           `db:"id"`
+	EventID     string          `db:"event_id"`
+	EventType   string          `db:"event_type"`
+	UserID      int             `db:"user_id"`
+	Payload     json.RawMessage `db:"payload"`
+	CreatedAt   time.Time       `db:"created_at"`
+	PublishedAt *time.Time      `db:"published_at"`
+	// PublishedSeq - номер публикации; растет в порядке публикации
+	PublishedSeq *int64 `db:"published_seq"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// PasswordResetToken - выданный токен сброса пароля. Хранится только хэш
+// токена; сам токен есть лишь в письме пользователю.
+type PasswordResetToken struct {
+	TokenHash string     `db:"token_hash"`
+	UserID    int        `db:"user_id"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// ForgotPasswordRequest представляет запрос письма для сброса пароля
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" format:"email" example:"john@example.com"`
+}
+
+// ResetPasswordRequest представляет запрос на установку нового пароля.
+// bcrypt учитывает только первые 72 байта пароля.
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required" example:"q3Xc9..."`
+	Password string `json:"password" binding:"required,min=8,max=72" minLength:"8" maxLength:"72" example:"correct horse battery"`
+}
+
+// MessageResponse представляет ответ с текстовым сообщением
+type MessageResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,211 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ошибки параметров статистики
+var (
+	ErrInvalidAgeBuckets = errors.New("age buckets must be increasing ages from 1 to 150")
+	ErrInvalidInterval   = errors.New("interval must be day, week or month")
+	ErrInvalidStatsRange = errors.New("from and to must be dates or RFC 3339 times, from before to")
+	ErrTooManyPeriods    = errors.New("signups range is too long for the interval")
+)
+
+// Периоды графика регистраций
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// StatsIntervals - допустимые значения параметра interval
+var StatsIntervals = []string{IntervalDay, IntervalWeek, IntervalMonth}
+
+// DefaultAgeBuckets - границы групп возраста по умолчанию
+var DefaultAgeBuckets = []int{18, 25, 35, 45, 55, 65}
+
+// MaxAgeBuckets - наибольшее число границ групп возраста
+const MaxAgeBuckets = 20
+
+// MaxSignupPeriods - наибольшее число периодов графика регистраций
+const MaxSignupPeriods = 1000
+
+// DefaultSignupsWindow - длина окна графика регистраций, если from не задан
+const DefaultSignupsWindow = 365 * 24 * time.Hour
+
+// UserStatsOptions - параметры расчета статистики
+type UserStatsOptions struct {
+	// AgeBuckets - возрастающие границы групп возраста: группы [b1, b2),
+	// [b2, b3) и т.д., а также младше b1 и от bn
+	AgeBuckets []int
+	// Interval - период графика регистраций: day, week или month
+	Interval string
+	// From и To ограничивают регистрации графика: created_at в [From, To).
+	// nil - To равен текущему моменту, From - To минус
+	// DefaultSignupsWindow. Не заданные границы вычисляются при расчете,
+	// поэтому не дробят кэш.
+	From *time.Time
+	To   *time.Time
+}
+
+// SignupsRange возвращает окно графика регистраций с границами по
+// умолчанию на момент now
+func (o UserStatsOptions) SignupsRange(now time.Time) (from, to time.Time) {
+	to = now
+	if o.To != nil {
+		to = *o.To
+	}
+	from = to.Add(-DefaultSignupsWindow)
+	if o.From != nil {
+		from = *o.From
+	}
+	return from, to
+}
+
+// UserStats - статистика пользователей
+type UserStats struct {
+	Total int `json:"total" example:"1250"`
+	// MeanAge и MedianAge равны null, если пользователей нет
+	MeanAge      *float64    `json:"mean_age" example:"37.4"`
+	MedianAge    *float64    `json:"median_age" example:"35"`
+	AgeHistogram []AgeBucket `json:"age_histogram"`
+	// Interval - период графика signups
+	Interval string `json:"interval" example:"day"`
+	// Signups - регистрации по периодам от первой до последней в окне
+	// from-to, включая периоды без регистраций
+	Signups     []SignupCount `json:"signups"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// AgeBucket - число пользователей с возрастом от Min до Max включительно.
+// У крайних групп одна из границ равна null.
+type AgeBucket struct {
+	Label string `json:"label" example:"25-34"`
+	Min   *int   `json:"min" example:"25"`
+	Max   *int   `json:"max" example:"34"`
+	Count int    `json:"count" example:"320"`
+}
+
+// SignupCount - число регистраций за период, начинающийся в Period (UTC)
+type SignupCount struct {
+	Period time.Time `json:"period" example:"2026-01-05T00:00:00Z"`
+	Count  int       `json:"count" example:"12"`
+}
+
+// ParseAgeBuckets разбирает границы групп возраста через запятую. Пустое
+// значение - DefaultAgeBuckets.
+func ParseAgeBuckets(value string) ([]int, error) {
+	if value == "" {
+		return DefaultAgeBuckets, nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) > MaxAgeBuckets {
+		return nil, fmt.Errorf("%w: at most %d buckets", ErrInvalidAgeBuckets, MaxAgeBuckets)
+	}
+	bounds := make([]int, len(parts))
+	for i, part := range parts {
+		bound, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || bound < 1 || bound > 150 || (i > 0 && bound <= bounds[i-1]) {
+			return nil, ErrInvalidAgeBuckets
+		}
+		bounds[i] = bound
+	}
+	return bounds, nil
+}
+
+// ParseStatsInterval проверяет период графика регистраций. Пустое
+// значение - day.
+func ParseStatsInterval(value string) (string, error) {
+	if value == "" {
+		return IntervalDay, nil
+	}
+	if !slices.Contains(StatsIntervals, value) {
+		return "", ErrInvalidInterval
+	}
+	return value, nil
+}
+
+// ParseStatsRange разбирает границы окна графика регистраций: даты
+// (2006-01-02, начало дня в UTC) или время в RFC 3339. Пустое значение -
+// nil. Окно не может содержать больше MaxSignupPeriods периодов interval;
+// границы по умолчанию отсчитываются от now.
+func ParseStatsRange(fromValue, toValue, interval string, now time.Time) (from, to *time.Time, err error) {
+	if from, err = parseStatsTime(fromValue); err != nil {
+		return nil, nil, err
+	}
+	if to, err = parseStatsTime(toValue); err != nil {
+		return nil, nil, err
+	}
+
+	start, end := UserStatsOptions{From: from, To: to}.SignupsRange(now)
+	if !start.Before(end) {
+		return nil, nil, ErrInvalidStatsRange
+	}
+	if signupPeriods(start, end, interval) > MaxSignupPeriods {
+		return nil, nil, fmt.Errorf("%w: at most %d periods", ErrTooManyPeriods, MaxSignupPeriods)
+	}
+	return from, to, nil
+}
+
+func parseStatsTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		if t, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, ErrInvalidStatsRange
+		}
+	}
+	t = t.UTC()
+	return &t, nil
+}
+
+// signupPeriods оценивает сверху число периодов interval между from и to
+func signupPeriods(from, to time.Time, interval string) int64 {
+	days := int64(to.Sub(from)/(24*time.Hour)) + 1
+	switch interval {
+	case IntervalWeek:
+		return days/7 + 1
+	case IntervalMonth:
+		return days/28 + 1
+	default:
+		return days
+	}
+}
+
+// NewAgeHistogram создает группы возраста по границам bounds с нулевыми
+// счетчиками: младше bounds[0], [bounds[i], bounds[i+1]) и от последней
+// границы
+func NewAgeHistogram(bounds []int) []AgeBucket {
+	histogram := make([]AgeBucket, 0, len(bounds)+1)
+	for i := 0; i <= len(bounds); i++ {
+		var bucket AgeBucket
+		if i > 0 {
+			lower := bounds[i-1]
+			bucket.Min = &lower
+		}
+		if i < len(bounds) {
+			upper := bounds[i] - 1
+			bucket.Max = &upper
+		}
+		switch {
+		case bucket.Min == nil:
+			bucket.Label = fmt.Sprintf("<%d", bounds[0])
+		case bucket.Max == nil:
+			bucket.Label = fmt.Sprintf("%d+", *bucket.Min)
+		case *bucket.Min == *bucket.Max:
+			bucket.Label = strconv.Itoa(*bucket.Min)
+		default:
+			bucket.Label = fmt.Sprintf("%d-%d", *bucket.Min, *bucket.Max)
+		}
+		histogram = append(histogram, bucket)
+	}
+	return histogram
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// Состояния арендатора
+const (
+	TenantStatusActive    = "active"
+	TenantStatusSuspended = "suspended"
+)
+
+// Tenant представляет арендатора - организацию-клиента со своими
+// пользователями и группами
+type Tenant struct {
+	ID        int       `json:"id" db:"id" example:"2"`
+	Name      string    `json:"name" db:"name" example:"Acme Corp"`
+	Slug      string    `json:"slug" db:"slug" example:"acme"`
+	Status    string    `json:"status" db:"status" enums:"active,suspended" example:"active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CreateTenantRequest представляет запрос на создание арендатора
+type CreateTenantRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100" minLength:"2" maxLength:"100" example:"Acme Corp"`
+	// Slug - короткое имя из строчных латинских букв, цифр и дефисов
+	Slug string `json:"slug" binding:"required,min=2,max=63" minLength:"2" maxLength:"63" pattern:"^[a-z0-9][a-z0-9-]*$" example:"acme"`
+}
+
+// TenantListResponse представляет ответ со списком арендаторов
+type TenantListResponse struct {
+	Tenants    []Tenant `json:"tenants"`
+	Total      int      `json:"total"`
+	Page       int      `json:"page"`
+	PageSize   int      `json:"page_size"`
+	TotalPages int      `json:"total_pages"`
+}
@@ -6,26 +6,37 @@ import (
 
 // User представляет модель пользователя
 type User struct {
-	ID        int       `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name" binding:"required,min=2,max=100"`
-	Email     string    `json:"email" db:"email" binding:"required,email"`
-	Age       int       `json:"age" db:"age" binding:"required,min=1,max=150"`
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID              int        `json:"id" db:"id" example:"1"`
+	TenantID        int        `json:"tenant_id" db:"tenant_id" example:"1"`
+	Name            string     `json:"name" db:"name" binding:"required,min=2,max=100" example:"John Doe"`
+	Email           string     `json:"email" db:"email" binding:"required,email" format:"email" example:"john@example.com"`
+	Age             int        `json:"age" db:"age" binding:"required,min=1,max=150" example:"30"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	Avatar          AvatarURLs `json:"avatar,omitempty" db:"avatar"`
+	AvatarKey       *string    `json:"-" db:"avatar_key"`
+	Metadata        Metadata   `json:"metadata" db:"metadata" swaggertype:"object"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
+	// Groups заполняется только по запросу expand=groups
+	Groups []UserGroup `json:"groups,omitempty" db:"-"`
 }
 
 // CreateUserRequest представляет запрос на создание пользователя
 type CreateUserRequest struct {
-	Name  string `json:"name" binding:"required,min=2,max=100"`
-	Email string `json:"email" binding:"required,email"`
-	Age   int    `json:"age" binding:"required,min=1,max=150"`
+	Name  string `json:"name" xml:"name" binding:"required,min=2,max=100" minLength:"2" maxLength:"100" example:"John Doe"`
+	Email string `json:"email" xml:"email" binding:"required,email,not_disposable,not_blocked" format:"email" example:"john@example.com"`
+	Age   int    `json:"age" xml:"age" binding:"required,min=1,max=150" minimum:"1" maximum:"150" example:"30"`
+	// Metadata - произвольные поля; значения ключей со схемой проверяются по ней
+	Metadata Metadata `json:"metadata,omitempty" xml:"metadata" swaggertype:"object"`
 }
 
 // UpdateUserRequest представляет запрос на обновление пользователя
 type UpdateUserRequest struct {
-	Name  string `json:"name" binding:"omitempty,min=2,max=100"`
-	Email string `json:"email" binding:"omitempty,email"`
-	Age   int    `json:"age" binding:"omitempty,min=1,max=150"`
+	Name  string `json:"name" xml:"name" binding:"omitempty,min=2,max=100" minLength:"2" maxLength:"100" example:"John Updated"`
+	Email string `json:"email" xml:"email" binding:"omitempty,email,not_disposable,not_blocked" format:"email" example:"john.new@example.com"`
+	Age   int    `json:"age" xml:"age" binding:"omitempty,min=1,max=150" minimum:"1" maximum:"150" example:"31"`
+	// Metadata дополняет существующие поля; ключ со значением null удаляется
+	Metadata Metadata `json:"metadata,omitempty" xml:"metadata" swaggertype:"object"`
 }
 
 // UserListResponse представляет ответ со списком пользователей
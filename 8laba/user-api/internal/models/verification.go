@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// VerificationToken - выданный токен подтверждения email. Токен
+// действителен для адреса Email, пока не истек и не использован.
+type VerificationToken struct {
+	ID        string     `db:"id"`
+	UserID    int        `db:"user_id"`
+	Email     string     `db:"email"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	CreatedAt time.Time  `db:"created_at"`
+}
+
+// VerifyEmailRequest представляет запрос подтверждения email токеном из
+// письма
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required" example:"eyJpZCI6..."`
+}
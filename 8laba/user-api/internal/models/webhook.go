@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryDead      = "dead"
+)
+
+// Webhook представляет подписку на события пользователей
+type Webhook struct {
+	ID        int            `json:"id" db:"id" example:"1"`
+	URL       string         `json:"url" db:"url" example:"https://crm.example.com/hooks/users"`
+	Secret    string         `json:"secret,omitempty" db:"secret" example:"3f9a1c..."`
+	Events    pq.StringArray `json:"events" db:"events" swaggertype:"array,string" example:"user.created,user.deleted"`
+	Active    bool           `json:"active" db:"active" example:"true"`
+	CreatedAt time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// CreateWebhookRequest представляет запрос на создание вебхука.
+// Если secret не указан, он генерируется и возвращается один раз в ответе.
+type CreateWebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048" example:"https://crm.example.com/hooks/users"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=user.created user.updated user.deleted" example:"user.created,user.deleted"`
+	Active *bool    `json:"active" example:"true"`
+}
+
+// UpdateWebhookRequest представляет запрос на обновление вебхука
+type UpdateWebhookRequest struct {
+	URL    string   `json:"url" binding:"omitempty,url,max=2048"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	Events []string `json:"events" binding:"omitempty,min=1,dive,oneof=user.created user.updated user.deleted"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookDelivery представляет попытку доставки события на вебхук
+type WebhookDelivery struct {
+	ID             int64           `json:"id" db:"id"`
+	WebhookID      int             `json:"webhook_id" db:"webhook_id"`
+	EventID        string          `json:"event_id" db:"event_id"`
+	EventType      string          `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage `json:"payload" db:"payload" swaggertype:"object"`
+	Status         string          `json:"status" db:"status" example:"pending" enums:"pending,succeeded,dead"`
+	Attempts       int             `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastError      *string         `json:"last_error,omitempty" db:"last_error"`
+	ResponseStatus *int            `json:"response_status,omitempty" db:"response_status"`
+	RedeliveryOf   *int64          `json:"redelivery_of,omitempty" db:"redelivery_of"`
+	CreatedAt      time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at" db:"updated_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// WebhookDeliveryListResponse представляет ответ со списком доставок
+type WebhookDeliveryListResponse struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	Total      int               `json:"total"`
+	Page       int               `json:"page"`
+	PageSize   int               `json:"page_size"`
+	TotalPages int               `json:"total_pages"`
+}
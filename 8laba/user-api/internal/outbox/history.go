@@ -0,0 +1,49 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"user-api/internal/events"
+	"user-api/internal/feed"
+	"user-api/internal/repository"
+)
+
+// History - опубликованные события outbox как история ленты SSE
+// (feed.History). Номер события в ленте - номер его публикации, поэтому
+// он одинаков на всех экземплярах API. События хранятся до удаления по
+// Config.Retention.
+type History struct {
+	repo repository.OutboxRepository
+}
+
+// NewHistory создает историю ленты по outbox
+func NewHistory(repo repository.OutboxRepository) *History {
+	return &History{repo: repo}
+}
+
+func (h *History) Last(ctx context.Context) (uint64, error) {
+	seq, err := h.repo.LastPublishedSeq(ctx)
+	return uint64(seq), err
+}
+
+func (h *History) After(ctx context.Context, seq uint64, limit int) ([]feed.Entry, error) {
+	messages, err := h.repo.PublishedAfter(ctx, int64(seq), limit)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]feed.Entry, 0, len(messages))
+	for _, message := range messages {
+		var event events.Event
+		if err := json.Unmarshal(message.Payload, &event); err != nil {
+			// Relay уже записал в лог, что пропускает такое событие
+			continue
+		}
+		entries = append(entries, feed.Entry{Seq: uint64(*message.PublishedSeq), Event: event})
+	}
+	return entries, nil
+}
+
+func (h *History) Contains(ctx context.Context, seq uint64) (bool, error) {
+	return h.repo.HasPublished(ctx, int64(seq))
+}
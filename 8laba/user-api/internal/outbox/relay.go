@@ -0,0 +1,177 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+// Config содержит настройки relay
+type Config struct {
+	BatchSize       int
+	PollInterval    time.Duration
+	Retention       time.Duration
+	CleanupInterval time.Duration
+}
+
+// Relay читает события из outbox и публикует их в EventPublisher.
+// Сообщение помечается опубликованным только после успешного Publish,
+// поэтому при сбоях событие может быть опубликовано повторно, но не
+// теряется (at-least-once). Для этого Publish должен вернуться только
+// после того, как получатель надежно сохранил или обработал событие, а не
+// поставил его в очередь в памяти. События одного пользователя
+// публикуются в порядке записи: после ошибки остальные события этого
+// пользователя ждут следующей попытки.
+type Relay struct {
+	repo      repository.OutboxRepository
+	publisher events.EventPublisher
+	cfg       Config
+	wake      chan struct{}
+}
+
+// NewRelay создает новый relay
+func NewRelay(repo repository.OutboxRepository, publisher events.EventPublisher, cfg Config) *Relay {
+	return &Relay{
+		repo:      repo,
+		publisher: publisher,
+		cfg:       cfg,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Notify будит relay, не дожидаясь следующего опроса
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run публикует события из outbox и удаляет старые опубликованные
+// записи до отмены ctx
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(r.cfg.CleanupInterval)
+	defer cleanup.Stop()
+
+	// Пользователи, публикация событий которых не удалась. До следующего
+	// опроса их события пропускаются, чтобы не нарушить порядок и не
+	// задерживать события остальных пользователей.
+	blocked := make(map[int]bool)
+
+	for ctx.Err() == nil {
+		skip := make([]int, 0, len(blocked))
+		for userID := range blocked {
+			skip = append(skip, userID)
+		}
+
+		published := 0
+		n, err := r.repo.Process(r.cfg.BatchSize, skip, func(messages []models.OutboxMessage) []int64 {
+			ids := r.publish(ctx, messages, blocked)
+			published = len(ids)
+			return ids
+		})
+		if err != nil {
+			log.Printf("Outbox: %v", err)
+		}
+
+		// Полная порция - вероятно, в outbox есть еще. Если ни одно событие
+		// не опубликовано и новых заблокированных пользователей нет,
+		// повтор сразу же ничего не изменит.
+		if n == r.cfg.BatchSize && (published > 0 || len(blocked) > len(skip)) {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		case <-cleanup.C:
+			r.cleanup()
+		}
+		clear(blocked)
+	}
+}
+
+// publish публикует сообщения и возвращает ID успешно опубликованных.
+// Пользователи, для которых публикация не удалась, добавляются в blocked.
+func (r *Relay) publish(ctx context.Context, messages []models.OutboxMessage, blocked map[int]bool) []int64 {
+	var published []int64
+
+	for _, message := range messages {
+		if blocked[message.UserID] {
+			continue
+		}
+
+		var event events.Event
+		if err := json.Unmarshal(message.Payload, &event); err != nil {
+			// Повтор не поможет: сообщение пропускается, чтобы не
+			// задерживать остальные события пользователя
+			log.Printf("Outbox: dropping malformed event %s: %v", message.EventID, err)
+			published = append(published, message.ID)
+			continue
+		}
+
+		if err := r.publisher.Publish(ctx, event); err != nil {
+			log.Printf("Outbox: failed to publish event %s: %v", message.EventID, err)
+			blocked[message.UserID] = true
+			continue
+		}
+		published = append(published, message.ID)
+	}
+
+	return published
+}
+
+func (r *Relay) cleanup() {
+	deleted, err := r.repo.DeletePublished(time.Now().Add(-r.cfg.Retention))
+	if err != nil {
+		log.Printf("Outbox: %v", err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("Outbox: deleted %d published events", deleted)
+	}
+}
+
+// Listen подписывается на уведомления PostgreSQL о новых записях outbox
+// и будит relay. Опрос по PollInterval остается запасным вариантом на
+// случай потери соединения.
+func (r *Relay) Listen(ctx context.Context, dsn string) {
+	Listen(ctx, dsn, repository.OutboxChannel, r.Notify)
+}
+
+// Listen подписывается на уведомления PostgreSQL в канале channel и
+// вызывает notify на каждое уведомление до отмены ctx
+func Listen(ctx context.Context, dsn, channel string, notify func()) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Outbox: listener: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(channel); err != nil {
+		log.Printf("Outbox: failed to listen for notifications: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-listener.Notify:
+			// nil приходит после переподключения: уведомления могли
+			// потеряться, поэтому тоже будим
+			notify()
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter ограничивает число событий на ключ (email, IP и т.п.) за окно
+// фиксированной длины. Счетчики хранятся в памяти процесса, поэтому при
+// нескольких экземплярах API лимит действует в каждом отдельно.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	entries   map[string]*entry
+	lastPrune time.Time
+}
+
+type entry struct {
+	count   int
+	resetAt time.Time
+}
+
+// New создает Limiter, разрешающий limit событий на ключ за window
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  window,
+		entries: make(map[string]*entry),
+	}
+}
+
+// Allow учитывает событие для key. Если лимит исчерпан, возвращает false
+// и время до начала следующего окна.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.prune(now)
+
+	e, ok := l.entries[key]
+	if !ok || !now.Before(e.resetAt) {
+		e = &entry{resetAt: now.Add(l.window)}
+		l.entries[key] = e
+	}
+	if e.count >= l.limit {
+		return false, e.resetAt.Sub(now)
+	}
+	e.count++
+	return true, 0
+}
+
+// prune раз в окно удаляет счетчики истекших окон, чтобы память не росла
+// с числом уникальных ключей
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.window {
+		return
+	}
+	for key, e := range l.entries {
+		if !now.Before(e.resetAt) {
+			delete(l.entries, key)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"user-api/internal/events"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// StoredEmail - адрес пользователя в том виде, в каком он хранится в БД
+type StoredEmail struct {
+	UserID   int    `db:"id" json:"user_id"`
+	TenantID int    `db:"tenant_id" json:"tenant_id"`
+	Email    string `db:"email" json:"email"`
+}
+
+// EmailConflict - пользователи одного арендатора, адреса которых
+// совпадают после нормализации
+type EmailConflict struct {
+	TenantID int    `json:"tenant_id"`
+	Email    string `json:"email"`
+	UserIDs  []int  `json:"user_ids"`
+}
+
+// EmailNormalization - план приведения сохраненных адресов к виду, в
+// котором их сохраняет сервис
+type EmailNormalization struct {
+	// Changes - адреса, которые изменятся (в Email - новое значение)
+	Changes []StoredEmail `json:"changes"`
+	// Invalid - адреса, которые не удалось нормализовать; они остаются
+	// как есть
+	Invalid []StoredEmail `json:"invalid"`
+	// Conflicts - адреса, которые после нормализации заняты несколькими
+	// пользователями. Пока они есть, план не применяется.
+	Conflicts []EmailConflict `json:"conflicts"`
+}
+
+// PlanEmailNormalization строит план нормализации emails функцией
+// normalize (validation.NormalizeEmail). Совпадения ищутся по
+// нормализованному адресу без учета регистра, как в уникальном индексе
+// users_tenant_email_lower_key; ненормализуемые адреса сравниваются
+// по LOWER(TRIM(email)), к которому их приведет миграция 011.
+func PlanEmailNormalization(emails []StoredEmail, normalize func(string) (string, error)) *EmailNormalization {
+	type key struct {
+		tenantID int
+		email    string
+	}
+	plan := &EmailNormalization{}
+	owners := make(map[key][]int)
+	var order []key
+	for _, stored := range emails {
+		email, err := normalize(stored.Email)
+		if err != nil {
+			plan.Invalid = append(plan.Invalid, stored)
+			email = strings.TrimSpace(stored.Email)
+		} else if email != stored.Email {
+			plan.Changes = append(plan.Changes, StoredEmail{UserID: stored.UserID, TenantID: stored.TenantID, Email: email})
+		}
+
+		k := key{tenantID: stored.TenantID, email: strings.ToLower(email)}
+		if _, ok := owners[k]; !ok {
+			order = append(order, k)
+		}
+		owners[k] = append(owners[k], stored.UserID)
+	}
+
+	for _, k := range order {
+		if ids := owners[k]; len(ids) > 1 {
+			slices.Sort(ids)
+			plan.Conflicts = append(plan.Conflicts, EmailConflict{TenantID: k.tenantID, Email: k.email, UserIDs: ids})
+		}
+	}
+	return plan
+}
+
+// NormalizeEmails нормализует адреса пользователей всех арендаторов
+// функцией normalize. Выполняется перед миграцией 011: SQL не умеет
+// переводить домены в punycode. Если после нормализации адреса
+// совпадают, ничего не меняет и возвращает план с Conflicts и
+// models.ErrEmailTaken. При dryRun только строит план. Об измененных
+// пользователях в outbox пишутся события user.updated. db должен быть
+// подключен под ролью, обходящей row-level security: адреса читаются у всех
+// арендаторов.
+func NormalizeEmails(ctx context.Context, db *sqlx.DB, normalize func(string) (string, error), dryRun bool) (*EmailNormalization, error) {
+	var plan *EmailNormalization
+	err := withTenantTx(tenant.WithSystem(ctx), db, func(tx *sqlx.Tx) error {
+		// Блокировка не дает API записать адрес, который совпадет с
+		// нормализуемым, пока план применяется
+		if _, err := tx.ExecContext(ctx, "LOCK TABLE users IN SHARE ROW EXCLUSIVE MODE"); err != nil {
+			return fmt.Errorf("failed to lock users: %w", err)
+		}
+
+		var emails []StoredEmail
+		if err := tx.SelectContext(ctx, &emails, "SELECT id, tenant_id, email FROM users ORDER BY id"); err != nil {
+			return fmt.Errorf("failed to read emails: %w", err)
+		}
+		plan = PlanEmailNormalization(emails, normalize)
+		if len(plan.Conflicts) > 0 {
+			return models.ErrEmailTaken
+		}
+		if dryRun {
+			return nil
+		}
+
+		for _, change := range plan.Changes {
+			var user models.User
+			err := tx.QueryRowxContext(ctx, `
+                UPDATE users
+                SET email = $2, updated_at = CURRENT_TIMESTAMP
+                WHERE id = $1
+                RETURNING `+userColumns, change.UserID, change.Email).StructScan(&user)
+			if err != nil {
+				return fmt.Errorf("failed to normalize email of user %d: %w", change.UserID, err)
+			}
+			if err := insertOutbox(tx, events.New(events.UserUpdated, user.ID, &user)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return plan, err
+}
@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"user-api/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GroupRepository интерфейс для работы с группами и их участниками
+type GroupRepository interface {
+	Create(ctx context.Context, req *models.CreateGroupRequest) (*models.Group, error)
+	GetByID(ctx context.Context, id int) (*models.Group, error)
+	GetAll(ctx context.Context, page, pageSize int) ([]models.Group, int, error)
+	Update(ctx context.Context, id int, req *models.UpdateGroupRequest) (*models.Group, error)
+	Delete(ctx context.Context, id int) error
+
+	AddMember(ctx context.Context, groupID, userID int, role string) (*models.GroupMember, error)
+	GetMembers(ctx context.Context, groupID, page, pageSize int) ([]models.GroupMember, int, error)
+	UpdateMember(ctx context.Context, groupID, userID int, role string) (*models.GroupMember, error)
+	RemoveMember(ctx context.Context, groupID, userID int) error
+}
+
+type groupRepository struct {
+	db *sqlx.DB
+}
+
+// NewGroupRepository создает новый репозиторий групп
+func NewGroupRepository(db *sqlx.DB) GroupRepository {
+	return &groupRepository{db: db}
+}
+
+// groupColumns - колонки groups и число участников; g - псевдоним groups
+const groupColumns = `g.id, g.name, g.description, g.created_at, g.updated_at,
+        (SELECT COUNT(*) FROM group_members m WHERE m.group_id = g.id) AS member_count`
+
+// memberColumns - колонки участника; m - group_members, u - users
+const memberColumns = "m.group_id, m.user_id, u.name, u.email, m.role, m.created_at, m.updated_at"
+
+func (r *groupRepository) Create(ctx context.Context, req *models.CreateGroupRequest) (*models.Group, error) {
+	query := `
+        INSERT INTO groups (name, description)
+        VALUES ($1, $2)
+        RETURNING id, name, description, created_at, updated_at`
+
+	var group models.Group
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, query, req.Name, req.Description).StructScan(&group)
+		if isUniqueViolation(err) {
+			return models.ErrGroupNameTaken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create group: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (r *groupRepository) GetByID(ctx context.Context, id int) (*models.Group, error) {
+	var group models.Group
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &group, "SELECT "+groupColumns+" FROM groups g WHERE g.id = $1", id)
+		if err == sql.ErrNoRows {
+			return models.ErrGroupNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get group: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (r *groupRepository) GetAll(ctx context.Context, page, pageSize int) ([]models.Group, int, error) {
+	query := "SELECT " + groupColumns + `
+        FROM groups g
+        ORDER BY g.name, g.id
+        LIMIT $1 OFFSET $2`
+
+	var total int
+	groups := []models.Group{}
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &total, "SELECT COUNT(*) FROM groups"); err != nil {
+			return fmt.Errorf("failed to count groups: %w", err)
+		}
+		if err := tx.SelectContext(ctx, &groups, query, pageSize, (page-1)*pageSize); err != nil {
+			return fmt.Errorf("failed to get groups: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return groups, total, nil
+}
+
+func (r *groupRepository) Update(ctx context.Context, id int, req *models.UpdateGroupRequest) (*models.Group, error) {
+	var updates []string
+	var args []interface{}
+	argCounter := 1
+
+	if req.Name != "" {
+		updates = append(updates, fmt.Sprintf("name = $%d", argCounter))
+		args = append(args, req.Name)
+		argCounter++
+	}
+
+	if req.Description != nil {
+		updates = append(updates, fmt.Sprintf("description = $%d", argCounter))
+		args = append(args, *req.Description)
+		argCounter++
+	}
+
+	if len(updates) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+        WITH g AS (
+            UPDATE groups
+            SET %s
+            WHERE id = $%d
+            RETURNING *
+        )
+        SELECT %s FROM g`, strings.Join(updates, ", "), argCounter, groupColumns)
+
+	var group models.Group
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, query, args...).StructScan(&group)
+		if err == sql.ErrNoRows {
+			return models.ErrGroupNotFound
+		}
+		if isUniqueViolation(err) {
+			return models.ErrGroupNameTaken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update group: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (r *groupRepository) Delete(ctx context.Context, id int) error {
+	return withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		return execOne(ctx, tx, models.ErrGroupNotFound, "failed to delete group", "DELETE FROM groups WHERE id = $1", id)
+	})
+}
+
+// AddMember добавляет участника. Составные внешние ключи group_members
+// не позволяют добавить пользователя другого арендатора: для них
+// возвращается models.ErrUserNotFound.
+func (r *groupRepository) AddMember(ctx context.Context, groupID, userID int, role string) (*models.GroupMember, error) {
+	query := `
+        WITH m AS (
+            INSERT INTO group_members (group_id, user_id, role)
+            VALUES ($1, $2, $3)
+            RETURNING *
+        )
+        SELECT ` + memberColumns + ` FROM m JOIN users u ON u.id = m.user_id`
+
+	var member models.GroupMember
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, query, groupID, userID, role).StructScan(&member)
+		if isUniqueViolation(err) {
+			return models.ErrAlreadyGroupMember
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			if pqErr.Constraint == "group_members_user_fkey" {
+				return models.ErrUserNotFound
+			}
+			return models.ErrGroupNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add group member: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// GetMembers возвращает участников группы, сначала владельцев и
+// администраторов
+func (r *groupRepository) GetMembers(ctx context.Context, groupID, page, pageSize int) ([]models.GroupMember, int, error) {
+	query := "SELECT " + memberColumns + `
+        FROM group_members m
+        JOIN users u ON u.id = m.user_id
+        WHERE m.group_id = $1
+        ORDER BY CASE m.role WHEN 'owner' THEN 0 WHEN 'admin' THEN 1 ELSE 2 END, u.name, u.id
+        LIMIT $2 OFFSET $3`
+
+	var total int
+	members := []models.GroupMember{}
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &total, "SELECT COUNT(*) FROM group_members WHERE group_id = $1", groupID)
+		if err != nil {
+			return fmt.Errorf("failed to count group members: %w", err)
+		}
+		if err := tx.SelectContext(ctx, &members, query, groupID, pageSize, (page-1)*pageSize); err != nil {
+			return fmt.Errorf("failed to get group members: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return members, total, nil
+}
+
+func (r *groupRepository) UpdateMember(ctx context.Context, groupID, userID int, role string) (*models.GroupMember, error) {
+	query := `
+        WITH m AS (
+            UPDATE group_members
+            SET role = $3, updated_at = CURRENT_TIMESTAMP
+            WHERE group_id = $1 AND user_id = $2
+            RETURNING *
+        )
+        SELECT ` + memberColumns + ` FROM m JOIN users u ON u.id = m.user_id`
+
+	var member models.GroupMember
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, query, groupID, userID, role).StructScan(&member)
+		if err == sql.ErrNoRows {
+			return models.ErrMemberNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update group member: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+func (r *groupRepository) RemoveMember(ctx context.Context, groupID, userID int) error {
+	return withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		return execOne(ctx, tx, models.ErrMemberNotFound, "failed to remove group member",
+			"DELETE FROM group_members WHERE group_id = $1 AND user_id = $2", groupID, userID)
+	})
+}
+
+// execOne выполняет запрос, который должен затронуть строку; если строк
+// нет, возвращает notFound
+func execOne(ctx context.Context, tx *sqlx.Tx, notFound error, message, query string, args ...interface{}) error {
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", message, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
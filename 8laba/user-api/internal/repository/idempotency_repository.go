@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"user-api/internal/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyRepository интерфейс для хранения результатов запросов
+// с Idempotency-Key
+type IdempotencyRepository interface {
+	// Acquire резервирует ключ за запросом owner на ttl. Ключ можно
+	// занять, если его нет, он истек или запрос, занявший его, не завершился
+	// за lockTimeout. Если ключ занят, возвращается существующая запись
+	// (nil, если ее успели удалить).
+	Acquire(key, owner, requestHash string, ttl, lockTimeout time.Duration) (bool, *models.IdempotencyRecord, error)
+	// Complete сохраняет ответ на запрос, если ключ все еще занят owner.
+	// Иначе возвращает ErrIdempotencyLockLost.
+	Complete(key, owner string, statusCode int, contentType string, body []byte) error
+	// Extend продлевает блокировку ключа запросом owner, который еще
+	// выполняется. Если ключ занял другой запрос, возвращает
+	// ErrIdempotencyLockLost.
+	Extend(key, owner string) error
+	// Release освобождает ключ, не сохраняя ответ. Ключ, который занял
+	// другой запрос, не меняется.
+	Release(key, owner string) error
+	// DeleteExpired удаляет истекшие ключи
+	DeleteExpired() (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *sqlx.DB
+}
+
+// NewIdempotencyRepository создает новый репозиторий ключей идемпотентности
+func NewIdempotencyRepository(db *sqlx.DB) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+func (r *idempotencyRepository) Acquire(key, owner, requestHash string, ttl, lockTimeout time.Duration) (bool, *models.IdempotencyRecord, error) {
+	query := `
+        INSERT INTO idempotency_keys (key, request_hash, locked_by, locked_at, expires_at)
+        VALUES ($1, $2, $3, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP + $4 * INTERVAL '1 millisecond')
+        ON CONFLICT (key) DO UPDATE
+        SET request_hash = EXCLUDED.request_hash, status_code = NULL, content_type = NULL,
+            response_body = NULL, locked_by = EXCLUDED.locked_by, locked_at = EXCLUDED.locked_at,
+            expires_at = EXCLUDED.expires_at
+        WHERE idempotency_keys.expires_at < CURRENT_TIMESTAMP
+           OR (idempotency_keys.status_code IS NULL
+               AND idempotency_keys.locked_at < CURRENT_TIMESTAMP - $5 * INTERVAL '1 millisecond')
+        RETURNING key`
+
+	var acquired string
+	err := r.db.Get(&acquired, query, key, requestHash, owner, ttl.Milliseconds(), lockTimeout.Milliseconds())
+	if err == nil {
+		return true, nil, nil
+	}
+	if err != sql.ErrNoRows {
+		return false, nil, fmt.Errorf("failed to acquire idempotency key: %w", err)
+	}
+
+	var record models.IdempotencyRecord
+	err = r.db.Get(&record, `
+        SELECT key, request_hash, status_code, content_type, response_body, locked_at, expires_at
+        FROM idempotency_keys
+        WHERE key = $1`, key)
+	if err == sql.ErrNoRows {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return false, &record, nil
+}
+
+func (r *idempotencyRepository) Complete(key, owner string, statusCode int, contentType string, body []byte) error {
+	query := `
+        UPDATE idempotency_keys
+        SET status_code = $3, content_type = $4, response_body = $5
+        WHERE key = $1 AND locked_by = $2 AND status_code IS NULL`
+
+	result, err := r.db.Exec(query, key, owner, statusCode, contentType, body)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return lockHeld(result)
+}
+
+func (r *idempotencyRepository) Extend(key, owner string) error {
+	query := `
+        UPDATE idempotency_keys
+        SET locked_at = CURRENT_TIMESTAMP
+        WHERE key = $1 AND locked_by = $2 AND status_code IS NULL`
+
+	result, err := r.db.Exec(query, key, owner)
+	if err != nil {
+		return fmt.Errorf("failed to extend idempotency key lock: %w", err)
+	}
+	return lockHeld(result)
+}
+
+// lockHeld возвращает ErrIdempotencyLockLost, если запрос не изменил ни
+// одной строки: ключ занял другой запрос или его удалили
+func lockHeld(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return models.ErrIdempotencyLockLost
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) Release(key, owner string) error {
+	query := "DELETE FROM idempotency_keys WHERE key = $1 AND locked_by = $2 AND status_code IS NULL"
+	if _, err := r.db.Exec(query, key, owner); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+func (r *idempotencyRepository) DeleteExpired() (int64, error) {
+	result, err := r.db.Exec("DELETE FROM idempotency_keys WHERE expires_at < CURRENT_TIMESTAMP")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
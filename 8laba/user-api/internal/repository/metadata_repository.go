@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"user-api/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// MetadataSchemaRepository интерфейс для работы со схемами ключей metadata
+type MetadataSchemaRepository interface {
+	GetAll() ([]models.MetadataSchema, error)
+	Get(key string) (*models.MetadataSchema, error)
+	// GetByKeys возвращает схемы указанных ключей; ключи без схемы пропускаются
+	GetByKeys(keys []string) ([]models.MetadataSchema, error)
+	Put(schema *models.MetadataSchema) (*models.MetadataSchema, error)
+	Delete(key string) error
+}
+
+type metadataSchemaRepository struct {
+	db *sqlx.DB
+}
+
+// NewMetadataSchemaRepository создает новый репозиторий схем metadata
+func NewMetadataSchemaRepository(db *sqlx.DB) MetadataSchemaRepository {
+	return &metadataSchemaRepository{db: db}
+}
+
+const metadataSchemaColumns = "key, schema, description, created_at, updated_at"
+
+func (r *metadataSchemaRepository) GetAll() ([]models.MetadataSchema, error) {
+	schemas := []models.MetadataSchema{}
+	err := r.db.Select(&schemas, "SELECT "+metadataSchemaColumns+" FROM metadata_schemas ORDER BY key")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+func (r *metadataSchemaRepository) Get(key string) (*models.MetadataSchema, error) {
+	var schema models.MetadataSchema
+	err := r.db.Get(&schema, "SELECT "+metadataSchemaColumns+" FROM metadata_schemas WHERE key = $1", key)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrMetadataSchemaNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata schema: %w", err)
+	}
+	return &schema, nil
+}
+
+func (r *metadataSchemaRepository) GetByKeys(keys []string) ([]models.MetadataSchema, error) {
+	var schemas []models.MetadataSchema
+	err := r.db.Select(&schemas, "SELECT "+metadataSchemaColumns+" FROM metadata_schemas WHERE key = ANY($1)", pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata schemas: %w", err)
+	}
+	return schemas, nil
+}
+
+func (r *metadataSchemaRepository) Put(schema *models.MetadataSchema) (*models.MetadataSchema, error) {
+	query := `
+        INSERT INTO metadata_schemas (key, schema, description)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (key) DO UPDATE
+        SET schema = EXCLUDED.schema, description = EXCLUDED.description, updated_at = CURRENT_TIMESTAMP
+        RETURNING ` + metadataSchemaColumns
+
+	var saved models.MetadataSchema
+	err := r.db.QueryRowx(query, schema.Key, []byte(schema.Schema), schema.Description).StructScan(&saved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save metadata schema: %w", err)
+	}
+	return &saved, nil
+}
+
+func (r *metadataSchemaRepository) Delete(key string) error {
+	result, err := r.db.Exec("DELETE FROM metadata_schemas WHERE key = $1", key)
+	if err != nil {
+		return fmt.Errorf("failed to delete metadata schema: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return models.ErrMetadataSchemaNotFound
+	}
+	return nil
+}
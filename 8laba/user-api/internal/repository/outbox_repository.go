@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// OutboxChannel - канал LISTEN/NOTIFY, в который сообщается о новых
+// записях outbox
+const OutboxChannel = "outbox"
+
+// OutboxPublishedChannel - канал LISTEN/NOTIFY, в который сообщается о
+// публикации событий outbox
+const OutboxPublishedChannel = "outbox_published"
+
+// outboxLockKey - ключ advisory lock, под которым работает relay. Одна
+// активная транзакция relay сохраняет порядок событий при нескольких
+// экземплярах API.
+const outboxLockKey = 0x6f7574626f78 // "outbox"
+
+// OutboxRepository интерфейс для чтения outbox
+type OutboxRepository interface {
+	// Process выбирает до limit неопубликованных сообщений в порядке записи,
+	// пропуская пользователей skipUsers, и передает их publish. Сообщения,
+	// ID которых вернул publish, помечаются опубликованными в той же
+	// транзакции и получают номера публикации в порядке ID. Если другой
+	// relay уже обрабатывает outbox, Process ничего не делает и
+	// возвращает 0.
+	Process(limit int, skipUsers []int, publish func([]models.OutboxMessage) []int64) (int, error)
+	// DeletePublished удаляет сообщения, опубликованные раньше before
+	DeletePublished(before time.Time) (int64, error)
+	// LastPublishedSeq возвращает наибольший номер публикации (0, если
+	// опубликованных сообщений нет)
+	LastPublishedSeq(ctx context.Context) (int64, error)
+	// PublishedAfter возвращает до limit опубликованных сообщений с номером
+	// публикации больше seq в порядке номеров
+	PublishedAfter(ctx context.Context, seq int64, limit int) ([]models.OutboxMessage, error)
+	// HasPublished сообщает, хранится ли сообщение с номером публикации seq
+	HasPublished(ctx context.Context, seq int64) (bool, error)
+}
+
+type outboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepository создает новый репозиторий outbox
+func NewOutboxRepository(db *sqlx.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Process(limit int, skipUsers []int, publish func([]models.OutboxMessage) []int64) (int, error) {
+	tx, err := r.db.Beginx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked bool
+	if err := tx.Get(&locked, "SELECT pg_try_advisory_xact_lock($1)", outboxLockKey); err != nil {
+		return 0, fmt.Errorf("failed to lock outbox: %w", err)
+	}
+	if !locked {
+		return 0, nil
+	}
+
+	query := `
+        SELECT id, event_id, event_type, user_id, payload, created_at, published_at
+        FROM outbox
+        WHERE published_at IS NULL AND NOT (user_id = ANY($2))
+        ORDER BY id
+        LIMIT $1`
+
+	var messages []models.OutboxMessage
+	if err := tx.Select(&messages, query, limit, pq.Array(skipUsers)); err != nil {
+		return 0, fmt.Errorf("failed to get outbox messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	published := publish(messages)
+	if len(published) > 0 {
+		// Номера выдаются в подзапросе с ORDER BY, чтобы события одного
+		// пользователя получили их в порядке записи
+		_, err := tx.Exec(`
+            UPDATE outbox o
+            SET published_at = CURRENT_TIMESTAMP, published_seq = p.seq
+            FROM (
+                SELECT id, nextval('outbox_published_seq') AS seq
+                FROM (SELECT id FROM outbox WHERE id = ANY($1) ORDER BY id) ids
+            ) p
+            WHERE o.id = p.id`, pq.Int64Array(published))
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark outbox messages published: %w", err)
+		}
+		if _, err := tx.Exec("SELECT pg_notify($1, '')", OutboxPublishedChannel); err != nil {
+			return 0, fmt.Errorf("failed to notify outbox: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit outbox: %w", err)
+	}
+	return len(messages), nil
+}
+
+func (r *outboxRepository) DeletePublished(before time.Time) (int64, error) {
+	result, err := r.db.Exec("DELETE FROM outbox WHERE published_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete published outbox messages: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+func (r *outboxRepository) LastPublishedSeq(ctx context.Context) (int64, error) {
+	var seq int64
+	if err := r.db.GetContext(ctx, &seq, "SELECT COALESCE(MAX(published_seq), 0) FROM outbox"); err != nil {
+		return 0, fmt.Errorf("failed to get last published outbox message: %w", err)
+	}
+	return seq, nil
+}
+
+func (r *outboxRepository) PublishedAfter(ctx context.Context, seq int64, limit int) ([]models.OutboxMessage, error) {
+	query := `
+        SELECT id, event_id, event_type, user_id, payload, created_at, published_at, published_seq
+        FROM outbox
+        WHERE published_seq > $1
+        ORDER BY published_seq
+        LIMIT $2`
+
+	var messages []models.OutboxMessage
+	if err := r.db.SelectContext(ctx, &messages, query, seq, limit); err != nil {
+		return nil, fmt.Errorf("failed to get published outbox messages: %w", err)
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) HasPublished(ctx context.Context, seq int64) (bool, error) {
+	var exists bool
+	if err := r.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM outbox WHERE published_seq = $1)", seq); err != nil {
+		return false, fmt.Errorf("failed to find published outbox message: %w", err)
+	}
+	return exists, nil
+}
+
+// insertOutbox записывает событие в outbox в транзакции tx. Уведомление
+// NOTIFY будет отправлено только после фиксации транзакции.
+func insertOutbox(tx *sqlx.Tx, event events.Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	query := `
+        INSERT INTO outbox (event_id, event_type, user_id, payload)
+        VALUES ($1, $2, $3, $4)`
+
+	if _, err := tx.Exec(query, event.ID, string(event.Type), event.UserID, payload); err != nil {
+		return fmt.Errorf("failed to write outbox: %w", err)
+	}
+	if _, err := tx.Exec("SELECT pg_notify($1, '')", OutboxChannel); err != nil {
+		return fmt.Errorf("failed to notify outbox: %w", err)
+	}
+	return nil
+}
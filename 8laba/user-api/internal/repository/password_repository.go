@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PasswordRepository интерфейс для работы с паролями и токенами их сброса
+type PasswordRepository interface {
+	// FindUsersByEmail возвращает пользователей всех арендаторов с email
+	// (без учета регистра). Запрос сброса приходит без арендатора, а адрес
+	// уникален только в его пределах, поэтому поиск идет под ролью
+	// database.system.
+	FindUsersByEmail(ctx context.Context, email string) ([]models.User, error)
+	// CreateResetToken сохраняет хэш выданного токена
+	CreateResetToken(token *models.PasswordResetToken) error
+	// ResetPassword гасит токен с хэшем tokenHash и устанавливает пароль.
+	// Остальные токены пользователя тоже гасятся. Возвращает
+	// models.ErrInvalidResetToken, если токен не найден, истек или уже
+	// использован. Токен сам определяет пользователя, поэтому арендатор не
+	// нужен.
+	ResetPassword(tokenHash, passwordHash string) (int, error)
+	// PasswordChangedAt возвращает время последней смены пароля
+	// пользователя арендатора из ctx или nil, если пароль не менялся или
+	// пользователя нет
+	PasswordChangedAt(ctx context.Context, userID int) (*time.Time, error)
+	// DeleteExpired удаляет истекшие и использованные токены
+	DeleteExpired() (int64, error)
+}
+
+type passwordRepository struct {
+	db *sqlx.DB
+	// system - соединения роли, обходящей row-level security: токен из
+	// письма сам определяет пользователя любого арендатора
+	system *sqlx.DB
+}
+
+// NewPasswordRepository создает новый репозиторий паролей
+// (db - роль приложения, system - роль с BYPASSRLS из database.system)
+func NewPasswordRepository(db, system *sqlx.DB) PasswordRepository {
+	return &passwordRepository{db: db, system: system}
+}
+
+func (r *passwordRepository) FindUsersByEmail(ctx context.Context, email string) ([]models.User, error) {
+	query := `
+        SELECT ` + userColumns + `
+        FROM users
+        WHERE LOWER(email) = LOWER($1)
+        ORDER BY id
+    `
+
+	var users []models.User
+	err := withTenantTx(tenant.WithSystem(ctx), r.system, func(tx *sqlx.Tx) error {
+		if err := tx.SelectContext(ctx, &users, query, email); err != nil {
+			return fmt.Errorf("failed to get users: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (r *passwordRepository) CreateResetToken(token *models.PasswordResetToken) error {
+	query := `
+        INSERT INTO password_reset_tokens (token_hash, user_id, expires_at)
+        VALUES ($1, $2, $3)
+        RETURNING created_at`
+
+	err := r.db.Get(&token.CreatedAt, query, token.TokenHash, token.UserID, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reset token: %w", err)
+	}
+	return nil
+}
+
+func (r *passwordRepository) ResetPassword(tokenHash, passwordHash string) (int, error) {
+	var userID int
+	err := withTenantTx(tenant.WithSystem(context.Background()), r.system, func(tx *sqlx.Tx) error {
+		err := tx.Get(&userID, `
+            UPDATE password_reset_tokens
+            SET used_at = CURRENT_TIMESTAMP
+            WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+            RETURNING user_id`, tokenHash)
+		if err == sql.ErrNoRows {
+			return models.ErrInvalidResetToken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to use reset token: %w", err)
+		}
+
+		// Письма, отправленные до сброса, больше не действуют
+		if _, err := tx.Exec(`
+            UPDATE password_reset_tokens
+            SET used_at = CURRENT_TIMESTAMP
+            WHERE user_id = $1 AND used_at IS NULL`, userID); err != nil {
+			return fmt.Errorf("failed to revoke reset tokens: %w", err)
+		}
+
+		// password_changed_at отзывает access токены, выданные до сброса
+		// (см. service.TokenRevocations)
+		if _, err := tx.Exec(`
+            UPDATE users
+            SET password_hash = $2, password_changed_at = CURRENT_TIMESTAMP
+            WHERE id = $1`, userID, passwordHash); err != nil {
+			return fmt.Errorf("failed to set password: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (r *passwordRepository) PasswordChangedAt(ctx context.Context, userID int) (*time.Time, error) {
+	var changedAt *time.Time
+	err := withTenantTx(ctx, r.db, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &changedAt, "SELECT password_changed_at FROM users WHERE id = $1", userID)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get password change time: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changedAt, nil
+}
+
+func (r *passwordRepository) DeleteExpired() (int64, error) {
+	result, err := r.db.Exec(`
+        DELETE FROM password_reset_tokens
+        WHERE expires_at < CURRENT_TIMESTAMP OR used_at IS NOT NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired reset tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// TenantRepository интерфейс для работы с арендаторами. Таблица tenants
+// не ограничена row-level security: с ней работают только администраторы.
+type TenantRepository interface {
+	Create(req *models.CreateTenantRequest) (*models.Tenant, error)
+	GetByID(id int) (*models.Tenant, error)
+	GetAll(page, pageSize int) ([]models.Tenant, int, error)
+	SetStatus(id int, status string) (*models.Tenant, error)
+}
+
+type tenantRepository struct {
+	db *sqlx.DB
+}
+
+// NewTenantRepository создает новый репозиторий арендаторов
+func NewTenantRepository(db *sqlx.DB) TenantRepository {
+	return &tenantRepository{db: db}
+}
+
+const tenantColumns = "id, name, slug, status, created_at, updated_at"
+
+func (r *tenantRepository) Create(req *models.CreateTenantRequest) (*models.Tenant, error) {
+	query := `
+        INSERT INTO tenants (name, slug)
+        VALUES ($1, $2)
+        RETURNING ` + tenantColumns
+
+	var t models.Tenant
+	err := r.db.QueryRowx(query, req.Name, req.Slug).StructScan(&t)
+	if isUniqueViolation(err) {
+		return nil, models.ErrTenantSlugTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tenant: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *tenantRepository) GetByID(id int) (*models.Tenant, error) {
+	var t models.Tenant
+	err := r.db.Get(&t, "SELECT "+tenantColumns+" FROM tenants WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return &t, nil
+}
+
+func (r *tenantRepository) GetAll(page, pageSize int) ([]models.Tenant, int, error) {
+	var total int
+	if err := r.db.Get(&total, "SELECT COUNT(*) FROM tenants"); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tenants: %w", err)
+	}
+
+	tenants := []models.Tenant{}
+	query := "SELECT " + tenantColumns + " FROM tenants ORDER BY id LIMIT $1 OFFSET $2"
+	if err := r.db.Select(&tenants, query, pageSize, (page-1)*pageSize); err != nil {
+		return nil, 0, fmt.Errorf("failed to get tenants: %w", err)
+	}
+
+	return tenants, total, nil
+}
+
+func (r *tenantRepository) SetStatus(id int, status string) (*models.Tenant, error) {
+	query := `
+        UPDATE tenants
+        SET status = $2, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1
+        RETURNING ` + tenantColumns
+
+	var t models.Tenant
+	err := r.db.QueryRowx(query, id, status).StructScan(&t)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update tenant: %w", err)
+	}
+
+	return &t, nil
+}
+
+// withTenantTx выполняет fn в транзакции, в которой row-level security
+// ограничивает таблицы арендатором из ctx. Контекст без арендатора
+// отклоняется, чтобы забытая привязка не открывала данные всех арендаторов.
+func withTenantTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	return withTx(db, func(tx *sqlx.Tx) error {
+		if err := setTenant(ctx, tx); err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
+func setTenant(ctx context.Context, tx *sqlx.Tx) error {
+	// Настройки, снимающей политики, нет: их обходит только роль
+	// database.system (BYPASSRLS). В соединениях роли приложения системный
+	// контекст не видит ни одной строки.
+	if tenant.IsSystem(ctx) {
+		return nil
+	}
+
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return models.ErrTenantRequired
+	}
+	if _, err := tx.ExecContext(ctx, "SELECT set_config('app.tenant_id', $1, true)", strconv.Itoa(id)); err != nil {
+		return fmt.Errorf("failed to set tenant: %w", err)
+	}
+	return nil
+}
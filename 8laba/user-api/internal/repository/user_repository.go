@@ -1,69 +1,196 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
 	"strings"
+	"time"
+	"user-api/internal/database"
+	"user-api/internal/events"
 	"user-api/internal/models"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // UserRepository интерфейс для работы с пользователями
 type UserRepository interface {
-	Create(user *models.CreateUserRequest) (*models.User, error)
-	GetByID(id int) (*models.User, error)
-	GetAll(page, pageSize int, filters map[string]interface{}) ([]models.User, int, error)
-	Update(id int, user *models.UpdateUserRequest) (*models.User, error)
-	Delete(id int) error
+	Create(ctx context.Context, user *models.CreateUserRequest) (*models.User, error)
+	GetByID(ctx context.Context, id int) (*models.User, error)
+	GetAll(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]models.User, int, error)
+	// Update изменяет заданные поля и сообщает, сменился ли email: новый
+	// адрес нужно подтвердить заново
+	Update(ctx context.Context, id int, user *models.UpdateUserRequest) (*models.User, bool, error)
+	Delete(ctx context.Context, id int) error
+	// SetAvatar заменяет аватар пользователя (nil key - удаляет) и
+	// возвращает ключ прежнего аватара, чтобы удалить его файлы
+	SetAvatar(ctx context.Context, id int, key *string, urls models.AvatarURLs) (*models.User, *string, error)
+	// GetGroups возвращает группы пользователей userIDs по ID пользователя
+	GetGroups(ctx context.Context, userIDs []int) (map[int][]models.UserGroup, error)
+	// EmailExists проверяет, занят ли email (без учета регистра) другим
+	// пользователем, кроме excludeID
+	EmailExists(ctx context.Context, email string, excludeID int) (bool, error)
+	// CreateBatch вставляет пользователей одним запросом и возвращает число
+	// созданных; пользователи с занятым email пропускаются. Из users
+	// берутся name, email, age, metadata, email_verified_at, created_at и
+	// updated_at (нулевое время - текущее). Предназначен для заполнения
+	// БД тестовыми данными, поэтому события в outbox не пишутся.
+	CreateBatch(ctx context.Context, users []models.User) (int, error)
+	// DeleteAll удаляет всех пользователей арендатора без событий в outbox
+	// и возвращает их число
+	DeleteAll(ctx context.Context) (int, error)
+	// Stats считает статистику пользователей по тем же фильтрам, что и
+	// GetAll
+	Stats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error)
+}
+
+// userColumns - колонки users, из которых собирается models.User
+const userColumns = "id, tenant_id, name, email, age, email_verified_at, avatar_key, avatar, metadata, created_at, updated_at"
+
+// selectColumns возвращает колонки для полей fields (см. models.UserFields).
+// id выбирается всегда: по нему встраиваются связанные ресурсы.
+func selectColumns(fields []string) string {
+	if len(fields) == 0 {
+		return userColumns
+	}
+	columns := []string{"id"}
+	for _, field := range fields {
+		if field != "id" && slices.Contains(models.UserFields, field) {
+			columns = append(columns, field)
+		}
+	}
+	return strings.Join(columns, ", ")
 }
 
 type userRepository struct {
-	db *sqlx.DB
+	db *database.Cluster
 }
 
-// NewUserRepository создает новый репозиторий пользователей
-func NewUserRepository(db *sqlx.DB) UserRepository {
+// NewUserRepository создает новый репозиторий пользователей. Все запросы
+// выполняются в транзакции арендатора из ctx (см. withTenantTx). Чтение
+// пользователей и списков идет в реплики db, запись и проверка
+// уникальности email - в основную БД.
+func NewUserRepository(db *database.Cluster) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(req *models.CreateUserRequest) (*models.User, error) {
+func (r *userRepository) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	query := `
-        INSERT INTO users (name, email, age)
-        VALUES ($1, $2, $3)
-        RETURNING id, name, email, age, created_at, updated_at
+        INSERT INTO users (name, email, age, metadata)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + userColumns + `
     `
 
 	var user models.User
-	err := r.db.QueryRowx(query, req.Name, req.Email, req.Age).StructScan(&user)
+	err := withTenantTx(ctx, r.db.Writer(ctx), func(tx *sqlx.Tx) error {
+		err := tx.QueryRowxContext(ctx, query, req.Name, req.Email, req.Age, req.Metadata).StructScan(&user)
+		if isUniqueViolation(err) {
+			return models.ErrEmailTaken
+		}
+		if isMetadataViolation(err) {
+			return fmt.Errorf("%w: metadata is too large", models.ErrInvalidMetadata)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create user: %w", err)
+		}
+		return insertOutbox(tx, events.New(events.UserCreated, user.ID, &user))
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user: %w", err)
+		return nil, err
 	}
 
 	return &user, nil
 }
 
-func (r *userRepository) GetByID(id int) (*models.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id int) (*models.User, error) {
 	query := `
-        SELECT id, name, email, age, created_at, updated_at
+        SELECT ` + userColumns + `
         FROM users
         WHERE id = $1
     `
 
 	var user models.User
-	err := r.db.Get(&user, query, id)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
-	}
+	err := withTenantTx(ctx, r.db.Reader(ctx), func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &user, query, id)
+		if err == sql.ErrNoRows {
+			return models.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user: %w", err)
+		return nil, err
 	}
 
 	return &user, nil
 }
 
-func (r *userRepository) GetAll(page, pageSize int, filters map[string]interface{}) ([]models.User, int, error) {
-	// Построение WHERE clause для фильтрации
+func (r *userRepository) EmailExists(ctx context.Context, email string, excludeID int) (bool, error) {
+	// Условие совпадает с выражением уникального индекса users_tenant_email_lower_key
+	query := `
+        SELECT EXISTS (
+            SELECT 1 FROM users WHERE LOWER(email) = LOWER($1) AND id <> $2
+        )
+    `
+
+	var exists bool
+	err := withTenantTx(ctx, r.db.Primary(), func(tx *sqlx.Tx) error {
+		if err := tx.GetContext(ctx, &exists, query, email, excludeID); err != nil {
+			return fmt.Errorf("failed to check email: %w", err)
+		}
+		return nil
+	})
+	return exists, err
+}
+
+func (r *userRepository) GetAll(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]models.User, int, error) {
+	whereClause, args := filterClause(filters)
+	argCounter := len(args) + 1
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users %s", whereClause)
+
+	fields, _ := filters["fields"].([]string)
+	offset := (page - 1) * pageSize
+	query := fmt.Sprintf(`
+        SELECT `+selectColumns(fields)+`
+        FROM users
+        %s
+        ORDER BY %s
+        LIMIT $%d OFFSET $%d
+    `, whereClause, orderBy(filters), argCounter, argCounter+1)
+
+	var total int
+	var users []models.User
+	err := withTenantTx(ctx, r.db.Reader(ctx), func(tx *sqlx.Tx) error {
+		// Подсчет общего количества
+		if err := tx.GetContext(ctx, &total, countQuery, args...); err != nil {
+			return fmt.Errorf("failed to count users: %w", err)
+		}
+
+		// Получение данных с пагинацией
+		if err := tx.SelectContext(ctx, &users, query, append(args, pageSize, offset)...); err != nil {
+			return fmt.Errorf("failed to get users: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// filterClause строит WHERE из фильтров списка (name, email, min_age,
+// max_age, verified, group, metadata, after_id) и возвращает его с
+// аргументами $1..$n
+func filterClause(filters map[string]interface{}) (string, []interface{}) {
 	var conditions []string
 	var args []interface{}
 	argCounter := 1
@@ -92,41 +219,177 @@ func (r *userRepository) GetAll(page, pageSize int, filters map[string]interface
 		argCounter++
 	}
 
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	if verified, ok := filters["verified"].(bool); ok {
+		if verified {
+			conditions = append(conditions, "email_verified_at IS NOT NULL")
+		} else {
+			conditions = append(conditions, "email_verified_at IS NULL")
+		}
 	}
 
-	// Подсчет общего количества
-	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM users %s", whereClause)
-	var total int
-	err := r.db.Get(&total, countQuery, args...)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count users: %w", err)
+	if groupID, ok := filters["group"].(int); ok && groupID > 0 {
+		conditions = append(conditions, fmt.Sprintf("id IN (SELECT user_id FROM group_members WHERE group_id = $%d)", argCounter))
+		args = append(args, groupID)
+		argCounter++
 	}
 
-	// Получение данных с пагинацией
-	offset := (page - 1) * pageSize
-	query := fmt.Sprintf(`
-        SELECT id, name, email, age, created_at, updated_at
+	// after_id - пользователи с ID больше заданного: постраничное чтение по
+	// ключу (keyset), которое не пропускает и не повторяет пользователей
+	// при одновременных изменениях, в отличие от OFFSET
+	if afterID, ok := filters["after_id"].(int); ok && afterID > 0 {
+		conditions = append(conditions, fmt.Sprintf("id > $%d", argCounter))
+		args = append(args, afterID)
+		argCounter++
+	}
+
+	// metadata.<ключ>=<значение>: строка или JSON литерал (число, true,
+	// false), поэтому ищутся оба варианта. Оба условия используют GIN индекс.
+	if metadata, ok := filters["metadata"].(map[string]string); ok {
+		keys := make([]string, 0, len(metadata))
+		for key := range metadata {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			alternatives := []string{metadataContains(key, metadata[key], false)}
+			if literal := metadataContains(key, metadata[key], true); literal != "" {
+				alternatives = append(alternatives, literal)
+			}
+			var parts []string
+			for _, value := range alternatives {
+				parts = append(parts, fmt.Sprintf("metadata @> $%d::jsonb", argCounter))
+				args = append(args, value)
+				argCounter++
+			}
+			conditions = append(conditions, "("+strings.Join(parts, " OR ")+")")
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+func (r *userRepository) Stats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error) {
+	whereClause, args := filterClause(filters)
+	n := len(args) + 1
+
+	summaryQuery := fmt.Sprintf(`
+        SELECT COUNT(*), AVG(age)::float8, percentile_cont(0.5) WITHIN GROUP (ORDER BY age)
         FROM users
         %s
-        ORDER BY created_at DESC
-        LIMIT $%d OFFSET $%d
-    `, whereClause, argCounter, argCounter+1)
+    `, whereClause)
+	// width_bucket возвращает 0 для возраста младше первой границы и
+	// len(bounds) для возраста от последней
+	histogramQuery := fmt.Sprintf(`
+        SELECT width_bucket(age, $%d::int[]) AS bucket, COUNT(*)
+        FROM users
+        %s
+        GROUP BY bucket
+    `, n, whereClause)
+	// Регистрации берутся только из окна opts.From-opts.To, поэтому число
+	// периодов ограничено; периоды без регистраций между первым и
+	// последним дополняются нулями
+	signupsWhere := "WHERE"
+	if whereClause != "" {
+		signupsWhere = whereClause + " AND"
+	}
+	signupsQuery := fmt.Sprintf(`
+        WITH counts AS (
+            SELECT date_trunc($%[1]d::text, created_at AT TIME ZONE 'UTC') AS period, COUNT(*) AS count
+            FROM users
+            %[2]s created_at >= $%[3]d AND created_at < $%[4]d
+            GROUP BY period
+        )
+        SELECT p.period AT TIME ZONE 'UTC', COALESCE(c.count, 0)
+        FROM generate_series((SELECT MIN(period) FROM counts), (SELECT MAX(period) FROM counts),
+                             ('1 ' || $%[1]d::text)::interval) AS p(period)
+        LEFT JOIN counts c ON c.period = p.period
+        ORDER BY p.period
+    `, n, signupsWhere, n+1, n+2)
+	from, to := opts.SignupsRange(time.Now())
+
+	stats := &models.UserStats{
+		AgeHistogram: models.NewAgeHistogram(opts.AgeBuckets),
+		Interval:     opts.Interval,
+		Signups:      []models.SignupCount{},
+	}
+	err := withTenantTx(ctx, r.db.Reader(ctx), func(tx *sqlx.Tx) error {
+		var mean, median sql.NullFloat64
+		if err := tx.QueryRowxContext(ctx, summaryQuery, args...).Scan(&stats.Total, &mean, &median); err != nil {
+			return fmt.Errorf("failed to count users: %w", err)
+		}
+		if mean.Valid {
+			stats.MeanAge, stats.MedianAge = &mean.Float64, &median.Float64
+		}
+
+		rows, err := tx.QueryxContext(ctx, histogramQuery, append(args, pq.Array(opts.AgeBuckets))...)
+		if err != nil {
+			return fmt.Errorf("failed to get age histogram: %w", err)
+		}
+		for rows.Next() {
+			var bucket, count int
+			if err := rows.Scan(&bucket, &count); err != nil {
+				rows.Close()
+				return err
+			}
+			stats.AgeHistogram[bucket].Count = count
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("failed to get age histogram: %w", err)
+		}
+
+		rows, err = tx.QueryxContext(ctx, signupsQuery, append(args, opts.Interval, from, to)...)
+		if err != nil {
+			return fmt.Errorf("failed to get signups: %w", err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var signup models.SignupCount
+			if err := rows.Scan(&signup.Period, &signup.Count); err != nil {
+				return err
+			}
+			signup.Period = signup.Period.UTC()
+			stats.Signups = append(stats.Signups, signup)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
 
-	args = append(args, pageSize, offset)
+// sortColumns - поля, по которым разрешена сортировка
+var sortColumns = map[string]bool{
+	"id":         true,
+	"name":       true,
+	"email":      true,
+	"age":        true,
+	"created_at": true,
+	"updated_at": true,
+}
 
-	var users []models.User
-	err = r.db.Select(&users, query, args...)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to get users: %w", err)
+// orderBy строит ORDER BY из фильтров sort_by и sort_order.
+// По умолчанию - сначала новые.
+func orderBy(filters map[string]interface{}) string {
+	column, ok := filters["sort_by"].(string)
+	if !ok || !sortColumns[column] {
+		return "created_at DESC, id DESC"
 	}
 
-	return users, total, nil
+	direction := "ASC"
+	if order, _ := filters["sort_order"].(string); strings.EqualFold(order, "desc") {
+		direction = "DESC"
+	}
+	// id добавляется, чтобы порядок страниц был стабильным при равных значениях
+	return fmt.Sprintf("%s %s, id %s", column, direction, direction)
 }
 
-func (r *userRepository) Update(id int, req *models.UpdateUserRequest) (*models.User, error) {
+func (r *userRepository) Update(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, bool, error) {
 	var updates []string
 	var args []interface{}
 	argCounter := 1
@@ -138,7 +401,10 @@ func (r *userRepository) Update(id int, req *models.UpdateUserRequest) (*models.
 	}
 
 	if req.Email != "" {
-		updates = append(updates, fmt.Sprintf("email = $%d", argCounter))
+		// Новый адрес нужно подтвердить заново
+		updates = append(updates,
+			fmt.Sprintf("email = $%d", argCounter),
+			fmt.Sprintf("email_verified_at = CASE WHEN email = $%d THEN email_verified_at END", argCounter))
 		args = append(args, req.Email)
 		argCounter++
 	}
@@ -149,8 +415,26 @@ func (r *userRepository) Update(id int, req *models.UpdateUserRequest) (*models.
 		argCounter++
 	}
 
+	if len(req.Metadata) > 0 {
+		set := models.Metadata{}
+		remove := []string{}
+		for key, value := range req.Metadata {
+			if models.IsNull(value) {
+				remove = append(remove, key)
+			} else {
+				set[key] = value
+			}
+		}
+		updates = append(updates, fmt.Sprintf("metadata = (metadata || $%d::jsonb) - $%d::text[]", argCounter, argCounter+1))
+		args = append(args, set, pq.Array(remove))
+		argCounter += 2
+	}
+
 	if len(updates) == 0 {
-		return r.GetByID(id)
+		// Пустое изменение возвращает пользователя из основной БД, как и
+		// настоящее
+		user, err := r.GetByID(database.WithPrimary(ctx), id)
+		return user, false, err
 	}
 
 	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
@@ -160,36 +444,254 @@ func (r *userRepository) Update(id int, req *models.UpdateUserRequest) (*models.
         UPDATE users
         SET %s
         WHERE id = $%d
-        RETURNING id, name, email, age, created_at, updated_at
+        RETURNING `+userColumns+`
     `, strings.Join(updates, ", "), argCounter)
 
 	var user models.User
-	err := r.db.QueryRowx(query, args...).StructScan(&user)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("user not found")
+	var previousEmail string
+	err := withTenantTx(ctx, r.db.Writer(ctx), func(tx *sqlx.Tx) error {
+		if req.Email != "" {
+			err := tx.GetContext(ctx, &previousEmail, "SELECT email FROM users WHERE id = $1 FOR UPDATE", id)
+			if err == sql.ErrNoRows {
+				return models.ErrUserNotFound
+			}
+			if err != nil {
+				return fmt.Errorf("failed to get user: %w", err)
+			}
+		}
+		err := tx.QueryRowxContext(ctx, query, args...).StructScan(&user)
+		if err == sql.ErrNoRows {
+			return models.ErrUserNotFound
+		}
+		if isUniqueViolation(err) {
+			return models.ErrEmailTaken
+		}
+		if isMetadataViolation(err) {
+			return fmt.Errorf("%w: metadata is too large", models.ErrInvalidMetadata)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update user: %w", err)
+		}
+		return insertOutbox(tx, events.New(events.UserUpdated, user.ID, &user))
+	})
+	if err != nil {
+		return nil, false, err
 	}
+
+	return &user, req.Email != "" && user.Email != previousEmail, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id int) error {
+	query := `
+        DELETE FROM users
+        WHERE id = $1
+        RETURNING ` + userColumns + `
+    `
+
+	return withTenantTx(ctx, r.db.Writer(ctx), func(tx *sqlx.Tx) error {
+		var user models.User
+		err := tx.QueryRowxContext(ctx, query, id).StructScan(&user)
+		if err == sql.ErrNoRows {
+			return models.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to delete user: %w", err)
+		}
+		// Событие содержит последнее состояние удаленного пользователя
+		return insertOutbox(tx, events.New(events.UserDeleted, user.ID, &user))
+	})
+}
+
+func (r *userRepository) CreateBatch(ctx context.Context, users []models.User) (int, error) {
+	if len(users) == 0 {
+		return 0, nil
+	}
+	// Строки передаются массивами колонок: один запрос на пачку вместо
+	// запроса на пользователя. COPY не подходит - он не работает с
+	// row-level security.
+	query := `
+        INSERT INTO users (name, email, age, metadata, email_verified_at, created_at, updated_at)
+        SELECT name, email, age, metadata, email_verified_at,
+               COALESCE(created_at, NOW()), COALESCE(updated_at, NOW())
+        FROM unnest($1::text[], $2::text[], $3::int[], $4::jsonb[],
+                    $5::timestamptz[], $6::timestamptz[], $7::timestamptz[])
+             AS t(name, email, age, metadata, email_verified_at, created_at, updated_at)
+        ON CONFLICT DO NOTHING
+    `
+
+	n := len(users)
+	names, emails := make([]string, n), make([]string, n)
+	ages := make([]int64, n)
+	metadata := make([]string, n)
+	verifiedAt, createdAt, updatedAt := make([]sql.NullString, n), make([]sql.NullString, n), make([]sql.NullString, n)
+	for i := range users {
+		user := &users[i]
+		names[i], emails[i], ages[i] = user.Name, user.Email, int64(user.Age)
+		metadata[i] = "{}"
+		if len(user.Metadata) > 0 {
+			data, err := json.Marshal(user.Metadata)
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal metadata: %w", err)
+			}
+			metadata[i] = string(data)
+		}
+		if user.EmailVerifiedAt != nil {
+			verifiedAt[i] = nullTime(*user.EmailVerifiedAt)
+		}
+		createdAt[i], updatedAt[i] = nullTime(user.CreatedAt), nullTime(user.UpdatedAt)
+	}
+
+	var created int64
+	err := withTenantTx(ctx, r.db.Writer(ctx), func(tx *sqlx.Tx) error {
+		result, err := tx.ExecContext(ctx, query, pq.Array(names), pq.Array(emails), pq.Array(ages),
+			pq.Array(metadata), pq.Array(verifiedAt), pq.Array(createdAt), pq.Array(updatedAt))
+		if isMetadataViolation(err) {
+			return fmt.Errorf("%w: metadata is too large", models.ErrInvalidMetadata)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to create users: %w", err)
+		}
+		created, err = result.RowsAffected()
+		return err
+	})
+	return int(created), err
+}
+
+func (r *userRepository) DeleteAll(ctx context.Context) (int, error) {
+	var deleted int64
+	err := withTenantTx(ctx, r.db.Writer(ctx), func(tx *sqlx.Tx) error {
+		// Строки других арендаторов скрыты политикой row-level security
+		result, err := tx.ExecContext(ctx, "DELETE FROM users")
+		if err != nil {
+			return fmt.Errorf("failed to delete users: %w", err)
+		}
+		deleted, err = result.RowsAffected()
+		return err
+	})
+	return int(deleted), err
+}
+
+func (r *userRepository) SetAvatar(ctx context.Context, id int, key *string, urls models.AvatarURLs) (*models.User, *string, error) {
+	var user models.User
+	var previous *string
+	err := withTenantTx(ctx, r.db.Writer(ctx), func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &previous, "SELECT avatar_key FROM users WHERE id = $1 FOR UPDATE", id)
+		if err == sql.ErrNoRows {
+			return models.ErrUserNotFound
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %w", err)
+		}
+
+		query := `
+            UPDATE users
+            SET avatar_key = $2, avatar = $3, updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1
+            RETURNING ` + userColumns
+		if err := tx.QueryRowxContext(ctx, query, id, key, urls).StructScan(&user); err != nil {
+			return fmt.Errorf("failed to set avatar: %w", err)
+		}
+		return insertOutbox(tx, events.New(events.UserUpdated, user.ID, &user))
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update user: %w", err)
+		return nil, nil, err
 	}
 
-	return &user, nil
+	return &user, previous, nil
 }
 
-func (r *userRepository) Delete(id int) error {
-	query := "DELETE FROM users WHERE id = $1"
-	result, err := r.db.Exec(query, id)
+// GetGroups читает группы всех пользователей userIDs одним запросом
+func (r *userRepository) GetGroups(ctx context.Context, userIDs []int) (map[int][]models.UserGroup, error) {
+	query := `
+        SELECT m.user_id, g.id, g.name, m.role
+        FROM group_members m
+        JOIN groups g ON g.id = m.group_id
+        WHERE m.user_id = ANY($1)
+        ORDER BY g.name
+    `
+
+	var rows []struct {
+		UserID int `db:"user_id"`
+		models.UserGroup
+	}
+	err := withTenantTx(ctx, r.db.Reader(ctx), func(tx *sqlx.Tx) error {
+		if err := tx.SelectContext(ctx, &rows, query, pq.Array(userIDs)); err != nil {
+			return fmt.Errorf("failed to get user groups: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return nil, err
+	}
+
+	groups := make(map[int][]models.UserGroup, len(userIDs))
+	for _, row := range rows {
+		groups[row.UserID] = append(groups[row.UserID], row.UserGroup)
 	}
+	return groups, nil
+}
 
-	rowsAffected, err := result.RowsAffected()
+// withTx выполняет fn в транзакции. Изменение пользователя и запись о нем
+// в outbox фиксируются вместе, поэтому событие не теряется при падении
+// процесса и не публикуется для отмененного изменения.
+func withTx(db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.Beginx()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("user not found")
+	if err := fn(tx); err != nil {
+		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
+
+// isMetadataViolation проверяет, что ошибка - нарушение ограничения
+// размера metadata
+func isMetadataViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23514" && pqErr.Constraint == "users_metadata_size"
+}
+
+// metadataContains возвращает JSON объект {key: value} для оператора @>.
+// Если literal равен true, value подставляется как JSON литерал; для
+// значений, которые не являются числом или true/false, возвращается "".
+func metadataContains(key, value string, literal bool) string {
+	var raw json.RawMessage
+	if literal {
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return ""
+		}
+		switch v.(type) {
+		case float64, bool:
+			raw = json.RawMessage(value)
+		default:
+			return ""
+		}
+	} else {
+		raw, _ = json.Marshal(value)
+	}
+	obj, _ := json.Marshal(map[string]json.RawMessage{key: raw})
+	return string(obj)
+}
+
+// isUniqueViolation проверяет, что ошибка - нарушение уникального индекса
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// nullTime возвращает t в формате для массива timestamptz; нулевое время -
+// NULL
+func nullTime(t time.Time) sql.NullString {
+	if t.IsZero() {
+		return sql.NullString{}
+	}
+	return sql.NullString{String: t.Format(time.RFC3339Nano), Valid: true}
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"user-api/internal/events"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// VerificationRepository интерфейс для работы с токенами подтверждения email
+type VerificationRepository interface {
+	// CreateToken сохраняет выданный токен
+	CreateToken(token *models.VerificationToken) error
+	// UseToken гасит токен id пользователя userID и отмечает email
+	// подтвержденным. Возвращает models.ErrInvalidToken, если токен не найден,
+	// уже использован, истек или адрес пользователя с тех пор изменился.
+	// Токен сам определяет пользователя, поэтому арендатор не нужен.
+	UseToken(id string, userID int) (*models.User, error)
+	// DeleteExpired удаляет истекшие и использованные токены
+	DeleteExpired() (int64, error)
+}
+
+type verificationRepository struct {
+	db *sqlx.DB
+	// system - соединения роли, обходящей row-level security: токен из
+	// письма сам определяет пользователя любого арендатора
+	system *sqlx.DB
+}
+
+// NewVerificationRepository создает новый репозиторий токенов подтверждения
+// (db - роль приложения, system - роль с BYPASSRLS из database.system)
+func NewVerificationRepository(db, system *sqlx.DB) VerificationRepository {
+	return &verificationRepository{db: db, system: system}
+}
+
+func (r *verificationRepository) CreateToken(token *models.VerificationToken) error {
+	query := `
+        INSERT INTO email_verification_tokens (id, user_id, email, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING created_at`
+
+	err := r.db.Get(&token.CreatedAt, query, token.ID, token.UserID, token.Email, token.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create verification token: %w", err)
+	}
+	return nil
+}
+
+func (r *verificationRepository) UseToken(id string, userID int) (*models.User, error) {
+	var user models.User
+	err := withTenantTx(tenant.WithSystem(context.Background()), r.system, func(tx *sqlx.Tx) error {
+		var email string
+		err := tx.Get(&email, `
+            UPDATE email_verification_tokens
+            SET used_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND user_id = $2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+            RETURNING email`, id, userID)
+		if err == sql.ErrNoRows {
+			return models.ErrInvalidToken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to use verification token: %w", err)
+		}
+
+		err = tx.QueryRowx(`
+            UPDATE users
+            SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP),
+                updated_at = CURRENT_TIMESTAMP
+            WHERE id = $1 AND email = $2
+            RETURNING `+userColumns, userID, email).StructScan(&user)
+		if err == sql.ErrNoRows {
+			// Токен выдан для прежнего адреса
+			return models.ErrInvalidToken
+		}
+		if err != nil {
+			return fmt.Errorf("failed to verify email: %w", err)
+		}
+		return insertOutbox(tx, events.New(events.UserUpdated, user.ID, &user))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (r *verificationRepository) DeleteExpired() (int64, error) {
+	result, err := r.db.Exec(`
+        DELETE FROM email_verification_tokens
+        WHERE expires_at < CURRENT_TIMESTAMP OR used_at IS NOT NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired verification tokens: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,285 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"user-api/internal/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// WebhookRepository интерфейс для работы с вебхуками и журналом доставок
+type WebhookRepository interface {
+	Create(webhook *models.Webhook) (*models.Webhook, error)
+	GetByID(id int) (*models.Webhook, error)
+	GetAll() ([]models.Webhook, error)
+	Update(id int, req *models.UpdateWebhookRequest) (*models.Webhook, error)
+	Delete(id int) error
+	GetActiveByEvent(eventType string) ([]models.Webhook, error)
+
+	CreateDelivery(webhookID int, eventID, eventType string, payload json.RawMessage, redeliveryOf *int64) (*models.WebhookDelivery, error)
+	GetDelivery(webhookID int, id int64) (*models.WebhookDelivery, error)
+	GetDeliveries(webhookID, page, pageSize int) ([]models.WebhookDelivery, int, error)
+	ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	MarkSucceeded(id int64, responseStatus int) error
+	MarkFailed(id int64, responseStatus *int, lastErr string, nextAttemptAt *time.Time) error
+}
+
+type webhookRepository struct {
+	db *sqlx.DB
+}
+
+// NewWebhookRepository создает новый репозиторий вебхуков
+func NewWebhookRepository(db *sqlx.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+const webhookColumns = "id, url, secret, events, active, created_at, updated_at"
+
+const deliveryColumns = `id, webhook_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+        last_error, response_status, redelivery_of, created_at, updated_at, delivered_at`
+
+func (r *webhookRepository) Create(webhook *models.Webhook) (*models.Webhook, error) {
+	query := `
+        INSERT INTO webhooks (url, secret, events, active)
+        VALUES ($1, $2, $3, $4)
+        RETURNING ` + webhookColumns
+
+	var created models.Webhook
+	err := r.db.QueryRowx(query, webhook.URL, webhook.Secret, webhook.Events, webhook.Active).StructScan(&created)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *webhookRepository) GetByID(id int) (*models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE id = $1"
+
+	var webhook models.Webhook
+	err := r.db.Get(&webhook, query, id)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+func (r *webhookRepository) GetAll() ([]models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks ORDER BY id"
+
+	webhooks := []models.Webhook{}
+	if err := r.db.Select(&webhooks, query); err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) Update(id int, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	var updates []string
+	var args []interface{}
+	argCounter := 1
+
+	if req.URL != "" {
+		updates = append(updates, fmt.Sprintf("url = $%d", argCounter))
+		args = append(args, req.URL)
+		argCounter++
+	}
+
+	if req.Secret != "" {
+		updates = append(updates, fmt.Sprintf("secret = $%d", argCounter))
+		args = append(args, req.Secret)
+		argCounter++
+	}
+
+	if len(req.Events) > 0 {
+		updates = append(updates, fmt.Sprintf("events = $%d", argCounter))
+		args = append(args, pq.StringArray(req.Events))
+		argCounter++
+	}
+
+	if req.Active != nil {
+		updates = append(updates, fmt.Sprintf("active = $%d", argCounter))
+		args = append(args, *req.Active)
+		argCounter++
+	}
+
+	if len(updates) == 0 {
+		return r.GetByID(id)
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+        UPDATE webhooks
+        SET %s
+        WHERE id = $%d
+        RETURNING %s
+    `, strings.Join(updates, ", "), argCounter, webhookColumns)
+
+	var webhook models.Webhook
+	err := r.db.QueryRowx(query, args...).StructScan(&webhook)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrWebhookNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return &webhook, nil
+}
+
+func (r *webhookRepository) Delete(id int) error {
+	result, err := r.db.Exec("DELETE FROM webhooks WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return models.ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (r *webhookRepository) GetActiveByEvent(eventType string) ([]models.Webhook, error) {
+	query := "SELECT " + webhookColumns + " FROM webhooks WHERE active AND $1 = ANY(events)"
+
+	var webhooks []models.Webhook
+	if err := r.db.Select(&webhooks, query, eventType); err != nil {
+		return nil, fmt.Errorf("failed to get webhooks for event: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+// CreateDelivery ставит событие в очередь доставки. Повторная постановка
+// того же события на тот же вебхук (не redelivery) возвращает
+// models.ErrDeliveryExists.
+func (r *webhookRepository) CreateDelivery(webhookID int, eventID, eventType string, payload json.RawMessage, redeliveryOf *int64) (*models.WebhookDelivery, error) {
+	query := `
+        INSERT INTO webhook_deliveries (webhook_id, event_id, event_type, payload, redelivery_of)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (webhook_id, event_id) WHERE redelivery_of IS NULL DO NOTHING
+        RETURNING ` + deliveryColumns
+
+	var delivery models.WebhookDelivery
+	err := r.db.QueryRowx(query, webhookID, eventID, eventType, []byte(payload), redeliveryOf).StructScan(&delivery)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrDeliveryExists
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDelivery(webhookID int, id int64) (*models.WebhookDelivery, error) {
+	query := "SELECT " + deliveryColumns + " FROM webhook_deliveries WHERE webhook_id = $1 AND id = $2"
+
+	var delivery models.WebhookDelivery
+	err := r.db.Get(&delivery, query, webhookID, id)
+	if err == sql.ErrNoRows {
+		return nil, models.ErrDeliveryNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+func (r *webhookRepository) GetDeliveries(webhookID, page, pageSize int) ([]models.WebhookDelivery, int, error) {
+	var total int
+	err := r.db.Get(&total, "SELECT COUNT(*) FROM webhook_deliveries WHERE webhook_id = $1", webhookID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count deliveries: %w", err)
+	}
+
+	query := "SELECT " + deliveryColumns + `
+        FROM webhook_deliveries
+        WHERE webhook_id = $1
+        ORDER BY created_at DESC, id DESC
+        LIMIT $2 OFFSET $3`
+
+	deliveries := []models.WebhookDelivery{}
+	if err := r.db.Select(&deliveries, query, webhookID, pageSize, (page-1)*pageSize); err != nil {
+		return nil, 0, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	return deliveries, total, nil
+}
+
+// ClaimDue выбирает доставки, время которых наступило, и сдвигает их
+// next_attempt_at на lease, чтобы другие воркеры (в том числе в других
+// процессах) не взяли их одновременно. Если воркер упадет, доставка
+// снова станет доступной после истечения lease.
+func (r *webhookRepository) ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	query := `
+        UPDATE webhook_deliveries
+        SET next_attempt_at = CURRENT_TIMESTAMP + $2 * INTERVAL '1 millisecond'
+        WHERE id IN (
+            SELECT id FROM webhook_deliveries
+            WHERE status = 'pending' AND next_attempt_at <= CURRENT_TIMESTAMP
+            ORDER BY next_attempt_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING ` + deliveryColumns
+
+	var deliveries []models.WebhookDelivery
+	if err := r.db.Select(&deliveries, query, limit, lease.Milliseconds()); err != nil {
+		return nil, fmt.Errorf("failed to claim deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) MarkSucceeded(id int64, responseStatus int) error {
+	query := `
+        UPDATE webhook_deliveries
+        SET status = 'succeeded', attempts = attempts + 1, response_status = $2, last_error = NULL,
+            next_attempt_at = NULL, delivered_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1`
+
+	if _, err := r.db.Exec(query, id, responseStatus); err != nil {
+		return fmt.Errorf("failed to mark delivery succeeded: %w", err)
+	}
+	return nil
+}
+
+// MarkFailed записывает неудачную попытку. Если nextAttemptAt равен nil,
+// попытки исчерпаны и доставка переходит в dead-letter статус.
+func (r *webhookRepository) MarkFailed(id int64, responseStatus *int, lastErr string, nextAttemptAt *time.Time) error {
+	status := models.DeliveryPending
+	if nextAttemptAt == nil {
+		status = models.DeliveryDead
+	}
+
+	query := `
+        UPDATE webhook_deliveries
+        SET status = $2, attempts = attempts + 1, response_status = $3, last_error = $4,
+            next_attempt_at = $5, updated_at = CURRENT_TIMESTAMP
+        WHERE id = $1`
+
+	if _, err := r.db.Exec(query, id, status, responseStatus, lastErr, nextAttemptAt); err != nil {
+		return fmt.Errorf("failed to mark delivery failed: %w", err)
+	}
+	return nil
+}
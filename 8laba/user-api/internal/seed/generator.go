@@ -0,0 +1,195 @@
+// Package seed заполняет БД правдоподобными тестовыми пользователями для
+// демонстрации и проверки пагинации. Данные определяются зерном генератора:
+// одно и то же зерно дает тех же пользователей.
+package seed
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"time"
+	"user-api/internal/models"
+)
+
+// ageBucket - доля пользователей с возрастом от min до max включительно
+type ageBucket struct {
+	min, max int
+	weight   float64
+}
+
+// ageBuckets - распределение возрастов, похожее на аудиторию сервиса
+var ageBuckets = []ageBucket{
+	{18, 24, 0.15},
+	{25, 34, 0.30},
+	{35, 44, 0.25},
+	{45, 54, 0.15},
+	{55, 64, 0.10},
+	{65, 80, 0.05},
+}
+
+const (
+	// history - период, за который распределены даты регистрации
+	history = 2 * 365 * 24 * time.Hour
+	// verifiedShare - доля пользователей с подтвержденным email
+	verifiedShare = 0.7
+)
+
+// Generator создает пользователей. Emails уникальны в пределах одного
+// генератора. Не безопасен для одновременного использования.
+type Generator struct {
+	rng          *rand.Rand
+	now          time.Time
+	russianShare float64
+	emails       map[string]struct{}
+}
+
+// NewGenerator создает генератор с зерном seed. russianShare - доля
+// пользователей с русскими именами (от 0 до 1, остальные - английские);
+// даты регистрации отсчитываются назад от now.
+func NewGenerator(seed uint64, russianShare float64, now time.Time) *Generator {
+	return &Generator{
+		rng:          rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15)),
+		now:          now,
+		russianShare: min(max(russianShare, 0), 1),
+		emails:       map[string]struct{}{},
+	}
+}
+
+// User возвращает следующего пользователя
+func (g *Generator) User() models.User {
+	russian := g.rng.Float64() < g.russianShare
+	female := g.rng.IntN(2) == 0
+
+	var first, last string
+	switch {
+	case russian && female:
+		first, last = g.pick(russianFemaleFirstNames), feminineLastName(g.pick(russianLastNames))
+	case russian:
+		first, last = g.pick(russianMaleFirstNames), g.pick(russianLastNames)
+	case female:
+		first, last = g.pick(englishFemaleFirstNames), g.pick(englishLastNames)
+	default:
+		first, last = g.pick(englishMaleFirstNames), g.pick(englishLastNames)
+	}
+
+	createdAt := g.now.Add(-time.Duration(g.rng.Int64N(int64(history)))).Truncate(time.Second)
+	user := models.User{
+		Name:      first + " " + last,
+		Email:     g.email(first, last),
+		Age:       g.age(),
+		Metadata:  g.metadata(russian),
+		CreatedAt: createdAt,
+		UpdatedAt: g.between(createdAt, g.now),
+	}
+	if g.rng.Float64() < verifiedShare {
+		// Email подтверждают обычно в первые дни после регистрации
+		verifiedAt := g.between(createdAt, createdAt.Add(72*time.Hour))
+		if verifiedAt.After(g.now) {
+			verifiedAt = g.now.Truncate(time.Second)
+		}
+		user.EmailVerifiedAt = &verifiedAt
+	}
+	return user
+}
+
+func (g *Generator) pick(values []string) string {
+	return values[g.rng.IntN(len(values))]
+}
+
+// between возвращает случайный момент от from до to
+func (g *Generator) between(from, to time.Time) time.Time {
+	if !to.After(from) {
+		return from
+	}
+	return from.Add(time.Duration(g.rng.Int64N(int64(to.Sub(from))))).Truncate(time.Second)
+}
+
+func (g *Generator) age() int {
+	x := g.rng.Float64()
+	for _, bucket := range ageBuckets {
+		if x < bucket.weight {
+			return bucket.min + g.rng.IntN(bucket.max-bucket.min+1)
+		}
+		x -= bucket.weight
+	}
+	last := ageBuckets[len(ageBuckets)-1]
+	return last.min + g.rng.IntN(last.max-last.min+1)
+}
+
+// email составляет адрес из имени и фамилии на зарезервированном домене;
+// при совпадении с уже выданным добавляется номер
+func (g *Generator) email(first, last string) string {
+	first, last = latin(first), latin(last)
+	var local string
+	switch g.rng.IntN(4) {
+	case 0:
+		local = first + "." + last
+	case 1:
+		local = first[:1] + "." + last
+	case 2:
+		local = first + last
+	default:
+		local = last + "." + first
+	}
+	domain := g.pick(emailDomains)
+
+	email := local + "@" + domain
+	for n := 2; ; n++ {
+		if _, taken := g.emails[email]; !taken {
+			break
+		}
+		email = fmt.Sprintf("%s%d@%s", local, n, domain)
+	}
+	g.emails[email] = struct{}{}
+	return email
+}
+
+// metadata заполняет отдел и город у части пользователей
+func (g *Generator) metadata(russian bool) models.Metadata {
+	metadata := models.Metadata{}
+	if g.rng.Float64() < 0.8 {
+		metadata["department"] = jsonString(g.pick(departments))
+	}
+	if g.rng.Float64() < 0.6 {
+		if russian {
+			metadata["city"] = jsonString(g.pick(russianCities))
+		} else {
+			metadata["city"] = jsonString(g.pick(englishCities))
+		}
+	}
+	return metadata
+}
+
+// feminineLastName возвращает женскую форму русской фамилии
+func feminineLastName(last string) string {
+	switch {
+	case strings.HasSuffix(last, "ий"):
+		return strings.TrimSuffix(last, "ий") + "ая"
+	case strings.HasSuffix(last, "ой"):
+		return strings.TrimSuffix(last, "ой") + "ая"
+	case strings.HasSuffix(last, "ов"), strings.HasSuffix(last, "ев"),
+		strings.HasSuffix(last, "ин"), strings.HasSuffix(last, "ын"):
+		return last + "а"
+	}
+	return last
+}
+
+// latin переводит имя в нижний регистр латиницей и оставляет только буквы
+func latin(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z':
+			b.WriteRune(r)
+		default:
+			b.WriteString(translit[r])
+		}
+	}
+	return b.String()
+}
+
+func jsonString(s string) json.RawMessage {
+	data, _ := json.Marshal(s)
+	return data
+}
@@ -0,0 +1,84 @@
+package seed
+
+// Списки имен для генерации. Русские фамилии даны в мужской форме; женская
+// форма образуется по окончанию (см. feminineLastName).
+var (
+	russianMaleFirstNames = []string{
+		"Александр", "Алексей", "Андрей", "Антон", "Артем", "Борис", "Вадим", "Василий",
+		"Виктор", "Владимир", "Глеб", "Григорий", "Даниил", "Денис", "Дмитрий", "Евгений",
+		"Егор", "Иван", "Игорь", "Илья", "Кирилл", "Константин", "Леонид", "Максим",
+		"Михаил", "Никита", "Николай", "Олег", "Павел", "Роман", "Сергей", "Степан",
+		"Тимофей", "Федор", "Юрий", "Ярослав",
+	}
+	russianFemaleFirstNames = []string{
+		"Алена", "Алина", "Алиса", "Анастасия", "Анна", "Валерия", "Варвара", "Вера",
+		"Виктория", "Галина", "Дарья", "Диана", "Екатерина", "Елена", "Елизавета", "Ирина",
+		"Ксения", "Лариса", "Любовь", "Людмила", "Маргарита", "Марина", "Мария", "Надежда",
+		"Наталья", "Нина", "Ольга", "Полина", "Светлана", "София", "Татьяна", "Ульяна",
+		"Юлия", "Яна",
+	}
+	russianLastNames = []string{
+		"Иванов", "Смирнов", "Кузнецов", "Попов", "Васильев", "Петров", "Соколов", "Михайлов",
+		"Новиков", "Федоров", "Морозов", "Волков", "Алексеев", "Лебедев", "Семенов", "Егоров",
+		"Павлов", "Козлов", "Степанов", "Николаев", "Орлов", "Андреев", "Макаров", "Никитин",
+		"Захаров", "Зайцев", "Соловьев", "Борисов", "Яковлев", "Григорьев", "Романов", "Воробьев",
+		"Сергеев", "Кузьмин", "Фролов", "Александров", "Дмитриев", "Королев", "Гусев", "Киселев",
+		"Ильин", "Максимов", "Поляков", "Сорокин", "Виноградов", "Ковалев", "Белов", "Медведев",
+		"Антонов", "Тарасов", "Жуков", "Баранов", "Филиппов", "Комаров", "Давыдов", "Беляев",
+		"Герасимов", "Богданов", "Осипов", "Сидоров", "Матвеев", "Титов", "Марков", "Миронов",
+		"Крылов", "Куликов", "Карпов", "Власов", "Мельников", "Денисов", "Гаврилов", "Тихонов",
+		"Казаков", "Афанасьев", "Данилов", "Савельев", "Тимофеев", "Фомин", "Чернов", "Абрамов",
+		"Мартынов", "Ефимов", "Федотов", "Щербаков", "Назаров", "Калинин", "Исаев", "Чернышев",
+		"Быков", "Маслов", "Родионов", "Коновалов", "Лазарев", "Воронин", "Климов", "Филатов",
+		"Пономарев", "Голубев", "Кудрявцев", "Прохоров", "Наумов", "Потапов", "Журавлев", "Овчинников",
+		"Трофимов", "Леонов", "Соболев", "Ермаков", "Колесников", "Гончаров", "Емельянов", "Никифоров",
+		"Грачев", "Котов", "Гришин", "Ефремов", "Архипов", "Громов", "Кириллов", "Малышев",
+		"Панов", "Моисеев", "Румянцев", "Акимов", "Кондратьев", "Бирюков", "Горбунов", "Анисимов",
+		"Еремин", "Тихомиров", "Галкин", "Лукьянов", "Михеев", "Скворцов", "Юдин", "Белоусов",
+		"Нестеров", "Симонов", "Прокофьев", "Харитонов", "Князев", "Цветков", "Левин", "Митрофанов",
+		"Воронцов", "Шубин", "Мишин", "Лавров", "Суханов", "Блинов", "Рябов", "Жданов",
+		"Смирницкий", "Вяземский", "Толстой", "Трубецкой",
+	}
+
+	englishMaleFirstNames = []string{
+		"James", "John", "Robert", "Michael", "William", "David", "Richard", "Joseph",
+		"Thomas", "Charles", "Daniel", "Matthew", "Anthony", "Mark", "Steven", "Paul",
+		"Andrew", "Joshua", "Kevin", "Brian", "George", "Edward", "Ryan", "Jacob",
+		"Nicholas", "Jonathan", "Samuel", "Benjamin", "Oliver", "Henry", "Jack", "Harry",
+	}
+	englishFemaleFirstNames = []string{
+		"Mary", "Patricia", "Jennifer", "Linda", "Elizabeth", "Barbara", "Susan", "Jessica",
+		"Sarah", "Karen", "Emily", "Nancy", "Lisa", "Betty", "Margaret", "Sandra",
+		"Ashley", "Emma", "Olivia", "Sophia", "Amelia", "Charlotte", "Grace", "Chloe",
+		"Hannah", "Rachel", "Laura", "Rebecca", "Victoria", "Megan", "Lucy", "Alice",
+	}
+	englishLastNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Miller", "Davis", "Wilson",
+		"Anderson", "Taylor", "Thomas", "Moore", "Jackson", "Martin", "Lee", "Thompson",
+		"White", "Harris", "Clark", "Lewis", "Robinson", "Walker", "Young", "Allen",
+		"King", "Wright", "Scott", "Hill", "Green", "Adams", "Baker", "Nelson",
+		"Carter", "Mitchell", "Roberts", "Turner", "Phillips", "Campbell", "Parker", "Evans",
+		"Edwards", "Collins", "Stewart", "Morris", "Murphy", "Cook", "Rogers", "Morgan",
+		"Cooper", "Peterson", "Reed", "Bailey", "Bell", "Kelly", "Howard", "Ward",
+		"Cox", "Richardson", "Wood", "Watson", "Brooks", "Bennett", "Gray", "Hughes",
+		"Price", "Sanders", "Myers", "Long", "Ross", "Foster", "O'Brien", "Fisher",
+	}
+
+	// Значения metadata.department и metadata.city
+	departments   = []string{"sales", "marketing", "engineering", "support", "finance", "hr", "legal", "operations"}
+	russianCities = []string{"Москва", "Санкт-Петербург", "Новосибирск", "Екатеринбург", "Казань", "Нижний Новгород", "Самара", "Краснодар"}
+	englishCities = []string{"London", "New York", "Manchester", "Boston", "Toronto", "Sydney", "Dublin", "Chicago"}
+
+	// emailDomains - зарезервированные домены (RFC 2606): письма на них
+	// никуда не уходят
+	emailDomains = []string{"example.com", "example.org", "example.net", "mail.example", "corp.example"}
+)
+
+// translit - транслитерация кириллицы для email
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
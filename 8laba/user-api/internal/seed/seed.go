@@ -0,0 +1,103 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+)
+
+// DefaultBatchSize - число пользователей в одном запросе INSERT
+const DefaultBatchSize = 1000
+
+// Config - параметры заполнения
+type Config struct {
+	// Count - сколько пользователей создать
+	Count int
+	// Seed - зерно генератора
+	Seed uint64
+	// BatchSize - пользователей в одном запросе; 0 - DefaultBatchSize
+	BatchSize int
+	// RussianShare - доля пользователей с русскими именами
+	RussianShare float64
+	// Wipe удаляет всех пользователей арендатора перед заполнением
+	Wipe bool
+	// Now - момент, от которого отсчитываются даты регистрации; нулевое
+	// значение - текущее время
+	Now time.Time
+}
+
+// Result - итог заполнения
+type Result struct {
+	Deleted int `json:"deleted"`
+	Created int `json:"created"`
+	// Skipped - пользователи, чей email уже занят в БД
+	Skipped int `json:"skipped"`
+}
+
+// Seeder заполняет БД через репозиторий пользователей
+type Seeder struct {
+	repo     repository.UserRepository
+	metadata service.MetadataValidator
+}
+
+// New создает Seeder. metadata проверяет сгенерированные поля по
+// зарегистрированным схемам; nil отключает проверку.
+func New(repo repository.UserRepository, metadata service.MetadataValidator) *Seeder {
+	return &Seeder{repo: repo, metadata: metadata}
+}
+
+// Run создает cfg.Count пользователей арендатора из ctx пачками по
+// cfg.BatchSize. progress, если не nil, вызывается после каждой пачки с
+// числом обработанных пользователей. Итог возвращается и при ошибке - по
+// уже вставленным пачкам.
+func (s *Seeder) Run(ctx context.Context, cfg Config, progress func(done int)) (*Result, error) {
+	result := &Result{}
+	if cfg.Count < 0 {
+		return result, errors.New("count must not be negative")
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultBatchSize
+	}
+	if cfg.Now.IsZero() {
+		cfg.Now = time.Now()
+	}
+
+	if cfg.Wipe {
+		deleted, err := s.repo.DeleteAll(ctx)
+		if err != nil {
+			return result, err
+		}
+		result.Deleted = deleted
+	}
+
+	generator := NewGenerator(cfg.Seed, cfg.RussianShare, cfg.Now)
+	batch := make([]models.User, 0, min(cfg.BatchSize, cfg.Count))
+	for done := 0; done < cfg.Count; {
+		batch = batch[:0]
+		for len(batch) < cfg.BatchSize && done+len(batch) < cfg.Count {
+			user := generator.User()
+			if s.metadata != nil {
+				if err := s.metadata.ValidateMetadata(user.Metadata); err != nil {
+					return result, fmt.Errorf("generated metadata of %s: %w", user.Email, err)
+				}
+			}
+			batch = append(batch, user)
+		}
+
+		created, err := s.repo.CreateBatch(ctx, batch)
+		if err != nil {
+			return result, err
+		}
+		result.Created += created
+		result.Skipped += len(batch) - created
+		done += len(batch)
+		if progress != nil {
+			progress(done)
+		}
+	}
+	return result, nil
+}
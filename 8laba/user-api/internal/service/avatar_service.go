@@ -0,0 +1,122 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"user-api/internal/avatar"
+	"user-api/internal/blob"
+	"user-api/internal/events"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// AvatarService интерфейс управления аватарами пользователей
+type AvatarService interface {
+	// SetAvatar обрабатывает изображение и заменяет им аватар пользователя
+	SetAvatar(ctx context.Context, id int, image []byte) (*models.User, error)
+	// DeleteAvatar удаляет аватар пользователя
+	DeleteAvatar(ctx context.Context, id int) (*models.User, error)
+}
+
+// UserInvalidator сбрасывает сохраненные копии пользователя (кэш)
+type UserInvalidator interface {
+	Invalidate(id int)
+}
+
+// AvatarStore сохраняет аватары в blob.Store. Каждая загрузка получает
+// новый ключ, поэтому адреса файлов можно кэшировать бессрочно.
+type AvatarStore struct {
+	repo        repository.UserRepository
+	store       blob.Store
+	invalidator UserInvalidator
+}
+
+// NewAvatarStore создает AvatarStore. invalidator может быть nil.
+func NewAvatarStore(repo repository.UserRepository, store blob.Store, invalidator UserInvalidator) *AvatarStore {
+	return &AvatarStore{repo: repo, store: store, invalidator: invalidator}
+}
+
+func (s *AvatarStore) SetAvatar(ctx context.Context, id int, image []byte) (*models.User, error) {
+	images, err := avatar.Process(image)
+	if err != nil {
+		return nil, err
+	}
+
+	key := fmt.Sprintf("avatars/%d/%s", id, newAvatarVersion())
+	urls := make(models.AvatarURLs, len(images))
+	for _, size := range avatar.Sizes {
+		data := images[size.Name]
+		if err := s.store.Put(ctx, fileKey(key, size.Name), bytes.NewReader(data), int64(len(data)), avatar.ContentType); err != nil {
+			s.deleteFiles(key)
+			return nil, err
+		}
+		urls[size.Name] = s.store.URL(fileKey(key, size.Name))
+	}
+
+	user, previous, err := s.repo.SetAvatar(ctx, id, &key, urls)
+	if err != nil {
+		s.deleteFiles(key)
+		return nil, err
+	}
+	s.invalidate(id)
+	if previous != nil {
+		s.deleteFiles(*previous)
+	}
+	return user, nil
+}
+
+func (s *AvatarStore) DeleteAvatar(ctx context.Context, id int) (*models.User, error) {
+	user, previous, err := s.repo.SetAvatar(ctx, id, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(id)
+	if previous != nil {
+		s.deleteFiles(*previous)
+	}
+	return user, nil
+}
+
+// Publish удаляет файлы аватара удаленного пользователя
+// (events.EventPublisher для relay outbox). После ошибки relay повторит
+// событие; повторное удаление ничего не ломает.
+func (s *AvatarStore) Publish(ctx context.Context, event events.Event) error {
+	if event.Type != events.UserDeleted {
+		return nil
+	}
+	prefix := fmt.Sprintf("avatars/%d/", event.UserID)
+	if err := s.store.DeletePrefix(ctx, prefix); err != nil {
+		return fmt.Errorf("failed to delete avatar files of user %d: %w", event.UserID, err)
+	}
+	return nil
+}
+
+// deleteFiles удаляет файлы аватара key. Ошибка только логируется:
+// оставшиеся файлы ни на что не влияют.
+func (s *AvatarStore) deleteFiles(key string) {
+	for _, size := range avatar.Sizes {
+		if err := s.store.Delete(context.Background(), fileKey(key, size.Name)); err != nil {
+			log.Printf("Avatars: failed to delete %s: %v", fileKey(key, size.Name), err)
+		}
+	}
+}
+
+func (s *AvatarStore) invalidate(id int) {
+	if s.invalidator != nil {
+		s.invalidator.Invalidate(id)
+	}
+}
+
+func fileKey(key, size string) string {
+	return key + "_" + size + ".jpg"
+}
+
+func newAvatarVersion() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"user-api/internal/cache"
+	"user-api/internal/database"
+	"user-api/internal/models"
+	"user-api/internal/tenant"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// CacheStats - счетчики обращений к кэшу пользователей
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+}
+
+// CachedUserService - декоратор UserService, который кэширует GetUser.
+// Одновременные промахи по одному ID объединяются в один запрос к next.
+// Обновление и удаление пользователя сбрасывают запись в кэше. ID
+// пользователей уникальны среди всех арендаторов, поэтому ключ кэша - ID,
+// а запись отдается только запросам арендатора пользователя.
+type CachedUserService struct {
+	next  UserService
+	store cache.Store
+	group singleflight.Group
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	// epoch увеличивается при каждом сбросе. Загрузка, начатая до сброса,
+	// не записывает в кэш возможно устаревшее значение.
+	epoch atomic.Uint64
+}
+
+// NewCachedUserService оборачивает next кэшем store
+func NewCachedUserService(next UserService, store cache.Store) *CachedUserService {
+	return &CachedUserService{next: next, store: store}
+}
+
+func (s *CachedUserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	return s.next.CreateUser(ctx, req)
+}
+
+func (s *CachedUserService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	if user, ok := s.store.Get(id); ok && visible(ctx, user) {
+		s.hits.Add(1)
+		return user, nil
+	}
+	s.misses.Add(1)
+
+	// Загрузку делят только запросы одного арендатора. Эпоха в ключе не
+	// дает запросу, начатому после сброса, получить результат загрузки,
+	// начатой до него.
+	epoch := s.epoch.Load()
+	key := fmt.Sprintf("%s/%d@%d", scope(ctx), id, epoch)
+	v, err, _ := s.group.Do(key, func() (interface{}, error) {
+		// Запись в кэш читается из основной БД: отстающая реплика вернула
+		// бы пользователя до изменения, только что сбросившего кэш
+		user, err := s.next.GetUser(database.WithPrimary(ctx), id)
+		if err != nil {
+			return nil, err
+		}
+		if s.epoch.Load() == epoch {
+			s.store.Set(id, user)
+		}
+		return user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Каждый вызывающий получает свою копию
+	user := *v.(*models.User)
+	return &user, nil
+}
+
+func (s *CachedUserService) GetUsers(ctx context.Context, page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
+	return s.next.GetUsers(ctx, page, pageSize, filters)
+}
+
+func (s *CachedUserService) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	user, err := s.next.UpdateUser(ctx, id, req)
+	s.Invalidate(id)
+	return user, err
+}
+
+func (s *CachedUserService) DeleteUser(ctx context.Context, id int) error {
+	err := s.next.DeleteUser(ctx, id)
+	s.Invalidate(id)
+	return err
+}
+
+func (s *CachedUserService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	user, err := s.next.VerifyEmail(ctx, token)
+	if user != nil {
+		s.Invalidate(user.ID)
+	}
+	return user, err
+}
+
+func (s *CachedUserService) ResendVerification(ctx context.Context, id int) error {
+	return s.next.ResendVerification(ctx, id)
+}
+
+func (s *CachedUserService) GetUserStats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error) {
+	return s.next.GetUserStats(ctx, filters, opts)
+}
+
+// Stats возвращает число попаданий и промахов
+func (s *CachedUserService) Stats() CacheStats {
+	return CacheStats{Hits: s.hits.Load(), Misses: s.misses.Load()}
+}
+
+// Invalidate сбрасывает запись даже после ошибки: изменение могло
+// примениться, а ответ - потеряться
+func (s *CachedUserService) Invalidate(id int) {
+	s.epoch.Add(1)
+	s.store.Delete(id)
+}
+
+// visible сообщает, можно ли отдать запись из кэша запросу ctx
+func visible(ctx context.Context, user *models.User) bool {
+	if tenant.IsSystem(ctx) {
+		return true
+	}
+	id, ok := tenant.FromContext(ctx)
+	return ok && id == user.TenantID
+}
+
+// scope возвращает арендатора запроса для ключа объединения загрузок
+func scope(ctx context.Context) string {
+	if tenant.IsSystem(ctx) {
+		return "system"
+	}
+	if id, ok := tenant.FromContext(ctx); ok {
+		return fmt.Sprint(id)
+	}
+	return "none"
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+	"user-api/internal/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// maxStatsEntries - наибольшее число запомненных результатов статистики;
+// при переполнении кэш очищается
+const maxStatsEntries = 1000
+
+// CachedUserStats - декоратор UserService, который запоминает результат
+// GetUserStats на ttl для каждого арендатора и набора параметров.
+// Статистика считается агрегатами по всем пользователям, поэтому изменения
+// пользователей кэш не сбрасывают: данные отстают не больше чем на ttl.
+// Возвращаемая статистика общая для вызывающих и не должна изменяться.
+type CachedUserStats struct {
+	UserService
+	ttl   time.Duration
+	group singleflight.Group
+
+	mu      sync.Mutex
+	entries map[string]statsEntry
+}
+
+type statsEntry struct {
+	stats     *models.UserStats
+	expiresAt time.Time
+}
+
+// NewCachedUserStats оборачивает next кэшем статистики со временем жизни ttl
+func NewCachedUserStats(next UserService, ttl time.Duration) *CachedUserStats {
+	return &CachedUserStats{UserService: next, ttl: ttl, entries: make(map[string]statsEntry)}
+}
+
+func (s *CachedUserStats) GetUserStats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error) {
+	// Карты кодируются в JSON с сортировкой ключей, поэтому одинаковые
+	// параметры дают одинаковый ключ
+	params, err := json.Marshal(struct {
+		Filters map[string]interface{}
+		Options models.UserStatsOptions
+	}{filters, opts})
+	if err != nil {
+		return s.UserService.GetUserStats(ctx, filters, opts)
+	}
+	key := scope(ctx) + "/" + string(params)
+
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.stats, nil
+	}
+
+	// Расчет общий для всех ждущих вызывающих, поэтому не отменяется
+	// вместе с контекстом первого из них; каждый вызывающий перестает
+	// ждать при отмене своего контекста
+	shared := context.WithoutCancel(ctx)
+	result := s.group.DoChan(key, func() (interface{}, error) {
+		stats, err := s.UserService.GetUserStats(shared, filters, opts)
+		if err != nil {
+			return nil, err
+		}
+		s.remember(key, stats)
+		return stats, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-result:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.(*models.UserStats), nil
+	}
+}
+
+func (s *CachedUserStats) remember(key string, stats *models.UserStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if len(s.entries) >= maxStatsEntries {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		if len(s.entries) >= maxStatsEntries {
+			clear(s.entries)
+		}
+	}
+	s.entries[key] = statsEntry{stats: stats, expiresAt: now.Add(s.ttl)}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+	"time"
+	"user-api/internal/mailer"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/verification"
+)
+
+// EmailVerifierConfig содержит настройки подтверждения email
+type EmailVerifierConfig struct {
+	// TokenTTL - срок действия ссылки из письма
+	TokenTTL time.Duration
+	// VerifyURL - адрес подтверждения; токен добавляется параметром token
+	VerifyURL string
+	// SendTimeout ограничивает отправку одного письма
+	SendTimeout time.Duration
+}
+
+// EmailVerifier выдает одноразовые подписанные токены подтверждения email,
+// отправляет их по почте и проверяет
+type EmailVerifier struct {
+	repo   repository.VerificationRepository
+	signer *verification.Signer
+	mailer mailer.Mailer
+	cfg    EmailVerifierConfig
+	// wg отслеживает отправку писем, идущую после ответа клиенту
+	wg sync.WaitGroup
+}
+
+// NewEmailVerifier создает EmailVerifier
+func NewEmailVerifier(repo repository.VerificationRepository, signer *verification.Signer, m mailer.Mailer, cfg EmailVerifierConfig) *EmailVerifier {
+	return &EmailVerifier{repo: repo, signer: signer, mailer: m, cfg: cfg}
+}
+
+// Send выдает пользователю новый токен для его текущего адреса и отправляет
+// письмо со ссылкой. Ранее выданные токены остаются действительными до
+// истечения срока.
+func (v *EmailVerifier) Send(user *models.User) error {
+	token := &models.VerificationToken{
+		ID:        verification.NewID(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(v.cfg.TokenTTL).Truncate(time.Second),
+	}
+	if err := v.repo.CreateToken(token); err != nil {
+		return err
+	}
+
+	link, err := v.link(v.signer.Sign(verification.Claims{
+		ID:        token.ID,
+		UserID:    token.UserID,
+		ExpiresAt: token.ExpiresAt,
+	}))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), v.cfg.SendTimeout)
+	defer cancel()
+
+	return v.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hello, %s!\n\n"+
+			"Please confirm your email address by opening the link below:\n\n%s\n\n"+
+			"The link is valid until %s and can be used once.\n"+
+			"If you did not create an account, ignore this message.\n",
+			user.Name, link, token.ExpiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+// SendAsync отправляет письмо как Send, но после ответа клиенту: медленный
+// почтовый сервер не задерживает создание и изменение пользователя.
+// Ошибка отправки записывается в лог, письмо можно запросить повторно.
+func (v *EmailVerifier) SendAsync(user *models.User) {
+	recipient := *user
+	v.wg.Add(1)
+	go func() {
+		defer v.wg.Done()
+		if err := v.Send(&recipient); err != nil {
+			log.Printf("Email verification: failed to send message to user %d: %v", recipient.ID, err)
+		}
+	}()
+}
+
+// Wait ждет завершения отправки писем, начатой SendAsync
+func (v *EmailVerifier) Wait() {
+	v.wg.Wait()
+}
+
+// Verify проверяет токен и отмечает email пользователя подтвержденным
+func (v *EmailVerifier) Verify(token string) (*models.User, error) {
+	claims, err := v.signer.Parse(token, time.Now())
+	if errors.Is(err, verification.ErrExpired) {
+		return nil, models.ErrTokenExpired
+	}
+	if err != nil {
+		return nil, models.ErrInvalidToken
+	}
+	return v.repo.UseToken(claims.ID, claims.UserID)
+}
+
+// RunCleanup периодически удаляет истекшие и использованные токены до
+// отмены ctx
+func (v *EmailVerifier) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := v.repo.DeleteExpired(); err != nil {
+				log.Printf("Email verification: %v", err)
+			}
+		}
+	}
+}
+
+func (v *EmailVerifier) link(token string) (string, error) {
+	u, err := url.Parse(v.cfg.VerifyURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid verification URL: %w", err)
+	}
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"context"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// GroupService интерфейс управления группами и участниками
+type GroupService interface {
+	CreateGroup(ctx context.Context, req *models.CreateGroupRequest) (*models.Group, error)
+	GetGroup(ctx context.Context, id int) (*models.Group, error)
+	GetGroups(ctx context.Context, page, pageSize int) (*models.GroupListResponse, error)
+	UpdateGroup(ctx context.Context, id int, req *models.UpdateGroupRequest) (*models.Group, error)
+	DeleteGroup(ctx context.Context, id int) error
+
+	AddMember(ctx context.Context, groupID int, req *models.AddGroupMemberRequest) (*models.GroupMember, error)
+	GetMembers(ctx context.Context, groupID, page, pageSize int) (*models.GroupMemberListResponse, error)
+	UpdateMember(ctx context.Context, groupID, userID int, req *models.UpdateGroupMemberRequest) (*models.GroupMember, error)
+	RemoveMember(ctx context.Context, groupID, userID int) error
+}
+
+type groupService struct {
+	repo repository.GroupRepository
+}
+
+// NewGroupService создает новый сервис групп
+func NewGroupService(repo repository.GroupRepository) GroupService {
+	return &groupService{repo: repo}
+}
+
+func (s *groupService) CreateGroup(ctx context.Context, req *models.CreateGroupRequest) (*models.Group, error) {
+	return s.repo.Create(ctx, req)
+}
+
+func (s *groupService) GetGroup(ctx context.Context, id int) (*models.Group, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *groupService) GetGroups(ctx context.Context, page, pageSize int) (*models.GroupListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	groups, total, err := s.repo.GetAll(ctx, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GroupListResponse{
+		Groups:     groups,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+func (s *groupService) UpdateGroup(ctx context.Context, id int, req *models.UpdateGroupRequest) (*models.Group, error) {
+	return s.repo.Update(ctx, id, req)
+}
+
+func (s *groupService) DeleteGroup(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *groupService) AddMember(ctx context.Context, groupID int, req *models.AddGroupMemberRequest) (*models.GroupMember, error) {
+	role := req.Role
+	if role == "" {
+		role = models.GroupRoleMember
+	}
+	return s.repo.AddMember(ctx, groupID, req.UserID, role)
+}
+
+func (s *groupService) GetMembers(ctx context.Context, groupID, page, pageSize int) (*models.GroupMemberListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	// Пустой список и несуществующая группа должны различаться
+	if _, err := s.repo.GetByID(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	members, total, err := s.repo.GetMembers(ctx, groupID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.GroupMemberListResponse{
+		Members:    members,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+func (s *groupService) UpdateMember(ctx context.Context, groupID, userID int, req *models.UpdateGroupMemberRequest) (*models.GroupMember, error) {
+	return s.repo.UpdateMember(ctx, groupID, userID, req.Role)
+}
+
+func (s *groupService) RemoveMember(ctx context.Context, groupID, userID int) error {
+	return s.repo.RemoveMember(ctx, groupID, userID)
+}
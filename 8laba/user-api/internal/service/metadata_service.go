@@ -0,0 +1,211 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Ограничения metadata одного запроса
+const (
+	MaxMetadataKeys = 50
+	MaxMetadataSize = 16 << 10
+)
+
+// metadataKeyPattern - допустимые ключи metadata: они же используются в
+// параметрах фильтра metadata.<ключ>
+var metadataKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ValidMetadataKey проверяет имя ключа metadata
+func ValidMetadataKey(key string) bool {
+	return metadataKeyPattern.MatchString(key)
+}
+
+// MetadataValidator проверяет metadata перед сохранением пользователя
+type MetadataValidator interface {
+	ValidateMetadata(metadata models.Metadata) error
+}
+
+// MetadataService интерфейс управления схемами ключей metadata
+type MetadataService interface {
+	MetadataValidator
+	GetSchemas() ([]models.MetadataSchema, error)
+	GetSchema(key string) (*models.MetadataSchema, error)
+	PutSchema(key string, req *models.PutMetadataSchemaRequest) (*models.MetadataSchema, error)
+	DeleteSchema(key string) error
+}
+
+type compiledSchema struct {
+	updatedAt time.Time
+	schema    *jsonschema.Schema
+}
+
+type metadataService struct {
+	repo repository.MetadataSchemaRepository
+
+	// compiled - скомпилированные схемы; схема перекомпилируется, когда
+	// меняется updated_at в БД (в том числе из другого экземпляра API)
+	mu       sync.Mutex
+	compiled map[string]compiledSchema
+}
+
+// NewMetadataService создает новый сервис схем metadata
+func NewMetadataService(repo repository.MetadataSchemaRepository) MetadataService {
+	return &metadataService{repo: repo, compiled: map[string]compiledSchema{}}
+}
+
+func (s *metadataService) GetSchemas() ([]models.MetadataSchema, error) {
+	return s.repo.GetAll()
+}
+
+func (s *metadataService) GetSchema(key string) (*models.MetadataSchema, error) {
+	if !ValidMetadataKey(key) {
+		return nil, models.ErrMetadataSchemaNotFound
+	}
+	return s.repo.Get(key)
+}
+
+// PutSchema регистрирует или заменяет схему ключа. Уже сохраненные
+// значения по новой схеме не проверяются.
+func (s *metadataService) PutSchema(key string, req *models.PutMetadataSchemaRequest) (*models.MetadataSchema, error) {
+	if !ValidMetadataKey(key) {
+		return nil, fmt.Errorf("%w: key must match %s", models.ErrInvalidMetadataSchema, metadataKeyPattern)
+	}
+	if _, err := compileSchema(key, req.Schema); err != nil {
+		return nil, fmt.Errorf("%w: %v", models.ErrInvalidMetadataSchema, err)
+	}
+	return s.repo.Put(&models.MetadataSchema{
+		Key:         key,
+		Schema:      req.Schema,
+		Description: req.Description,
+	})
+}
+
+func (s *metadataService) DeleteSchema(key string) error {
+	if !ValidMetadataKey(key) {
+		return models.ErrMetadataSchemaNotFound
+	}
+	if err := s.repo.Delete(key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	delete(s.compiled, key)
+	s.mu.Unlock()
+	return nil
+}
+
+// ValidateMetadata проверяет ключи, размер и значения ключей, для которых
+// зарегистрирована схема. Значения null (удаление ключа) не проверяются.
+func (s *metadataService) ValidateMetadata(metadata models.Metadata) error {
+	if len(metadata) == 0 {
+		return nil
+	}
+	if len(metadata) > MaxMetadataKeys {
+		return fmt.Errorf("%w: at most %d keys are allowed", models.ErrInvalidMetadata, MaxMetadataKeys)
+	}
+
+	size := 0
+	keys := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		if !ValidMetadataKey(key) {
+			return fmt.Errorf("%w: key %q must match %s", models.ErrInvalidMetadata, key, metadataKeyPattern)
+		}
+		size += len(key) + len(value)
+		if !models.IsNull(value) {
+			keys = append(keys, key)
+		}
+	}
+	if size > MaxMetadataSize {
+		return fmt.Errorf("%w: metadata must not exceed %d bytes", models.ErrInvalidMetadata, MaxMetadataSize)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	schemas, err := s.repo.GetByKeys(keys)
+	if err != nil {
+		return err
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Key < schemas[j].Key })
+
+	for _, schema := range schemas {
+		compiled, err := s.compiledSchema(schema)
+		if err != nil {
+			return err
+		}
+		value, err := jsonschema.UnmarshalJSON(bytes.NewReader(metadata[schema.Key]))
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", models.ErrInvalidMetadata, schema.Key, err)
+		}
+		if err := compiled.Validate(value); err != nil {
+			return fmt.Errorf("%w: %s: %s", models.ErrInvalidMetadata, schema.Key, validationMessage(err))
+		}
+	}
+	return nil
+}
+
+func (s *metadataService) compiledSchema(schema models.MetadataSchema) (*jsonschema.Schema, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.compiled[schema.Key]; ok && c.updatedAt.Equal(schema.UpdatedAt) {
+		return c.schema, nil
+	}
+	compiled, err := compileSchema(schema.Key, schema.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile metadata schema %q: %w", schema.Key, err)
+	}
+	s.compiled[schema.Key] = compiledSchema{updatedAt: schema.UpdatedAt, schema: compiled}
+	return compiled, nil
+}
+
+// compileSchema компилирует JSON Schema. Внешние $ref (файлы, URL) не
+// загружаются: схема должна быть самодостаточной.
+func compileSchema(key string, raw []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	url := "urn:metadata:" + key
+	compiler := jsonschema.NewCompiler()
+	compiler.DefaultDraft(jsonschema.Draft2020)
+	compiler.UseLoader(jsonschema.SchemeURLLoader{})
+	if err := compiler.AddResource(url, doc); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(url)
+}
+
+// validationMessage сворачивает ошибки jsonschema в одну строку
+func validationMessage(err error) string {
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return err.Error()
+	}
+
+	var messages []string
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		message := unit.Error.String()
+		if unit.InstanceLocation != "" {
+			message = unit.InstanceLocation + ": " + message
+		}
+		messages = append(messages, message)
+	}
+	if len(messages) == 0 {
+		return err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"user-api/internal/mailer"
+	"user-api/internal/models"
+	"user-api/internal/ratelimit"
+	"user-api/internal/repository"
+	"user-api/internal/tenant"
+	"user-api/internal/validation"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PasswordService интерфейс восстановления пароля
+type PasswordService interface {
+	// ForgotPassword отправляет письмо со ссылкой сброса, если пользователь
+	// с таким email существует. Результат не зависит от того, существует ли
+	// пользователь; ошибкой может быть только превышение лимита запросов.
+	// Забывший пароль не может назвать арендатора, поэтому письмо получает
+	// пользователь с этим адресом у каждого арендатора: ссылка сбрасывает
+	// пароль только своей учетной записи.
+	ForgotPassword(ctx context.Context, email, ip string) error
+	// ResetPassword устанавливает новый пароль по токену из письма
+	ResetPassword(token, password, ip string) error
+}
+
+// PasswordResetConfig содержит настройки сброса пароля
+type PasswordResetConfig struct {
+	// TokenTTL - срок действия ссылки из письма
+	TokenTTL time.Duration
+	// ResetURL - страница сброса пароля; токен добавляется параметром token
+	ResetURL string
+	// SendTimeout ограничивает отправку одного письма
+	SendTimeout time.Duration
+	// EmailLimit и IPLimit - сколько запросов разрешено за LimitWindow
+	// на один email и на один IP
+	EmailLimit  int
+	IPLimit     int
+	LimitWindow time.Duration
+}
+
+// PasswordResetService выдает одноразовые токены сброса пароля. В БД
+// хранится только SHA-256 токена, сам токен есть лишь в письме.
+type PasswordResetService struct {
+	repo         repository.PasswordRepository
+	mailer       mailer.Mailer
+	cfg          PasswordResetConfig
+	emailLimiter *ratelimit.Limiter
+	ipLimiter    *ratelimit.Limiter
+	// wg отслеживает отправку писем, идущую после ответа клиенту
+	wg sync.WaitGroup
+}
+
+// NewPasswordResetService создает PasswordResetService
+func NewPasswordResetService(repo repository.PasswordRepository, m mailer.Mailer, cfg PasswordResetConfig) *PasswordResetService {
+	return &PasswordResetService{
+		repo:         repo,
+		mailer:       m,
+		cfg:          cfg,
+		emailLimiter: ratelimit.New(cfg.EmailLimit, cfg.LimitWindow),
+		ipLimiter:    ratelimit.New(cfg.IPLimit, cfg.LimitWindow),
+	}
+}
+
+func (s *PasswordResetService) ForgotPassword(ctx context.Context, email, ip string) error {
+	if err := s.allow(ip); err != nil {
+		return err
+	}
+	// Адрес ищется в том виде, в котором хранится; некорректный адрес
+	// обрабатывается как несуществующий
+	if normalized, err := validation.NormalizeEmail(email); err == nil {
+		email = normalized
+	}
+	// Лимит по email считается и для несуществующих адресов, иначе по
+	// ответу 429 можно было бы узнать, что адрес зарегистрирован
+	if ok, retryAfter := s.emailLimiter.Allow(strings.ToLower(strings.TrimSpace(email))); !ok {
+		return &models.RateLimitError{RetryAfter: retryAfter}
+	}
+
+	// Поиск пользователя и отправка письма идут после ответа, чтобы время
+	// ответа не выдавало существование адреса. Контекст запроса к этому
+	// времени отменен.
+	ctx = context.WithoutCancel(ctx)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.sendReset(ctx, email); err != nil {
+			log.Printf("Password reset: %v", err)
+		}
+	}()
+	return nil
+}
+
+func (s *PasswordResetService) ResetPassword(token, password, ip string) error {
+	if err := s.allow(ip); err != nil {
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	_, err = s.repo.ResetPassword(hashToken(token), string(hash))
+	return err
+}
+
+// Wait ждет завершения отправки писем, начатой ForgotPassword
+func (s *PasswordResetService) Wait() {
+	s.wg.Wait()
+}
+
+// RunCleanup периодически удаляет истекшие и использованные токены до
+// отмены ctx
+func (s *PasswordResetService) RunCleanup(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.repo.DeleteExpired(); err != nil {
+				log.Printf("Password reset: %v", err)
+			}
+		}
+	}
+}
+
+func (s *PasswordResetService) allow(ip string) error {
+	if ok, retryAfter := s.ipLimiter.Allow(ip); !ok {
+		return &models.RateLimitError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// sendReset отправляет письмо сброса каждому пользователю с email
+func (s *PasswordResetService) sendReset(ctx context.Context, email string) error {
+	users, err := s.repo.FindUsersByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for i := range users {
+		errs = append(errs, s.sendResetTo(&users[i]))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *PasswordResetService) sendResetTo(user *models.User) error {
+	token := newResetToken()
+	expiresAt := time.Now().Add(s.cfg.TokenTTL)
+	if err := s.repo.CreateResetToken(&models.PasswordResetToken{
+		TokenHash: hashToken(token),
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	}); err != nil {
+		return err
+	}
+
+	link, err := url.Parse(s.cfg.ResetURL)
+	if err != nil {
+		return fmt.Errorf("invalid reset URL: %w", err)
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.SendTimeout)
+	defer cancel()
+
+	return s.mailer.Send(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hello, %s!\n\n"+
+			"Someone requested a password reset for your account. To set a new password, open the link below:\n\n%s\n\n"+
+			"The link is valid until %s and can be used once.\n"+
+			"If you did not request a reset, ignore this message: your password stays the same.\n",
+			user.Name, link, expiresAt.UTC().Format(time.RFC1123)),
+	})
+}
+
+// newResetToken возвращает случайный токен (256 бит) в base64url
+func newResetToken() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// hashToken возвращает SHA-256 токена. Токен случайный и длинный, поэтому
+// медленный хэш (как для паролей) не нужен.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// TokenRevocations - tenant.Revocations по users.password_changed_at:
+// сброс пароля отзывает access токены пользователя, выданные до него.
+// Subject токена - ID пользователя; токены с другим subject не
+// отзываются.
+type TokenRevocations struct {
+	repo repository.PasswordRepository
+}
+
+// NewTokenRevocations создает проверку отзыва токенов
+func NewTokenRevocations(repo repository.PasswordRepository) *TokenRevocations {
+	return &TokenRevocations{repo: repo}
+}
+
+func (r *TokenRevocations) TokensRevokedAt(ctx context.Context, tenantID int, subject string) (time.Time, error) {
+	userID, err := strconv.Atoi(subject)
+	if err != nil || userID <= 0 {
+		return time.Time{}, nil
+	}
+	changedAt, err := r.repo.PasswordChangedAt(tenant.WithID(ctx, tenantID), userID)
+	if err != nil || changedAt == nil {
+		return time.Time{}, err
+	}
+	return *changedAt, nil
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// validTenantSlug - допустимое короткое имя арендатора
+var validTenantSlug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+// TenantService интерфейс управления арендаторами
+type TenantService interface {
+	CreateTenant(req *models.CreateTenantRequest) (*models.Tenant, error)
+	GetTenant(id int) (*models.Tenant, error)
+	GetTenants(page, pageSize int) (*models.TenantListResponse, error)
+	// SuspendTenant приостанавливает арендатора: запросы от его имени
+	// отклоняются, данные сохраняются
+	SuspendTenant(id int) (*models.Tenant, error)
+	ActivateTenant(id int) (*models.Tenant, error)
+	// CheckTenant возвращает models.ErrTenantNotFound или
+	// models.ErrTenantSuspended, если от имени арендатора нельзя работать
+	CheckTenant(ctx context.Context, id int) error
+}
+
+// TenantManager реализует TenantService. Состояние арендаторов
+// проверяется на каждом запросе, поэтому кэшируется на statusTTL.
+// Приостановка через этот экземпляр действует сразу, через другие
+// экземпляры API - не позже чем через statusTTL.
+type TenantManager struct {
+	repo      repository.TenantRepository
+	statusTTL time.Duration
+
+	mu       sync.Mutex
+	statuses map[int]tenantStatus
+}
+
+type tenantStatus struct {
+	err       error
+	expiresAt time.Time
+}
+
+// NewTenantManager создает TenantManager. statusTTL, равный 0,
+// отключает кэширование состояния.
+func NewTenantManager(repo repository.TenantRepository, statusTTL time.Duration) *TenantManager {
+	return &TenantManager{repo: repo, statusTTL: statusTTL, statuses: make(map[int]tenantStatus)}
+}
+
+func (s *TenantManager) CreateTenant(req *models.CreateTenantRequest) (*models.Tenant, error) {
+	if !validTenantSlug.MatchString(req.Slug) {
+		return nil, models.ErrInvalidTenantSlug
+	}
+	t, err := s.repo.Create(req)
+	if err != nil {
+		return nil, err
+	}
+	s.remember(t)
+	return t, nil
+}
+
+func (s *TenantManager) GetTenant(id int) (*models.Tenant, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *TenantManager) GetTenants(page, pageSize int) (*models.TenantListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	tenants, total, err := s.repo.GetAll(page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TenantListResponse{
+		Tenants:    tenants,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+func (s *TenantManager) SuspendTenant(id int) (*models.Tenant, error) {
+	return s.setStatus(id, models.TenantStatusSuspended)
+}
+
+func (s *TenantManager) ActivateTenant(id int) (*models.Tenant, error) {
+	return s.setStatus(id, models.TenantStatusActive)
+}
+
+func (s *TenantManager) CheckTenant(ctx context.Context, id int) error {
+	s.mu.Lock()
+	status, ok := s.statuses[id]
+	s.mu.Unlock()
+	if ok && time.Now().Before(status.expiresAt) {
+		return status.err
+	}
+
+	// Отсутствие арендатора не кэшируется: он мог быть создан через
+	// другой экземпляр API
+	t, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	return s.remember(t)
+}
+
+func (s *TenantManager) setStatus(id int, status string) (*models.Tenant, error) {
+	t, err := s.repo.SetStatus(id, status)
+	if err != nil {
+		return nil, err
+	}
+	s.remember(t)
+	return t, nil
+}
+
+// remember кэширует состояние t и возвращает результат проверки
+func (s *TenantManager) remember(t *models.Tenant) error {
+	var err error
+	if t.Status != models.TenantStatusActive {
+		err = models.ErrTenantSuspended
+	}
+	s.store(t.ID, err)
+	return err
+}
+
+func (s *TenantManager) store(id int, err error) {
+	if s.statusTTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[id] = tenantStatus{err: err, expiresAt: time.Now().Add(s.statusTTL)}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// ParseUserFilters собирает фильтры списка пользователей из параметров
+// запроса: name, email, min_age, max_age, verified, group, sort, order и
+// metadata.<ключ>. Некорректные числа и логические значения игнорируются,
+// ошибкой считается только недопустимый ключ metadata. Так фильтры
+// разбирают и REST API, и userctl.
+func ParseUserFilters(query url.Values) (map[string]interface{}, error) {
+	filters := make(map[string]interface{})
+
+	if name := query.Get("name"); name != "" {
+		filters["name"] = name
+	}
+	if email := query.Get("email"); email != "" {
+		filters["email"] = email
+	}
+	if minAge, err := strconv.Atoi(query.Get("min_age")); err == nil && minAge > 0 {
+		filters["min_age"] = minAge
+	}
+	if maxAge, err := strconv.Atoi(query.Get("max_age")); err == nil && maxAge > 0 {
+		filters["max_age"] = maxAge
+	}
+	if verified, err := strconv.ParseBool(query.Get("verified")); err == nil {
+		filters["verified"] = verified
+	}
+	if group, err := strconv.Atoi(query.Get("group")); err == nil && group > 0 {
+		filters["group"] = group
+	}
+	if sortBy := query.Get("sort"); sortBy != "" {
+		filters["sort_by"] = sortBy
+		filters["sort_order"] = "asc"
+		if order := query.Get("order"); order != "" {
+			filters["sort_order"] = order
+		}
+	}
+
+	metadata := map[string]string{}
+	for param, values := range query {
+		key, ok := strings.CutPrefix(param, "metadata.")
+		if !ok {
+			continue
+		}
+		if !ValidMetadataKey(key) {
+			return nil, fmt.Errorf("invalid metadata key %q", key)
+		}
+		metadata[key] = values[0]
+	}
+	if len(metadata) > 0 {
+		filters["metadata"] = metadata
+	}
+	return filters, nil
+}
@@ -1,37 +1,68 @@
 package service
 
 import (
+	"context"
+	"slices"
+	"time"
 	"user-api/internal/models"
 	"user-api/internal/repository"
+	"user-api/internal/validation"
 )
 
 // UserService интерфейс бизнес-логики
 type UserService interface {
-	CreateUser(req *models.CreateUserRequest) (*models.User, error)
-	GetUser(id int) (*models.User, error)
-	GetUsers(page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error)
-	UpdateUser(id int, req *models.UpdateUserRequest) (*models.User, error)
-	DeleteUser(id int) error
+	CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error)
+	GetUser(ctx context.Context, id int) (*models.User, error)
+	GetUsers(ctx context.Context, page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error)
+	UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error)
+	DeleteUser(ctx context.Context, id int) error
+	VerifyEmail(ctx context.Context, token string) (*models.User, error)
+	ResendVerification(ctx context.Context, id int) error
+	// GetUserStats считает статистику пользователей по фильтрам списка
+	GetUserStats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error)
 }
 
 type userService struct {
-	repo repository.UserRepository
+	repo     repository.UserRepository
+	verifier *EmailVerifier
+	metadata MetadataValidator
 }
 
-// NewUserService создает новый сервис пользователей
-func NewUserService(repo repository.UserRepository) UserService {
-	return &userService{repo: repo}
+// NewUserService создает новый сервис пользователей. Если verifier равен
+// nil, подтверждение email отключено; если metadata равен nil, поле
+// metadata не проверяется.
+func NewUserService(repo repository.UserRepository, verifier *EmailVerifier, metadata MetadataValidator) UserService {
+	return &userService{repo: repo, verifier: verifier, metadata: metadata}
 }
 
-func (s *userService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
-	return s.repo.Create(req)
+func (s *userService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	normalized := *req
+	var err error
+	if normalized.Name, err = validation.NormalizeName(req.Name); err != nil {
+		return nil, err
+	}
+	if normalized.Email, err = validation.NormalizeEmail(req.Email); err != nil {
+		return nil, err
+	}
+	if err := s.validateMetadata(req.Metadata); err != nil {
+		return nil, err
+	}
+	if err := s.checkEmail(ctx, normalized.Email, 0); err != nil {
+		return nil, err
+	}
+	user, err := s.repo.Create(ctx, &normalized)
+	if err != nil {
+		return nil, err
+	}
+	s.sendVerification(user)
+	return user, nil
 }
 
-func (s *userService) GetUser(id int) (*models.User, error) {
-	return s.repo.GetByID(id)
+func (s *userService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	return s.repo.GetByID(ctx, id)
 }
 
-func (s *userService) GetUsers(page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
+func (s *userService) GetUsers(ctx context.Context, page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -39,10 +70,15 @@ func (s *userService) GetUsers(page, pageSize int, filters map[string]interface{
 		pageSize = 10
 	}
 
-	users, total, err := s.repo.GetAll(page, pageSize, filters)
+	users, total, err := s.repo.GetAll(ctx, page, pageSize, filters)
 	if err != nil {
 		return nil, err
 	}
+	if expand, _ := filters["expand"].([]string); slices.Contains(expand, models.ExpandGroups) {
+		if err := s.expandGroups(ctx, users); err != nil {
+			return nil, err
+		}
+	}
 
 	totalPages := (total + pageSize - 1) / pageSize
 
@@ -55,10 +91,125 @@ func (s *userService) GetUsers(page, pageSize int, filters map[string]interface{
 	}, nil
 }
 
-func (s *userService) UpdateUser(id int, req *models.UpdateUserRequest) (*models.User, error) {
-	return s.repo.Update(id, req)
+func (s *userService) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	normalized := *req
+	var err error
+	if req.Name != "" {
+		if normalized.Name, err = validation.NormalizeName(req.Name); err != nil {
+			return nil, err
+		}
+	}
+	if req.Email != "" {
+		if normalized.Email, err = validation.NormalizeEmail(req.Email); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.validateMetadata(req.Metadata); err != nil {
+		return nil, err
+	}
+	if normalized.Email != "" {
+		if err := s.checkEmail(ctx, normalized.Email, id); err != nil {
+			return nil, err
+		}
+	}
+	user, emailChanged, err := s.repo.Update(ctx, id, &normalized)
+	if err != nil {
+		return nil, err
+	}
+	// Смена адреса сбрасывает подтверждение; тот же адрес в запросе
+	// подтверждение не меняет, и письмо не нужно
+	if emailChanged {
+		s.sendVerification(user)
+	}
+	return user, nil
+}
+
+func (s *userService) DeleteUser(ctx context.Context, id int) error {
+	return s.repo.Delete(ctx, id)
 }
 
-func (s *userService) DeleteUser(id int) error {
-	return s.repo.Delete(id)
+func (s *userService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	if s.verifier == nil {
+		return nil, models.ErrVerificationDisabled
+	}
+	return s.verifier.Verify(token)
+}
+
+func (s *userService) ResendVerification(ctx context.Context, id int) error {
+	if s.verifier == nil {
+		return models.ErrVerificationDisabled
+	}
+	user, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return models.ErrEmailAlreadyVerified
+	}
+	return s.verifier.Send(user)
+}
+
+func (s *userService) GetUserStats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error) {
+	if len(opts.AgeBuckets) == 0 {
+		opts.AgeBuckets = models.DefaultAgeBuckets
+	}
+	if opts.Interval == "" {
+		opts.Interval = models.IntervalDay
+	}
+	stats, err := s.repo.Stats(ctx, filters, opts)
+	if err != nil {
+		return nil, err
+	}
+	stats.GeneratedAt = time.Now().UTC()
+	return stats, nil
+}
+
+// checkEmail возвращает ErrEmailTaken, если email занят другим
+// пользователем. Проверка до записи дает понятную ошибку, а от гонки двух
+// запросов защищает уникальный индекс (см. миграцию 011).
+func (s *userService) checkEmail(ctx context.Context, email string, excludeID int) error {
+	taken, err := s.repo.EmailExists(ctx, email, excludeID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return models.ErrEmailTaken
+	}
+	return nil
+}
+
+func (s *userService) validateMetadata(metadata models.Metadata) error {
+	if s.metadata == nil {
+		return nil
+	}
+	return s.metadata.ValidateMetadata(metadata)
+}
+
+// sendVerification отправляет письмо с подтверждением в фоне. Ошибка
+// отправки не отменяет изменение пользователя: письмо можно запросить
+// повторно.
+func (s *userService) sendVerification(user *models.User) {
+	if s.verifier == nil {
+		return
+	}
+	s.verifier.SendAsync(user)
+}
+
+// expandGroups встраивает в users их группы одним запросом на страницу
+func (s *userService) expandGroups(ctx context.Context, users []models.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]int, len(users))
+	for i := range users {
+		ids[i] = users[i].ID
+	}
+	groups, err := s.repo.GetGroups(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		users[i].Groups = groups[users[i].ID]
+	}
+	return nil
 }
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// WebhookService интерфейс управления подписками на события
+type WebhookService interface {
+	CreateWebhook(req *models.CreateWebhookRequest) (*models.Webhook, error)
+	GetWebhook(id int) (*models.Webhook, error)
+	GetWebhooks() ([]models.Webhook, error)
+	UpdateWebhook(id int, req *models.UpdateWebhookRequest) (*models.Webhook, error)
+	DeleteWebhook(id int) error
+	GetDeliveries(webhookID, page, pageSize int) (*models.WebhookDeliveryListResponse, error)
+	Redeliver(webhookID int, deliveryID int64) (*models.WebhookDelivery, error)
+}
+
+// DeliveryNotifier будит воркер доставки, когда появились новые доставки
+type DeliveryNotifier interface {
+	Notify()
+}
+
+type webhookService struct {
+	repo     repository.WebhookRepository
+	notifier DeliveryNotifier
+}
+
+// NewWebhookService создает новый сервис вебхуков
+func NewWebhookService(repo repository.WebhookRepository, notifier DeliveryNotifier) WebhookService {
+	return &webhookService{repo: repo, notifier: notifier}
+}
+
+func (s *webhookService) CreateWebhook(req *models.CreateWebhookRequest) (*models.Webhook, error) {
+	secret := req.Secret
+	if secret == "" {
+		secret = generateSecret()
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	// Секрет возвращается только в ответе на создание
+	return s.repo.Create(&models.Webhook{
+		URL:    req.URL,
+		Secret: secret,
+		Events: req.Events,
+		Active: active,
+	})
+}
+
+func (s *webhookService) GetWebhook(id int) (*models.Webhook, error) {
+	webhook, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+func (s *webhookService) GetWebhooks() ([]models.Webhook, error) {
+	webhooks, err := s.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+func (s *webhookService) UpdateWebhook(id int, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.repo.Update(id, req)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+func (s *webhookService) DeleteWebhook(id int) error {
+	return s.repo.Delete(id)
+}
+
+func (s *webhookService) GetDeliveries(webhookID, page, pageSize int) (*models.WebhookDeliveryListResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 20
+	}
+
+	if _, err := s.repo.GetByID(webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, total, err := s.repo.GetDeliveries(webhookID, page, pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.WebhookDeliveryListResponse{
+		Deliveries: deliveries,
+		Total:      total,
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (total + pageSize - 1) / pageSize,
+	}, nil
+}
+
+// Redeliver ставит событие в очередь повторно. Создается новая запись в
+// журнале, исходная доставка остается без изменений.
+func (s *webhookService) Redeliver(webhookID int, deliveryID int64) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(webhookID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.repo.CreateDelivery(webhookID, original.EventID, original.EventType, original.Payload, &original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.notifier != nil {
+		s.notifier.Notify()
+	}
+	return delivery, nil
+}
+
+func generateSecret() string {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
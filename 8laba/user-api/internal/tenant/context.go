@@ -0,0 +1,40 @@
+// Package tenant определяет арендатора запроса и передает его через
+// context.Context до репозиториев, которые ограничивают им запросы к БД
+package tenant
+
+import "context"
+
+// DefaultID - арендатор, которому принадлежат данные, созданные до
+// разделения, и все запросы при выключенном разделении
+const DefaultID = 1
+
+type ctxKey struct{}
+
+// system отмечает контекст операций, не привязанных к арендатору
+type system struct{}
+
+// WithID возвращает контекст запроса арендатора id
+func WithID(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает арендатора из ctx
+func FromContext(ctx context.Context) (int, bool) {
+	id, ok := ctx.Value(ctxKey{}).(int)
+	return id, ok
+}
+
+// WithSystem возвращает контекст операции, которая видит данные всех
+// арендаторов. Используется только там, где доступ дает сам запрос, а не
+// арендатор: одноразовые токены из писем. Все данные такой контекст видит
+// только в соединениях роли с BYPASSRLS (database.system), в соединениях
+// роли приложения - никаких.
+func WithSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, system{}, true)
+}
+
+// IsSystem сообщает, создан ли ctx через WithSystem
+func IsSystem(ctx context.Context) bool {
+	v, _ := ctx.Value(system{}).(bool)
+	return v
+}
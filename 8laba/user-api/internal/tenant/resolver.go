@@ -0,0 +1,179 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"user-api/internal/models"
+)
+
+// Checker проверяет, что арендатор существует и не приостановлен
+type Checker interface {
+	CheckTenant(ctx context.Context, id int) error
+}
+
+// Revocations сообщает, когда были отозваны токены пользователя
+type Revocations interface {
+	// TokensRevokedAt возвращает момент, раньше которого выданные токены
+	// пользователя subject арендатора tenantID недействительны; нулевое
+	// время - токены не отзывались
+	TokensRevokedAt(ctx context.Context, tenantID int, subject string) (time.Time, error)
+}
+
+// Credentials - данные запроса, по которым определяется арендатор
+type Credentials struct {
+	// Bearer - access токен из заголовка Authorization
+	Bearer string
+	// Header - значение заголовка с ID арендатора
+	Header string
+	// RemoteAddr - адрес клиента (host:port) без учета X-Forwarded-For
+	RemoteAddr string
+	// VerifiedCert - клиент предъявил сертификат, подписанный доверенным CA
+	VerifiedCert bool
+}
+
+// Resolver определяет арендатора запроса. Арендатор берется из
+// tenant_id access токена; заголовок принимается только от доверенных
+// вызывающих - клиентов с проверенным сертификатом или из доверенных сетей.
+type Resolver struct {
+	enabled     bool
+	secret      []byte
+	trusted     []*net.IPNet
+	checker     Checker
+	revocations Revocations
+}
+
+// NewResolver создает Resolver. Если enabled равен false, все запросы
+// относятся к DefaultID. Пустой secret отключает прием токенов. Если
+// revocations не nil, токен, выданный (iat) раньше отзыва токенов его
+// пользователя, отклоняется.
+func NewResolver(enabled bool, secret []byte, trustedNetworks []string, checker Checker, revocations Revocations) (*Resolver, error) {
+	networks, err := ParseNetworks(trustedNetworks)
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{enabled: enabled, secret: secret, trusted: networks, checker: checker, revocations: revocations}, nil
+}
+
+// Enabled сообщает, включено ли разделение по арендаторам
+func (r *Resolver) Enabled() bool {
+	return r.enabled
+}
+
+// Resolve возвращает ID активного арендатора запроса
+func (r *Resolver) Resolve(ctx context.Context, cred Credentials) (int, error) {
+	if !r.enabled {
+		return DefaultID, nil
+	}
+
+	var id int
+	switch {
+	case cred.Bearer != "" && len(r.secret) > 0:
+		claims, err := ParseToken(cred.Bearer, r.secret, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		if err := r.checkRevoked(ctx, claims); err != nil {
+			return 0, err
+		}
+		id = claims.TenantID
+	case cred.Header != "":
+		if !r.Trusted(cred) {
+			return 0, models.ErrUntrustedCaller
+		}
+		parsed, err := strconv.Atoi(cred.Header)
+		if err != nil || parsed <= 0 {
+			return 0, fmt.Errorf("%w: tenant ID must be a positive number", models.ErrTenantRequired)
+		}
+		id = parsed
+	default:
+		return 0, models.ErrTenantRequired
+	}
+
+	if err := r.checker.CheckTenant(ctx, id); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// checkRevoked отклоняет токен, выданный до отзыва токенов его
+// пользователя. Токен без iat после отзыва тоже отклоняется: время его
+// выдачи неизвестно. iat хранится с точностью до секунды, поэтому токен,
+// выданный в ту же секунду, что и отзыв, принимается.
+func (r *Resolver) checkRevoked(ctx context.Context, claims *Claims) error {
+	if r.revocations == nil {
+		return nil
+	}
+	revokedAt, err := r.revocations.TokensRevokedAt(ctx, claims.TenantID, claims.Subject)
+	if err != nil {
+		return err
+	}
+	if !revokedAt.IsZero() && claims.IssuedAt < revokedAt.Unix() {
+		return models.ErrInvalidAuthToken
+	}
+	return nil
+}
+
+// Trusted сообщает, доверенный ли вызывающий. При выключенном разделении
+// доверенными считаются все.
+func (r *Resolver) Trusted(cred Credentials) bool {
+	if !r.enabled || cred.VerifiedCert {
+		return true
+	}
+	host, _, err := net.SplitHostPort(cred.RemoteAddr)
+	if err != nil {
+		host = cred.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// BearerToken возвращает токен из значения заголовка Authorization
+func BearerToken(authorization string) string {
+	scheme, token, ok := strings.Cut(authorization, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// ParseNetworks разбирает список сетей в нотации CIDR. Отдельный адрес
+// считается сетью из одного адреса.
+func ParseNetworks(values []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted network %q", value)
+			}
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted network %q", value)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
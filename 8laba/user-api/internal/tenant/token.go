@@ -0,0 +1,77 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+	"user-api/internal/models"
+)
+
+// Claims - поля access токена, которые нужны для выбора арендатора
+type Claims struct {
+	Subject   string `json:"sub"`
+	TenantID  int    `json:"tenant_id"`
+	ExpiresAt int64  `json:"exp"`
+	NotBefore int64  `json:"nbf,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
+
+// ParseToken проверяет JWT, подписанный HS256 ключом secret, и возвращает
+// его поля. Токен без exp или tenant_id отклоняется.
+func ParseToken(token string, secret []byte, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, models.ErrInvalidAuthToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil || header.Alg != "HS256" {
+		return nil, models.ErrInvalidAuthToken
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, models.ErrInvalidAuthToken
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, models.ErrInvalidAuthToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, models.ErrInvalidAuthToken
+	}
+	if claims.ExpiresAt == 0 || now.Unix() >= claims.ExpiresAt || now.Unix() < claims.NotBefore {
+		return nil, models.ErrInvalidAuthToken
+	}
+	if claims.TenantID <= 0 {
+		return nil, models.ErrInvalidAuthToken
+	}
+	return &claims, nil
+}
+
+// SignToken подписывает claims ключом secret (HS256)
+func SignToken(claims Claims, secret []byte) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, _ := json.Marshal(claims)
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,55 @@
+// Package validation нормализует и проверяет данные пользователей: email
+// (регистр, пробелы, IDN) и имя (Unicode NFC, пробелы), а также
+// настраиваемые правила для email - одноразовые домены и блок-лист.
+package validation
+
+import (
+	"strings"
+	"unicode/utf8"
+	"user-api/internal/models"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// maxEmailLength - максимальная длина адреса (RFC 5321) и колонки users.email
+const maxEmailLength = 254
+
+// minNameLength - минимальная длина имени после нормализации (как в теге min)
+const minNameLength = 2
+
+// NormalizeEmail приводит адрес к виду, в котором он хранится: без пробелов
+// по краям, в нижнем регистре, с доменом в punycode (пример.рф →
+// xn--e1afmkfd.xn--p1ai)
+func NormalizeEmail(email string) (string, error) {
+	email = strings.TrimSpace(email)
+	at := strings.LastIndexByte(email, '@')
+	if at <= 0 || at == len(email)-1 {
+		return "", models.ErrInvalidEmail
+	}
+	local := strings.ToLower(norm.NFC.String(email[:at]))
+	domain, err := idna.Lookup.ToASCII(strings.TrimSuffix(email[at+1:], "."))
+	if err != nil {
+		return "", models.ErrInvalidEmail
+	}
+	email = local + "@" + strings.ToLower(domain)
+	if len(email) > maxEmailLength {
+		return "", models.ErrInvalidEmail
+	}
+	return email, nil
+}
+
+// NormalizeName приводит имя к форме Unicode NFC, убирает пробелы по краям
+// и заменяет последовательности пробельных символов одним пробелом
+func NormalizeName(name string) (string, error) {
+	name = strings.Join(strings.Fields(norm.NFC.String(name)), " ")
+	if utf8.RuneCountInString(name) < minNameLength {
+		return "", models.ErrInvalidName
+	}
+	return name, nil
+}
+
+// Domain возвращает домен нормализованного адреса
+func Domain(email string) string {
+	return email[strings.LastIndexByte(email, '@')+1:]
+}
@@ -0,0 +1,177 @@
+package validation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Теги binding для email, которые проверяются по EmailRules
+const (
+	TagNotDisposable = "not_disposable"
+	TagNotBlocked    = "not_blocked"
+)
+
+// disposableDomains - встроенный список доменов одноразовой почты
+var disposableDomains = []string{
+	"10minutemail.com",
+	"dispostable.com",
+	"getnada.com",
+	"guerrillamail.com",
+	"mailinator.com",
+	"maildrop.cc",
+	"sharklasers.com",
+	"temp-mail.org",
+	"tempmail.com",
+	"throwawaymail.com",
+	"trashmail.com",
+	"yopmail.com",
+}
+
+// EmailRules - настраиваемые правила для адресов пользователей. Домены
+// сравниваются вместе с поддоменами: правило для example.com действует и на
+// mail.example.com.
+type EmailRules struct {
+	disposable     map[string]bool
+	blockedDomains map[string]bool
+	blockedEmails  map[string]bool
+}
+
+// NewEmailRules создает правила. Если blockDisposable равен false, адреса
+// одноразовой почты разрешены; disposable дополняет встроенный список.
+func NewEmailRules(blockDisposable bool, disposable, blockedDomains, blockedEmails []string) (*EmailRules, error) {
+	r := &EmailRules{
+		disposable:     map[string]bool{},
+		blockedDomains: map[string]bool{},
+		blockedEmails:  map[string]bool{},
+	}
+	if blockDisposable {
+		for _, domain := range slices.Concat(disposableDomains, disposable) {
+			if err := addDomain(r.disposable, domain); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, domain := range blockedDomains {
+		if err := addDomain(r.blockedDomains, domain); err != nil {
+			return nil, err
+		}
+	}
+	for _, email := range blockedEmails {
+		normalized, err := NormalizeEmail(email)
+		if err != nil {
+			return nil, fmt.Errorf("blocked email %q: %w", email, err)
+		}
+		r.blockedEmails[normalized] = true
+	}
+	return r, nil
+}
+
+func addDomain(set map[string]bool, domain string) error {
+	normalized, err := NormalizeEmail("x@" + domain)
+	if err != nil {
+		return fmt.Errorf("invalid domain %q", domain)
+	}
+	set[Domain(normalized)] = true
+	return nil
+}
+
+// ReadDomains читает домены из r: по одному в строке, пустые строки и
+// строки с # пропускаются
+func ReadDomains(r io.Reader) ([]string, error) {
+	var domains []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			domains = append(domains, line)
+		}
+	}
+	return domains, scanner.Err()
+}
+
+// ReadDomainsFile читает домены из файла path (см. ReadDomains); пустой
+// путь - пустой список
+func ReadDomainsFile(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadDomains(f)
+}
+
+// Disposable проверяет, что адрес на домене одноразовой почты
+func (r *EmailRules) Disposable(email string) bool {
+	return r != nil && matchDomain(r.disposable, email)
+}
+
+// Blocked проверяет, что адрес или его домен в блок-листе
+func (r *EmailRules) Blocked(email string) bool {
+	if r == nil {
+		return false
+	}
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		return false
+	}
+	return r.blockedEmails[normalized] || matchDomain(r.blockedDomains, normalized)
+}
+
+// matchDomain проверяет домен адреса и его родительские домены
+func matchDomain(set map[string]bool, email string) bool {
+	if len(set) == 0 {
+		return false
+	}
+	normalized, err := NormalizeEmail(email)
+	if err != nil {
+		return false
+	}
+	for domain := Domain(normalized); domain != ""; {
+		if set[domain] {
+			return true
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok {
+			break
+		}
+		domain = parent
+	}
+	return false
+}
+
+// Register регистрирует в v теги not_disposable и not_blocked. Теги
+// используются в моделях запросов, поэтому должны быть зарегистрированы в
+// каждом validator, который их проверяет; при rules == nil они ничего не
+// запрещают. Некорректный адрес тегами не отклоняется - это задача тега
+// email.
+func Register(v *validator.Validate, rules *EmailRules) error {
+	if err := v.RegisterValidation(TagNotDisposable, func(fl validator.FieldLevel) bool {
+		return !rules.Disposable(fl.Field().String())
+	}); err != nil {
+		return err
+	}
+	return v.RegisterValidation(TagNotBlocked, func(fl validator.FieldLevel) bool {
+		return !rules.Blocked(fl.Field().String())
+	})
+}
+
+// New создает validator, который проверяет модели по тегам binding (как
+// gin) с правилами rules
+func New(rules *EmailRules) *validator.Validate {
+	v := validator.New()
+	v.SetTagName("binding")
+	if err := Register(v, rules); err != nil {
+		// Имена тегов заданы константами и корректны
+		panic(err)
+	}
+	return v
+}
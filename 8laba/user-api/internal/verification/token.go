@@ -0,0 +1,99 @@
+package verification
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ошибки проверки токена
+var (
+	ErrMalformed = errors.New("malformed token")
+	ErrSignature = errors.New("invalid token signature")
+	ErrExpired   = errors.New("token expired")
+)
+
+// Claims - данные, которые подписываются в токене
+type Claims struct {
+	// ID - уникальный идентификатор токена; по нему токен гасится
+	// после использования
+	ID        string
+	UserID    int
+	ExpiresAt time.Time
+}
+
+// Signer подписывает и проверяет токены подтверждения HMAC-SHA256.
+// Формат токена: base64url("<id>.<user_id>.<expires_unix>").base64url(hmac).
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner создает Signer с секретом secret
+func NewSigner(secret string) *Signer {
+	return &Signer{secret: []byte(secret)}
+}
+
+// NewID возвращает случайный идентификатор токена
+func NewID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Sign возвращает подписанный токен
+func (s *Signer) Sign(c Claims) string {
+	payload := fmt.Sprintf("%s.%d.%d", c.ID, c.UserID, c.ExpiresAt.Unix())
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(s.mac(encoded))
+}
+
+// Parse проверяет подпись и срок действия токена на момент now
+func (s *Signer) Parse(token string, now time.Time) (Claims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return Claims{}, ErrMalformed
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	if !hmac.Equal(mac, s.mac(encoded)) {
+		return Claims{}, ErrSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	parts := strings.Split(string(payload), ".")
+	if len(parts) != 3 || parts[0] == "" {
+		return Claims{}, ErrMalformed
+	}
+	userID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	expires, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+
+	claims := Claims{ID: parts[0], UserID: userID, ExpiresAt: time.Unix(expires, 0)}
+	if !now.Before(claims.ExpiresAt) {
+		return claims, ErrExpired
+	}
+	return claims, nil
+}
+
+func (s *Signer) mac(payload string) []byte {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
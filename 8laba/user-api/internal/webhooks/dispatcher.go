@@ -0,0 +1,236 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+)
+
+// Заголовки, которые получает получатель вебхука
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Config содержит настройки доставки
+type Config struct {
+	Workers      int
+	BatchSize    int
+	PollInterval time.Duration
+	Timeout      time.Duration
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+}
+
+// Dispatcher превращает события пользователей в доставки и отправляет их
+// в фоне с повторами. Все состояние хранится в БД, поэтому доставки
+// переживают перезапуск процесса.
+type Dispatcher struct {
+	repo   repository.WebhookRepository
+	cfg    Config
+	client *http.Client
+	wake   chan struct{}
+}
+
+// NewDispatcher создает новый диспетчер вебхуков
+func NewDispatcher(repo repository.WebhookRepository, cfg Config) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Publish создает доставки события для всех подходящих подписок
+// (events.EventPublisher для relay outbox). Ошибка возвращается, если
+// хотя бы одна доставка не записана: relay повторит событие, а уже
+// созданные доставки не продублируются.
+func (d *Dispatcher) Publish(_ context.Context, event events.Event) error {
+	webhooks, err := d.repo.GetActiveByEvent(string(event.Type))
+	if err != nil {
+		return fmt.Errorf("failed to find webhooks for %s: %w", event.Type, err)
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		// Повтор не поможет
+		log.Printf("Webhooks: failed to marshal event %s: %v", event.ID, err)
+		return nil
+	}
+
+	var errs []error
+	for _, webhook := range webhooks {
+		_, err := d.repo.CreateDelivery(webhook.ID, event.ID, string(event.Type), payload, nil)
+		if errors.Is(err, models.ErrDeliveryExists) {
+			// Событие уже получено ранее (relay публикует at-least-once)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to queue event %s for webhook %d: %w", event.ID, webhook.ID, err))
+		}
+	}
+	d.Notify()
+	return errors.Join(errs...)
+}
+
+// Notify будит воркер, не дожидаясь следующего опроса
+func (d *Dispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run обрабатывает очередь доставок до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	// Lease с запасом покрывает таймаут запроса, чтобы доставку не взял
+	// другой воркер, пока текущий еще ждет ответа
+	lease := 2*d.cfg.Timeout + 30*time.Second
+
+	for ctx.Err() == nil {
+		deliveries, err := d.repo.ClaimDue(d.cfg.BatchSize, lease)
+		if err != nil {
+			log.Printf("Webhooks: %v", err)
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, d.cfg.Workers)
+		for _, delivery := range deliveries {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(delivery models.WebhookDelivery) {
+				defer func() { <-sem; wg.Done() }()
+				d.deliver(ctx, &delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		// Полная порция - вероятно, в очереди есть еще
+		if len(deliveries) == d.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	webhook, err := d.repo.GetByID(delivery.WebhookID)
+	if err != nil {
+		d.fail(delivery, nil, err.Error(), false)
+		return
+	}
+	if !webhook.Active {
+		d.fail(delivery, nil, "webhook is inactive", false)
+		return
+	}
+
+	timestamp := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		d.fail(delivery, nil, err.Error(), false)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "user-api-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		d.fail(delivery, nil, err.Error(), true)
+		return
+	}
+	defer resp.Body.Close()
+	// Читаем немного тела, чтобы соединение можно было переиспользовать
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		status := resp.StatusCode
+		d.fail(delivery, &status, fmt.Sprintf("unexpected status %d", resp.StatusCode), true)
+		return
+	}
+
+	if err := d.repo.MarkSucceeded(delivery.ID, resp.StatusCode); err != nil {
+		log.Printf("Webhooks: %v", err)
+	}
+}
+
+// fail записывает неудачную попытку и планирует повтор с экспоненциальной
+// задержкой. После MaxAttempts попыток (или если повтор бессмысленен)
+// доставка переходит в dead-letter статус.
+func (d *Dispatcher) fail(delivery *models.WebhookDelivery, responseStatus *int, reason string, retry bool) {
+	attempts := delivery.Attempts + 1
+
+	var next *time.Time
+	if retry && attempts < d.cfg.MaxAttempts {
+		at := time.Now().Add(Backoff(attempts, d.cfg.BackoffBase, d.cfg.BackoffMax))
+		next = &at
+	} else {
+		log.Printf("Webhooks: delivery %d to webhook %d is dead after %d attempts: %s",
+			delivery.ID, delivery.WebhookID, attempts, reason)
+	}
+
+	if len(reason) > 1000 {
+		reason = reason[:1000]
+	}
+	if err := d.repo.MarkFailed(delivery.ID, responseStatus, reason, next); err != nil {
+		log.Printf("Webhooks: %v", err)
+	}
+}
+
+// Backoff возвращает задержку перед попыткой attempt+1: base*2^(attempt-1),
+// не больше max, плюс до 20% случайного разброса
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempt && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
+
+// Sign вычисляет подпись тела запроса: "sha256=" + hex(HMAC-SHA256(secret,
+// "<timestamp>.<body>")). Получатель должен вычислить ту же подпись по
+// заголовку X-Webhook-Timestamp и сравнить с X-Webhook-Signature.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS outbox (
+    id BIGSERIAL PRIMARY KEY,
+    event_id VARCHAR(64) NOT NULL UNIQUE,
+    event_type VARCHAR(64) NOT NULL,
+    user_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_outbox_unpublished ON outbox(id) WHERE published_at IS NULL;
+CREATE INDEX idx_outbox_published_at ON outbox(published_at) WHERE published_at IS NOT NULL;
+
+-- Relay публикует события "как минимум один раз": повторно опубликованное
+-- событие не должно породить вторую доставку вебхука
+CREATE UNIQUE INDEX idx_webhook_deliveries_event
+    ON webhook_deliveries(webhook_id, event_id) WHERE redelivery_of IS NULL;
@@ -0,0 +1,4 @@
+-- avatar_key - префикс ключей файлов аватара в хранилище, avatar - адреса
+-- файлов по размерам ({"small": "...", "medium": "...", "large": "..."})
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_key VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar JSONB;
@@ -0,0 +1,19 @@
+-- metadata - произвольные поля пользователя ({"department": "sales", ...}).
+-- Размер ограничен, чтобы строка users не разрасталась.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS metadata JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE users ADD CONSTRAINT users_metadata_object CHECK (jsonb_typeof(metadata) = 'object');
+ALTER TABLE users ADD CONSTRAINT users_metadata_size CHECK (octet_length(metadata::text) <= 16384);
+
+-- jsonb_path_ops поддерживает только @>, зато индекс меньше и быстрее
+-- jsonb_ops; фильтр metadata.<ключ>=<значение> строится на @>
+CREATE INDEX IF NOT EXISTS idx_users_metadata ON users USING GIN (metadata jsonb_path_ops);
+
+-- JSON Schema для значений отдельных ключей metadata. Ключи без схемы
+-- принимают любое значение.
+CREATE TABLE IF NOT EXISTS metadata_schemas (
+    key VARCHAR(64) PRIMARY KEY,
+    schema JSONB NOT NULL,
+    description VARCHAR(500) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
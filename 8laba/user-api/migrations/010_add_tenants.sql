@@ -0,0 +1,78 @@
+-- Арендаторы (организации-клиенты). Пользователи и группы, созданные до
+-- разделения, принадлежат арендатору 1.
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'suspended')),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO tenants (id, name, slug) VALUES (1, 'Default', 'default') ON CONFLICT (id) DO NOTHING;
+SELECT setval(pg_get_serial_sequence('tenants', 'id'), (SELECT MAX(id) FROM tenants));
+
+-- Арендатор транзакции задается приложением через
+-- set_config('app.tenant_id', ..., true). Без него строки не видны и не
+-- создаются.
+CREATE OR REPLACE FUNCTION current_tenant_id() RETURNS INTEGER AS $$
+    SELECT NULLIF(current_setting('app.tenant_id', true), '')::INTEGER
+$$ LANGUAGE SQL STABLE;
+
+-- Операции по одноразовым токенам (подтверждение email, сброс пароля)
+-- не привязаны к арендатору и включают app.bypass_rls
+CREATE OR REPLACE FUNCTION tenant_rls_bypassed() RETURNS BOOLEAN AS $$
+    SELECT COALESCE(current_setting('app.bypass_rls', true), '') = 'on'
+$$ LANGUAGE SQL STABLE;
+
+-- Пользователи: email уникален в пределах арендатора
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE users ALTER COLUMN tenant_id SET DEFAULT current_tenant_id();
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_email_key UNIQUE (tenant_id, email);
+ALTER TABLE users ADD CONSTRAINT users_id_tenant_key UNIQUE (id, tenant_id);
+
+-- Группы: имя уникально в пределах арендатора
+ALTER TABLE groups ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants(id);
+ALTER TABLE groups ALTER COLUMN tenant_id SET DEFAULT current_tenant_id();
+ALTER TABLE groups DROP CONSTRAINT IF EXISTS groups_name_key;
+ALTER TABLE groups ADD CONSTRAINT groups_tenant_name_key UNIQUE (tenant_id, name);
+ALTER TABLE groups ADD CONSTRAINT groups_id_tenant_key UNIQUE (id, tenant_id);
+
+-- Составные внешние ключи не дают добавить в группу пользователя другого
+-- арендатора
+ALTER TABLE group_members ADD COLUMN IF NOT EXISTS tenant_id INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE group_members ALTER COLUMN tenant_id SET DEFAULT current_tenant_id();
+ALTER TABLE group_members DROP CONSTRAINT IF EXISTS group_members_group_id_fkey;
+ALTER TABLE group_members DROP CONSTRAINT IF EXISTS group_members_user_id_fkey;
+ALTER TABLE group_members ADD CONSTRAINT group_members_group_fkey
+    FOREIGN KEY (group_id, tenant_id) REFERENCES groups(id, tenant_id) ON DELETE CASCADE;
+ALTER TABLE group_members ADD CONSTRAINT group_members_user_fkey
+    FOREIGN KEY (user_id, tenant_id) REFERENCES users(id, tenant_id) ON DELETE CASCADE;
+
+-- Row-level security. FORCE применяет политики и к владельцу таблиц;
+-- суперпользователь и роли с BYPASSRLS их не соблюдают, поэтому
+-- приложение должно подключаться под обычной ролью.
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON users;
+CREATE POLICY tenant_isolation ON users
+    USING (tenant_rls_bypassed() OR tenant_id = current_tenant_id())
+    WITH CHECK (tenant_rls_bypassed() OR tenant_id = current_tenant_id());
+
+ALTER TABLE groups ENABLE ROW LEVEL SECURITY;
+ALTER TABLE groups FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON groups;
+CREATE POLICY tenant_isolation ON groups
+    USING (tenant_rls_bypassed() OR tenant_id = current_tenant_id())
+    WITH CHECK (tenant_rls_bypassed() OR tenant_id = current_tenant_id());
+
+ALTER TABLE group_members ENABLE ROW LEVEL SECURITY;
+ALTER TABLE group_members FORCE ROW LEVEL SECURITY;
+DROP POLICY IF EXISTS tenant_isolation ON group_members;
+CREATE POLICY tenant_isolation ON group_members
+    USING (tenant_rls_bypassed() OR tenant_id = current_tenant_id())
+    WITH CHECK (tenant_rls_bypassed() OR tenant_id = current_tenant_id());
+
+-- Ключи идемпотентности хранятся с префиксом арендатора
+ALTER TABLE idempotency_keys ALTER COLUMN key TYPE VARCHAR(300);
@@ -0,0 +1,42 @@
+-- Email хранится нормализованным (без пробелов по краям, в нижнем
+-- регистре, в Unicode NFC, с доменом в punycode) и уникален в пределах
+-- арендатора без учета регистра.
+--
+-- Перевести домены в punycode SQL не умеет, поэтому на существующей базе
+-- перед миграцией выполняется `userctl normalize-emails`: он нормализует
+-- адреса так же, как сервис (validation.NormalizeEmail). Пока остаются
+-- адреса с Unicode символами или точкой в конце домена, миграция
+-- останавливается.
+--
+-- Миграция меняет строки всех арендаторов, поэтому выполняется с
+-- app.bypass_rls. Если у арендатора есть адреса, совпадающие после
+-- нормализации, миграция тоже останавливается: таких пользователей нужно
+-- объединить или исправить вручную (их перечисляет
+-- `userctl normalize-emails --dry-run`, запрос для поиска - в README).
+SELECT set_config('app.bypass_rls', 'on', false);
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM users
+        WHERE substring(email from '@([^@]*)$') ~ '([^\x01-\x7f]|\.\s*$)'
+    ) THEN
+        RAISE EXCEPTION 'users with internationalized domains exist, run "userctl normalize-emails" before applying this migration';
+    END IF;
+
+    IF EXISTS (
+        SELECT 1 FROM users
+        GROUP BY tenant_id, LOWER(TRIM(NORMALIZE(email, NFC)))
+        HAVING COUNT(*) > 1
+    ) THEN
+        RAISE EXCEPTION 'users with emails equal after normalization exist, resolve them before applying this migration';
+    END IF;
+END $$;
+
+UPDATE users SET email = LOWER(TRIM(NORMALIZE(email, NFC)))
+WHERE email <> LOWER(TRIM(NORMALIZE(email, NFC)));
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_email_key;
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_lower_key ON users (tenant_id, LOWER(email));
+
+SELECT set_config('app.bypass_rls', '', false);
@@ -0,0 +1,11 @@
+-- Номер публикации события. Relay присваивает его под advisory lock
+-- outbox, поэтому номера растут в порядке публикации на всех экземплярах
+-- API. Лента SSE каждого экземпляра читает опубликованные события по
+-- номерам и использует номер как ID события: после переподключения к
+-- любому экземпляру клиент догоняет ленту по outbox.
+CREATE SEQUENCE IF NOT EXISTS outbox_published_seq;
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS published_seq BIGINT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_outbox_published_seq
+    ON outbox(published_seq) WHERE published_seq IS NOT NULL;
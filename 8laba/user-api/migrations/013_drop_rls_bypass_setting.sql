@@ -0,0 +1,20 @@
+-- Политики больше не пропускают строки по настройке app.bypass_rls: ее
+-- может задать любой запрос роли приложения (set_config или SQL инъекция).
+-- Операции по токенам из писем выполняются под отдельной ролью с
+-- BYPASSRLS (database.system.user), которую создает администратор.
+DROP POLICY IF EXISTS tenant_isolation ON users;
+CREATE POLICY tenant_isolation ON users
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+DROP POLICY IF EXISTS tenant_isolation ON groups;
+CREATE POLICY tenant_isolation ON groups
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+DROP POLICY IF EXISTS tenant_isolation ON group_members;
+CREATE POLICY tenant_isolation ON group_members
+    USING (tenant_id = current_tenant_id())
+    WITH CHECK (tenant_id = current_tenant_id());
+
+DROP FUNCTION IF EXISTS tenant_rls_bypassed();
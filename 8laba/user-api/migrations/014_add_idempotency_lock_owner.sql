@@ -0,0 +1,4 @@
+-- Владелец блокировки ключа. Запрос, потерявший блокировку (не продлил ее
+-- за lockTimeout), не должен сохранять ответ поверх записи запроса,
+-- занявшего ключ после него, или удалять ее.
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS locked_by CHAR(32);
@@ -0,0 +1,260 @@
+// Package client - типизированный Go клиент REST API пользователей
+// (/api/v1/users). Методы повторяют service.UserService; запросы
+// повторяются с экспоненциальной задержкой при ответах 5xx и 429, а ответы
+// с ошибкой превращаются в *APIError.
+//
+//	c, err := client.New("http://localhost:8080", client.WithToken(token))
+//	user, err := c.GetUser(ctx, 42)
+//	if errors.Is(err, client.ErrNotFound) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	cryptorand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTenantHeader - заголовок с ID арендатора по умолчанию
+// (tenancy.header сервера)
+const DefaultTenantHeader = "X-Tenant-ID"
+
+// headerIdempotencyKey - заголовок, по которому сервер выполняет повтор
+// POST запроса не более одного раза
+const headerIdempotencyKey = "Idempotency-Key"
+
+// RetryPolicy задает повторы запросов при ответах 5xx и 429. Задержка
+// перед n-й попыткой - BaseDelay * 2^(n-1) со случайным разбросом, но не
+// больше MaxDelay; Retry-After из ответа 429 учитывается, если он не
+// больше MaxDelay, иначе ошибка возвращается сразу.
+type RetryPolicy struct {
+	// MaxRetries - число повторов после первой попытки; 0 отключает повторы
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy - политика повторов по умолчанию
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: 200 * time.Millisecond, MaxDelay: 5 * time.Second}
+
+// TokenSource возвращает access токен для очередного запроса, например
+// обновляя его по истечении срока
+type TokenSource func(ctx context.Context) (string, error)
+
+// Client - клиент API пользователей. Безопасен для одновременного
+// использования из нескольких горутин.
+type Client struct {
+	base         *url.URL
+	httpClient   *http.Client
+	token        TokenSource
+	tenantHeader string
+	tenantID     string
+	language     string
+	userAgent    string
+	retry        RetryPolicy
+}
+
+// Option настраивает Client
+type Option func(*Client)
+
+// WithHTTPClient задает HTTP клиент (таймауты, транспорт, TLS)
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithToken задает постоянный access токен для заголовка Authorization
+func WithToken(token string) Option {
+	return WithTokenSource(func(context.Context) (string, error) { return token, nil })
+}
+
+// WithTokenSource задает источник access токенов
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) { c.token = source }
+}
+
+// WithTenant передает ID арендатора в DefaultTenantHeader. Сервер
+// принимает заголовок только от доверенных вызывающих; обычно арендатор
+// берется из токена.
+func WithTenant(id int) Option {
+	return func(c *Client) { c.tenantID = strconv.Itoa(id) }
+}
+
+// WithTenantHeader задает заголовок для WithTenant
+func WithTenantHeader(header string) Option {
+	return func(c *Client) { c.tenantHeader = header }
+}
+
+// WithLanguage задает язык сообщений об ошибках (Accept-Language)
+func WithLanguage(language string) Option {
+	return func(c *Client) { c.language = language }
+}
+
+// WithUserAgent задает заголовок User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) { c.userAgent = userAgent }
+}
+
+// WithRetry задает политику повторов
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// New создает клиент API по адресу сервера, например http://localhost:8080
+// (префикс /api/v1 добавляется сам)
+func New(baseURL string, opts ...Option) (*Client, error) {
+	base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("client: invalid base URL %q", baseURL)
+	}
+	c := &Client{
+		base:         base,
+		httpClient:   &http.Client{Timeout: 30 * time.Second},
+		tenantHeader: DefaultTenantHeader,
+		userAgent:    "user-api-client",
+		retry:        DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// CloseIdleConnections закрывает неиспользуемые соединения HTTP клиента
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// do отправляет запрос к /api/v1<path> с повторами и декодирует JSON
+// ответ в out. POST запросы получают Idempotency-Key, общий для всех
+// попыток, поэтому повтор не создает запись дважды, если на сервере
+// включена идемпотентность.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	endpoint := c.base.JoinPath("/api/v1", path)
+	endpoint.RawQuery = query.Encode()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	var idempotencyKey string
+	if method == http.MethodPost {
+		idempotencyKey = newIdempotencyKey()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := c.send(ctx, method, endpoint.String(), data, idempotencyKey, out)
+		delay, ok := c.retryDelay(err, attempt)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// send выполняет одну попытку запроса
+func (c *Client) send(ctx context.Context, method, endpoint string, data []byte, idempotencyKey string, out interface{}) error {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(headerIdempotencyKey, idempotencyKey)
+	}
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if c.tenantID != "" {
+		req.Header.Set(c.tenantHeader, c.tenantID)
+	}
+	if c.token != nil {
+		token, err := c.token(ctx)
+		if err != nil {
+			return fmt.Errorf("client: failed to get token: %w", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return newAPIError(resp)
+	}
+	if out == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("client: failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// retryDelay возвращает задержку перед повтором после попытки attempt
+// (с нуля) или false, если повторять не нужно
+func (c *Client) retryDelay(err error, attempt int) (time.Duration, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || attempt >= c.retry.MaxRetries {
+		return 0, false
+	}
+	if apiErr.StatusCode != http.StatusTooManyRequests && apiErr.StatusCode < http.StatusInternalServerError {
+		return 0, false
+	}
+
+	delay := c.retry.BaseDelay << attempt
+	if delay <= 0 || delay > c.retry.MaxDelay {
+		delay = c.retry.MaxDelay
+	}
+	// Разброс в пределах половины задержки, чтобы клиенты не повторяли
+	// запросы одновременно
+	if half := int64(delay / 2); half > 0 {
+		delay = time.Duration(half + rand.Int64N(half+1))
+	}
+	if apiErr.RetryAfter > 0 {
+		if apiErr.RetryAfter > c.retry.MaxDelay {
+			return 0, false
+		}
+		delay = max(delay, apiErr.RetryAfter)
+	}
+	return delay, true
+}
+
+// newIdempotencyKey возвращает случайный ключ (128 бит) в hex
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, _ = cryptorand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Ошибки по коду ответа; проверяются через errors.Is
+var (
+	// ErrInvalidRequest - запрос не прошел проверку (400, 422)
+	ErrInvalidRequest = errors.New("invalid request")
+	// ErrUnauthorized - токен отсутствует, истек или неверен (401)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden - арендатор приостановлен или вызывающий не доверенный (403)
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound - пользователь не найден (404)
+	ErrNotFound = errors.New("not found")
+	// ErrConflict - email уже занят или уже подтвержден (409)
+	ErrConflict = errors.New("conflict")
+	// ErrRateLimited - превышен лимит запросов (429)
+	ErrRateLimited = errors.New("rate limited")
+	// ErrServer - ошибка сервера (5xx)
+	ErrServer = errors.New("server error")
+)
+
+// APIError - ответ API с ошибкой (ErrorResponse)
+type APIError struct {
+	StatusCode int
+	// Code - поле error ответа, например "User not found"
+	Code string
+	// Message - подробности; язык задается WithLanguage
+	Message string
+	// RetryAfter - значение заголовка Retry-After, если он есть
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s (HTTP %d)", e.Code, e.StatusCode)
+	}
+	return fmt.Sprintf("%s: %s (HTTP %d)", e.Code, e.Message, e.StatusCode)
+}
+
+// Is сопоставляет ошибку с ErrNotFound, ErrConflict и другими ошибками
+// пакета по коду ответа
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidRequest:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= http.StatusInternalServerError
+	}
+	return false
+}
+
+// newAPIError читает ErrorResponse из ответа. Если тело не JSON, в Code
+// попадает текст статуса.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	var body ErrorResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&body); err == nil {
+		apiErr.Code, apiErr.Message = body.Error, body.Message
+	}
+	if apiErr.Code == "" {
+		apiErr.Code = http.StatusText(resp.StatusCode)
+	}
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return apiErr
+}
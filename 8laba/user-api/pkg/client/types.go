@@ -0,0 +1,121 @@
+package client
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Типы запросов и ответов API. Они принадлежат клиенту и повторяют только
+// JSON представление ресурсов, поэтому не меняются вместе с внутренними
+// структурами сервера.
+
+// User - пользователь
+type User struct {
+	ID       int    `json:"id"`
+	TenantID int    `json:"tenant_id"`
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Age      int    `json:"age"`
+	// EmailVerifiedAt - время подтверждения email; nil, пока не подтвержден
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	Avatar          AvatarURLs `json:"avatar,omitempty"`
+	Metadata        Metadata   `json:"metadata"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	// Groups заполняется только по запросу с Expand: groups
+	Groups []UserGroup `json:"groups,omitempty"`
+}
+
+// UserGroup - группа пользователя и его роль в ней
+type UserGroup struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// Metadata - произвольные поля пользователя. Значения - JSON как есть,
+// чтобы не терять точность чисел.
+type Metadata map[string]json.RawMessage
+
+// AvatarURLs - адреса аватара по размерам (small, medium, large)
+type AvatarURLs map[string]string
+
+// CreateUserRequest - данные нового пользователя
+type CreateUserRequest struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Age   int    `json:"age"`
+	// Metadata - произвольные поля; значения ключей со схемой проверяются
+	// сервером по ней
+	Metadata Metadata `json:"metadata,omitempty"`
+}
+
+// UpdateUserRequest - изменяемые поля пользователя; нулевые значения не
+// меняются
+type UpdateUserRequest struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+	Age   int    `json:"age,omitempty"`
+	// Metadata дополняет существующие поля; ключ со значением null удаляется
+	Metadata Metadata `json:"metadata,omitempty"`
+}
+
+// UserListResponse - страница списка пользователей
+type UserListResponse struct {
+	Users      []User `json:"users"`
+	Total      int    `json:"total"`
+	Page       int    `json:"page"`
+	PageSize   int    `json:"page_size"`
+	TotalPages int    `json:"total_pages"`
+}
+
+// UserStatsOptions - параметры статистики (GET /users/stats). Нулевые
+// значения не передаются, сервер использует свои значения по умолчанию.
+type UserStatsOptions struct {
+	// AgeBuckets - возрастающие границы групп возраста
+	AgeBuckets []int
+	// Interval - период графика регистраций: day, week или month
+	Interval string
+	// From и To ограничивают регистрации графика: created_at в [From, To)
+	From *time.Time
+	To   *time.Time
+}
+
+// UserStats - статистика пользователей
+type UserStats struct {
+	Total int `json:"total"`
+	// MeanAge и MedianAge равны nil, если пользователей нет
+	MeanAge      *float64    `json:"mean_age"`
+	MedianAge    *float64    `json:"median_age"`
+	AgeHistogram []AgeBucket `json:"age_histogram"`
+	Interval     string      `json:"interval"`
+	// Signups - регистрации по периодам, включая периоды без регистраций
+	Signups     []SignupCount `json:"signups"`
+	GeneratedAt time.Time     `json:"generated_at"`
+}
+
+// AgeBucket - число пользователей с возрастом от Min до Max включительно.
+// У крайних групп одна из границ равна nil.
+type AgeBucket struct {
+	Label string `json:"label"`
+	Min   *int   `json:"min"`
+	Max   *int   `json:"max"`
+	Count int    `json:"count"`
+}
+
+// SignupCount - число регистраций за период, начинающийся в Period (UTC)
+type SignupCount struct {
+	Period time.Time `json:"period"`
+	Count  int       `json:"count"`
+}
+
+// ErrorResponse - тело ответа с ошибкой
+type ErrorResponse struct {
+	Error   string `json:"error"`
+	Message string `json:"message,omitempty"`
+}
+
+// verifyEmailRequest - тело POST /verify-email
+type verifyEmailRequest struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,211 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxPageSize - наибольший размер страницы списка
+const MaxPageSize = 100
+
+// UserFilter - фильтры и сортировка списка пользователей (параметры
+// GET /users). Нулевые значения не применяются.
+type UserFilter struct {
+	// Name и Email ищутся как подстрока без учета регистра
+	Name  string
+	Email string
+	// MinAge и MaxAge - границы возраста включительно
+	MinAge int
+	MaxAge int
+	// Verified - статус подтверждения email
+	Verified *bool
+	// Group - ID группы, в которой состоит пользователь
+	Group int
+	// Sort - поле сортировки (id, name, email, age, created_at,
+	// updated_at); Order - asc или desc. По умолчанию сначала новые.
+	Sort  string
+	Order string
+	// Metadata - значения полей metadata
+	Metadata map[string]string
+	// Expand - связанные ресурсы (groups)
+	Expand []string
+}
+
+// Values возвращает параметры запроса GET /users
+func (f *UserFilter) Values() url.Values {
+	query := url.Values{}
+	if f == nil {
+		return query
+	}
+	if f.Name != "" {
+		query.Set("name", f.Name)
+	}
+	if f.Email != "" {
+		query.Set("email", f.Email)
+	}
+	if f.MinAge > 0 {
+		query.Set("min_age", strconv.Itoa(f.MinAge))
+	}
+	if f.MaxAge > 0 {
+		query.Set("max_age", strconv.Itoa(f.MaxAge))
+	}
+	if f.Verified != nil {
+		query.Set("verified", strconv.FormatBool(*f.Verified))
+	}
+	if f.Group > 0 {
+		query.Set("group", strconv.Itoa(f.Group))
+	}
+	if f.Sort != "" {
+		query.Set("sort", f.Sort)
+		if f.Order != "" {
+			query.Set("order", f.Order)
+		}
+	}
+	for key, value := range f.Metadata {
+		query.Set("metadata."+key, value)
+	}
+	if len(f.Expand) > 0 {
+		query.Set("expand", strings.Join(f.Expand, ","))
+	}
+	return query
+}
+
+// CreateUser создает пользователя
+func (c *Client) CreateUser(ctx context.Context, req *CreateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPost, "/users", nil, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser возвращает пользователя по ID
+func (c *Client) GetUser(ctx context.Context, id int) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodGet, userPath(id), nil, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUsers возвращает страницу списка пользователей; filter может быть
+// nil. pageSize больше MaxPageSize сервер заменяет размером по умолчанию.
+func (c *Client) GetUsers(ctx context.Context, page, pageSize int, filter *UserFilter) (*UserListResponse, error) {
+	query := filter.Values()
+	query.Set("page", strconv.Itoa(page))
+	query.Set("page_size", strconv.Itoa(pageSize))
+
+	var response UserListResponse
+	if err := c.do(ctx, http.MethodGet, "/users", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateUser изменяет заданные поля пользователя
+func (c *Client) UpdateUser(ctx context.Context, id int, req *UpdateUserRequest) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPut, userPath(id), nil, req, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// DeleteUser удаляет пользователя
+func (c *Client) DeleteUser(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, userPath(id), nil, nil, nil)
+}
+
+// GetUserStats возвращает статистику пользователей по filter (сортировка
+// и expand не учитываются); filter и opts могут быть nil. Сервер кэширует
+// результат, поэтому он может отставать от данных.
+func (c *Client) GetUserStats(ctx context.Context, filter *UserFilter, opts *UserStatsOptions) (*UserStats, error) {
+	query := filter.Values()
+	if opts != nil {
+		if len(opts.AgeBuckets) > 0 {
+			bounds := make([]string, len(opts.AgeBuckets))
+			for i, bound := range opts.AgeBuckets {
+				bounds[i] = strconv.Itoa(bound)
+			}
+			query.Set("age_buckets", strings.Join(bounds, ","))
+		}
+		if opts.Interval != "" {
+			query.Set("interval", opts.Interval)
+		}
+		if opts.From != nil {
+			query.Set("from", opts.From.Format(time.RFC3339))
+		}
+		if opts.To != nil {
+			query.Set("to", opts.To.Format(time.RFC3339))
+		}
+	}
+
+	var stats UserStats
+	if err := c.do(ctx, http.MethodGet, "/users/stats", query, nil, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// VerifyEmail подтверждает email по токену из письма
+func (c *Client) VerifyEmail(ctx context.Context, token string) (*User, error) {
+	var user User
+	if err := c.do(ctx, http.MethodPost, "/verify-email", nil, &verifyEmailRequest{Token: token}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// ResendVerification повторно отправляет письмо подтверждения email
+func (c *Client) ResendVerification(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodPost, userPath(id)+"/verification", nil, nil, nil)
+}
+
+// Pages перебирает страницы списка пользователей по filter, начиная с
+// первой. Перебор останавливается после последней страницы или первой
+// ошибки, которая возвращается вместе с nil.
+//
+//	for page, err := range c.Pages(ctx, filter, 100) { ... }
+func (c *Client) Pages(ctx context.Context, filter *UserFilter, pageSize int) iter.Seq2[*UserListResponse, error] {
+	return func(yield func(*UserListResponse, error) bool) {
+		for page := 1; ; page++ {
+			response, err := c.GetUsers(ctx, page, pageSize, filter)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(response, nil) || page >= response.TotalPages || len(response.Users) == 0 {
+				return
+			}
+		}
+	}
+}
+
+// AllUsers перебирает всех пользователей по filter, загружая страницы по
+// мере необходимости. Страницы запрашиваются по номеру, поэтому создание и
+// удаление пользователей во время перебора сдвигает их; с сортировкой по
+// id новые пользователи хотя бы попадают в конец.
+func (c *Client) AllUsers(ctx context.Context, filter *UserFilter, pageSize int) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		for page, err := range c.Pages(ctx, filter, pageSize) {
+			if err != nil {
+				yield(User{}, err)
+				return
+			}
+			for _, user := range page.Users {
+				if !yield(user, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func userPath(id int) string {
+	return "/users/" + strconv.Itoa(id)
+}
@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"user-api/internal/cache"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingUserService считает обращения к GetUser и отдает имя, заданное
+// последним UpdateUser. Все пользователи принадлежат арендатору по
+// умолчанию.
+type countingUserService struct {
+	mockUserService
+	calls atomic.Int32
+	delay time.Duration
+	name  atomic.Value
+}
+
+func newCountingUserService(delay time.Duration) *countingUserService {
+	s := &countingUserService{delay: delay}
+	s.name.Store("Test User")
+	return s
+}
+
+func (s *countingUserService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	s.calls.Add(1)
+	time.Sleep(s.delay)
+	if tenantID, _ := tenant.FromContext(ctx); id == 404 || tenantID != tenant.DefaultID {
+		return nil, models.ErrUserNotFound
+	}
+	return &models.User{ID: id, TenantID: tenant.DefaultID, Name: s.name.Load().(string)}, nil
+}
+
+func (s *countingUserService) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	s.name.Store(req.Name)
+	return &models.User{ID: id, TenantID: tenant.DefaultID, Name: req.Name}, nil
+}
+
+func TestCachedUserServiceHitsAndMisses(t *testing.T) {
+	next := newCountingUserService(0)
+	svc := service.NewCachedUserService(next, cache.NewLRU(10, time.Minute))
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+
+	for i := 0; i < 3; i++ {
+		user, err := svc.GetUser(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 1, user.ID)
+	}
+
+	assert.Equal(t, int32(1), next.calls.Load())
+	assert.Equal(t, service.CacheStats{Hits: 2, Misses: 1}, svc.Stats())
+
+	// Ошибки не кэшируются
+	_, err := svc.GetUser(ctx, 404)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	_, err = svc.GetUser(ctx, 404)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	assert.Equal(t, int32(3), next.calls.Load())
+}
+
+func TestCachedUserServiceCollapsesConcurrentMisses(t *testing.T) {
+	next := newCountingUserService(50 * time.Millisecond)
+	svc := service.NewCachedUserService(next, cache.NewLRU(10, time.Minute))
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user, err := svc.GetUser(ctx, 7)
+			assert.NoError(t, err)
+			assert.Equal(t, 7, user.ID)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), next.calls.Load())
+}
+
+func TestCachedUserServiceInvalidatesOnWrite(t *testing.T) {
+	next := newCountingUserService(0)
+	svc := service.NewCachedUserService(next, cache.NewLRU(10, time.Minute))
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+
+	user, _ := svc.GetUser(ctx, 1)
+	assert.Equal(t, "Test User", user.Name)
+
+	// Изменение копии не влияет на кэш
+	user.Name = "Mutated"
+	user, _ = svc.GetUser(ctx, 1)
+	assert.Equal(t, "Test User", user.Name)
+
+	_, err := svc.UpdateUser(ctx, 1, &models.UpdateUserRequest{Name: "Renamed"})
+	require.NoError(t, err)
+	user, _ = svc.GetUser(ctx, 1)
+	assert.Equal(t, "Renamed", user.Name)
+
+	require.NoError(t, svc.DeleteUser(ctx, 1))
+	_, _ = svc.GetUser(ctx, 1)
+	assert.Equal(t, int32(3), next.calls.Load())
+}
+
+func TestCachedUserServiceIsolatesTenants(t *testing.T) {
+	next := newCountingUserService(0)
+	svc := service.NewCachedUserService(next, cache.NewLRU(10, time.Minute))
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+
+	_, err := svc.GetUser(ctx, 1)
+	require.NoError(t, err)
+
+	// Запись в кэше не видна другому арендатору
+	other := tenant.WithID(context.Background(), 2)
+	_, err = svc.GetUser(other, 1)
+	assert.ErrorIs(t, err, models.ErrUserNotFound)
+	assert.Equal(t, int32(2), next.calls.Load())
+
+	_, err = svc.GetUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), next.calls.Load())
+}
+
+func TestLRUEvictionAndTTL(t *testing.T) {
+	lru := cache.NewLRU(2, time.Minute)
+	lru.Set(1, &models.User{ID: 1})
+	lru.Set(2, &models.User{ID: 2})
+	_, _ = lru.Get(1) // 1 становится недавно использованным
+	lru.Set(3, &models.User{ID: 3})
+
+	_, ok := lru.Get(2)
+	assert.False(t, ok, "least recently used entry must be evicted")
+	_, ok = lru.Get(1)
+	assert.True(t, ok)
+	assert.Equal(t, 2, lru.Len())
+
+	short := cache.NewLRU(2, 10*time.Millisecond)
+	short.Set(1, &models.User{ID: 1})
+	time.Sleep(20 * time.Millisecond)
+	_, ok = short.Get(1)
+	assert.False(t, ok, "expired entry must not be returned")
+}
@@ -0,0 +1,270 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/build"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"user-api/internal/models"
+	"user-api/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingProxy пропускает запросы к next, запоминая их заголовки, и
+// отвечает failures[i] на i-й запрос, пока failures не кончатся
+type recordingProxy struct {
+	next     http.Handler
+	mu       sync.Mutex
+	requests []*http.Request
+	failures []int
+}
+
+func (p *recordingProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.mu.Lock()
+	p.requests = append(p.requests, r.Clone(context.Background()))
+	var status int
+	if len(p.failures) > 0 {
+		status, p.failures = p.failures[0], p.failures[1:]
+	}
+	p.mu.Unlock()
+
+	switch status {
+	case 0:
+		p.next.ServeHTTP(w, r)
+	case http.StatusTooManyRequests:
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(status)
+	default:
+		w.WriteHeader(status)
+	}
+}
+
+func (p *recordingProxy) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.requests)
+}
+
+// startClientAPI запускает API пользователей за recordingProxy и
+// возвращает клиент к нему
+func startClientAPI(t *testing.T, users *memUserService, opts ...client.Option) (*client.Client, *recordingProxy) {
+	t.Helper()
+
+	router := setupTestRouter()
+	startUserRoutes(router.Group("/api/v1"), users)
+	proxy := &recordingProxy{next: router}
+	server := httptest.NewServer(proxy)
+	t.Cleanup(server.Close)
+
+	opts = append([]client.Option{
+		client.WithRetry(client.RetryPolicy{MaxRetries: 3, BaseDelay: time.Millisecond, MaxDelay: 50 * time.Millisecond}),
+	}, opts...)
+	c, err := client.New(server.URL, opts...)
+	require.NoError(t, err)
+	return c, proxy
+}
+
+func TestClientUsers(t *testing.T) {
+	c, proxy := startClientAPI(t, &memUserService{})
+	ctx := context.Background()
+
+	user, err := c.CreateUser(ctx, &client.CreateUserRequest{
+		Name: "Jane Doe", Email: "jane@example.com", Age: 30,
+		Metadata: client.Metadata{"department": []byte(`"sales"`)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+
+	user, err = c.GetUser(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", user.Email)
+
+	user, err = c.UpdateUser(ctx, 1, &client.UpdateUserRequest{Age: 31})
+	require.NoError(t, err)
+	assert.Equal(t, 31, user.Age)
+
+	verified := false
+	list, err := c.GetUsers(ctx, 1, 10, &client.UserFilter{
+		MinAge: 18, Verified: &verified, Sort: "name", Order: "desc",
+		Metadata: map[string]string{"department": "sales"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, list.Total)
+	query := proxy.requests[len(proxy.requests)-1].URL.Query()
+	assert.Equal(t, "18", query.Get("min_age"))
+	assert.Equal(t, "false", query.Get("verified"))
+	assert.Equal(t, "desc", query.Get("order"))
+	assert.Equal(t, "sales", query.Get("metadata.department"))
+
+	require.NoError(t, c.DeleteUser(ctx, 1))
+	_, err = c.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func TestClientErrors(t *testing.T) {
+	c, proxy := startClientAPI(t, &memUserService{})
+	ctx := context.Background()
+
+	_, err := c.CreateUser(ctx, &client.CreateUserRequest{Name: "Jane", Email: "jane@example.com", Age: 30})
+	require.NoError(t, err)
+
+	_, err = c.CreateUser(ctx, &client.CreateUserRequest{Name: "Jane", Email: "JANE@example.com", Age: 30})
+	assert.ErrorIs(t, err, client.ErrConflict)
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+	assert.Equal(t, "Failed to create user", apiErr.Code)
+	assert.Equal(t, models.ErrEmailTaken.Error(), apiErr.Message)
+
+	_, err = c.CreateUser(ctx, &client.CreateUserRequest{Name: "J", Email: "j@example.com", Age: 30})
+	assert.ErrorIs(t, err, client.ErrInvalidRequest)
+	assert.False(t, errors.Is(err, client.ErrServer))
+
+	// Ошибки 4xx не повторяются
+	before := proxy.count()
+	_, err = c.GetUsers(ctx, 1, 10, &client.UserFilter{Metadata: map[string]string{"Bad-Key": "1"}})
+	assert.ErrorIs(t, err, client.ErrInvalidRequest)
+	assert.Equal(t, before+1, proxy.count())
+
+	_, err = client.New("localhost:8080")
+	assert.Error(t, err)
+}
+
+func TestClientRetries(t *testing.T) {
+	c, proxy := startClientAPI(t, &memUserService{})
+	ctx := context.Background()
+
+	// Повтор POST идет с тем же Idempotency-Key
+	proxy.failures = []int{http.StatusServiceUnavailable, http.StatusBadGateway}
+	user, err := c.CreateUser(ctx, &client.CreateUserRequest{Name: "Jane", Email: "jane@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, 1, user.ID)
+	require.Len(t, proxy.requests, 3)
+	key := proxy.requests[0].Header.Get("Idempotency-Key")
+	assert.NotEmpty(t, key)
+	for _, req := range proxy.requests {
+		assert.Equal(t, key, req.Header.Get("Idempotency-Key"))
+	}
+
+	// После MaxRetries повторов возвращается последняя ошибка
+	proxy.failures = []int{500, 500, 500, 500, 500}
+	_, err = c.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, client.ErrServer)
+	assert.Equal(t, 3+4, proxy.count())
+	proxy.failures = nil
+
+	// Retry-After больше MaxDelay - ошибка возвращается сразу
+	proxy.failures = []int{http.StatusTooManyRequests}
+	_, err = c.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, client.ErrRateLimited)
+	var apiErr *client.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 10*time.Second, apiErr.RetryAfter)
+	assert.Equal(t, 3+4+1, proxy.count())
+
+	// Отмена контекста прерывает ожидание повтора
+	slow, proxy := startClientAPI(t, &memUserService{},
+		client.WithRetry(client.RetryPolicy{MaxRetries: 5, BaseDelay: time.Minute, MaxDelay: time.Minute}))
+	proxy.failures = []int{http.StatusServiceUnavailable}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = slow.GetUser(ctx, 1)
+	assert.ErrorIs(t, err, client.ErrServer)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestClientHeaders(t *testing.T) {
+	tokens := 0
+	c, proxy := startClientAPI(t, &memUserService{},
+		client.WithTokenSource(func(context.Context) (string, error) {
+			tokens++
+			return fmt.Sprintf("token-%d", tokens), nil
+		}),
+		client.WithTenant(7),
+		client.WithTenantHeader("X-Org-ID"),
+		client.WithLanguage("ru"),
+		client.WithUserAgent("billing-service"),
+	)
+
+	_, _ = c.GetUser(context.Background(), 1)
+	_, _ = c.GetUser(context.Background(), 1)
+	require.Len(t, proxy.requests, 2)
+	req := proxy.requests[1]
+	assert.Equal(t, "Bearer token-2", req.Header.Get("Authorization"))
+	assert.Equal(t, "7", req.Header.Get("X-Org-ID"))
+	assert.Equal(t, "ru", req.Header.Get("Accept-Language"))
+	assert.Equal(t, "billing-service", req.Header.Get("User-Agent"))
+	assert.Empty(t, req.Header.Get("Idempotency-Key"))
+
+	failing, _ := startClientAPI(t, &memUserService{},
+		client.WithTokenSource(func(context.Context) (string, error) { return "", errors.New("expired") }))
+	_, err := failing.GetUser(context.Background(), 1)
+	assert.ErrorContains(t, err, "expired")
+}
+
+func TestClientPagination(t *testing.T) {
+	users := &memUserService{}
+	for i := 0; i < 25; i++ {
+		_, err := users.CreateUser(context.Background(), &models.CreateUserRequest{
+			Name: "User", Email: fmt.Sprintf("user%d@example.com", i), Age: 20,
+		})
+		require.NoError(t, err)
+	}
+	c, proxy := startClientAPI(t, users)
+	ctx := context.Background()
+	filter := &client.UserFilter{Sort: "id"}
+
+	var pages []int
+	for page, err := range c.Pages(ctx, filter, 10) {
+		require.NoError(t, err)
+		pages = append(pages, len(page.Users))
+	}
+	assert.Equal(t, []int{10, 10, 5}, pages)
+
+	var ids []int
+	for user, err := range c.AllUsers(ctx, filter, 10) {
+		require.NoError(t, err)
+		ids = append(ids, user.ID)
+	}
+	require.Len(t, ids, 25)
+	assert.Equal(t, 25, ids[24])
+
+	// Перебор, прерванный на первой странице, не загружает следующие
+	before := proxy.count()
+	for range c.AllUsers(ctx, filter, 10) {
+		break
+	}
+	assert.Equal(t, before+1, proxy.count())
+
+	// Ошибка завершает перебор
+	proxy.failures = []int{0, 500, 500, 500, 500}
+	var seen int
+	var iterErr error
+	for _, err := range c.AllUsers(ctx, filter, 10) {
+		if err != nil {
+			iterErr = err
+			continue
+		}
+		seen++
+	}
+	assert.Equal(t, 10, seen)
+	assert.ErrorIs(t, iterErr, client.ErrServer)
+}
+
+func TestClientDoesNotImportInternalPackages(t *testing.T) {
+	// Типы SDK не должны зависеть от внутренних структур сервера
+	pkg, err := build.ImportDir("../pkg/client", 0)
+	require.NoError(t, err)
+	for _, path := range pkg.Imports {
+		assert.False(t, strings.HasPrefix(path, "user-api/internal/"), "pkg/client imports %s", path)
+	}
+}
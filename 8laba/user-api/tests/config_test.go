@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"user-api/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfigDefaults(t *testing.T) {
+	cfg, printConfig, err := config.Load(nil)
+	require.NoError(t, err)
+
+	assert.False(t, printConfig)
+	assert.Equal(t, 8080, cfg.Server.Port)
+	assert.Equal(t, "disable", cfg.Database.SSLMode)
+	assert.Equal(t, 25, cfg.Database.Pool.MaxOpenConns)
+	assert.Equal(t, 5*time.Minute, cfg.Database.Pool.ConnMaxLifetime.Std())
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	file := writeFile(t, "config.yaml", `
+server:
+  port: 9000
+database:
+  host: file-host
+  name: file-db
+  pool:
+    max_open_conns: 50
+`)
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("SERVER_PORT", "9100")
+
+	cfg, _, err := config.Load([]string{"-config", file, "-server.port", "9200"})
+	require.NoError(t, err)
+
+	assert.Equal(t, 9200, cfg.Server.Port, "flag overrides env")
+	assert.Equal(t, "env-host", cfg.Database.Host, "env overrides file")
+	assert.Equal(t, "file-db", cfg.Database.Name, "file overrides default")
+	assert.Equal(t, 50, cfg.Database.Pool.MaxOpenConns)
+	assert.Equal(t, "postgres", cfg.Database.User, "default kept")
+}
+
+func TestConfigTOMLFile(t *testing.T) {
+	file := writeFile(t, "config.toml", `
+[database]
+sslmode = "require"
+
+[database.pool]
+conn_max_lifetime = "1m"
+`)
+
+	cfg, _, err := config.Load([]string{"-config", file})
+	require.NoError(t, err)
+
+	assert.Equal(t, "require", cfg.Database.SSLMode)
+	assert.Equal(t, time.Minute, cfg.Database.Pool.ConnMaxLifetime.Std())
+}
+
+func TestConfigSecretFromFile(t *testing.T) {
+	secret := writeFile(t, "db_password", "s3cret\n")
+	t.Setenv("DB_PASSWORD", "ignored")
+	t.Setenv("DB_PASSWORD_FILE", secret)
+
+	cfg, _, err := config.Load(nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "s3cret", cfg.Database.Password)
+	assert.NotContains(t, cfg.String(), "s3cret")
+	assert.Equal(t, "s3cret", cfg.Database.Password, "redaction must not modify the original")
+}
+
+func TestConfigValidation(t *testing.T) {
+	t.Setenv("DB_SSLMODE", "sometimes")
+	t.Setenv("LOG_LEVEL", "loud")
+
+	_, _, err := config.Load([]string{"-server.port", "70000"})
+	require.Error(t, err)
+
+	msg := err.Error()
+	assert.True(t, strings.Contains(msg, "server.port"), msg)
+	assert.True(t, strings.Contains(msg, "database.sslmode"), msg)
+	assert.True(t, strings.Contains(msg, "log.level"), msg)
+}
+
+func TestConfigSystemRole(t *testing.T) {
+	t.Setenv("TENANCY_ENABLED", "true")
+	t.Setenv("TENANCY_TRUSTED_NETWORKS", "10.0.0.0/8")
+	t.Setenv("VERIFICATION_ENABLED", "true")
+	t.Setenv("VERIFICATION_SECRET", "test-secret-that-is-at-least-32-bytes")
+
+	// Роль приложения при разделении не обходит RLS
+	_, _, err := config.Load(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database.system.user: required")
+
+	t.Setenv("DB_SYSTEM_USER", "postgres")
+	_, _, err = config.Load(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "database.system.user: must differ")
+
+	t.Setenv("DB_SYSTEM_USER", "user_api_system")
+	t.Setenv("DB_SYSTEM_PASSWORD", "system-secret")
+	cfg, _, err := config.Load(nil)
+	require.NoError(t, err)
+	system := cfg.Database.SystemDB()
+	assert.Equal(t, "user_api_system", system.User)
+	assert.Equal(t, "system-secret", system.Password)
+	assert.Equal(t, cfg.Database.Name, system.DBName)
+}
+
+func TestConfigUnknownFileKey(t *testing.T) {
+	file := writeFile(t, "config.yaml", "server:\n  prot: 9000\n")
+
+	_, _, err := config.Load([]string{"-config", file})
+	assert.Error(t, err)
+}
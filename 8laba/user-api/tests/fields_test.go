@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-api/internal/handlers"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memListRepo реализует GetAll и GetGroups из UserRepository и запоминает
+// фильтры запроса списка
+type memListRepo struct {
+	repository.UserRepository
+	users       []models.User
+	groups      map[int][]models.UserGroup
+	filters     map[string]interface{}
+	groupCalls  int
+	groupLookup []int
+}
+
+func (r *memListRepo) GetAll(ctx context.Context, page, pageSize int, filters map[string]interface{}) ([]models.User, int, error) {
+	r.filters = filters
+	users := make([]models.User, len(r.users))
+	copy(users, r.users)
+	return users, len(users), nil
+}
+
+func (r *memListRepo) GetGroups(ctx context.Context, userIDs []int) (map[int][]models.UserGroup, error) {
+	r.groupCalls++
+	r.groupLookup = userIDs
+	return r.groups, nil
+}
+
+func newMemListRepo() *memListRepo {
+	return &memListRepo{
+		users: []models.User{
+			{ID: 1, Name: "Alice", Email: "alice@example.com", Age: 30},
+			{ID: 2, Name: "Bob", Email: "bob@example.com", Age: 40},
+		},
+		groups: map[int][]models.UserGroup{
+			1: {{ID: 7, Name: "Backend", Role: models.GroupRoleOwner}},
+		},
+	}
+}
+
+func getUserList(t *testing.T, repo *memListRepo, query string) (int, map[string]json.RawMessage) {
+	router := setupTestRouter()
+	router.GET("/users", handlers.NewUserHandler(service.NewUserService(repo, nil, nil)).GetUsers)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users"+query, nil))
+
+	var body map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return w.Code, body
+}
+
+func TestParseUserFields(t *testing.T) {
+	fields, err := models.ParseUserFields(" name,email,,name ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"name", "email"}, fields)
+
+	fields, err = models.ParseUserFields("")
+	require.NoError(t, err)
+	assert.Empty(t, fields)
+
+	_, err = models.ParseUserFields("name,password_hash")
+	assert.ErrorIs(t, err, models.ErrUnknownField)
+
+	_, err = models.ParseUserExpand("groups,audit")
+	assert.ErrorIs(t, err, models.ErrUnknownExpansion)
+}
+
+func TestGetUsersSparseFields(t *testing.T) {
+	repo := newMemListRepo()
+	code, body := getUserList(t, repo, "?fields=id,name,email_verified_at")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"id", "name", "email_verified_at"}, repo.filters["fields"])
+	assert.Zero(t, repo.groupCalls)
+
+	var users []map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(body["users"], &users))
+	require.Len(t, users, 2)
+	assert.JSONEq(t, `{"id": 1, "name": "Alice", "email_verified_at": null}`, mustJSON(t, users[0]))
+	assert.JSONEq(t, `2`, string(body["total"]))
+}
+
+func TestGetUsersExpandGroups(t *testing.T) {
+	repo := newMemListRepo()
+	code, body := getUserList(t, repo, "?fields=name&expand=groups")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1, repo.groupCalls)
+	assert.Equal(t, []int{1, 2}, repo.groupLookup)
+
+	assert.JSONEq(t, `[
+		{"name": "Alice", "groups": [{"id": 7, "name": "Backend", "role": "owner"}]},
+		{"name": "Bob", "groups": []}
+	]`, string(body["users"]))
+}
+
+func TestGetUsersWithoutFieldsReturnsFullUsers(t *testing.T) {
+	repo := newMemListRepo()
+	code, body := getUserList(t, repo, "")
+	require.Equal(t, http.StatusOK, code)
+	assert.NotContains(t, repo.filters, "fields")
+
+	var users []models.User
+	require.NoError(t, json.Unmarshal(body["users"], &users))
+	assert.Equal(t, "alice@example.com", users[0].Email)
+	assert.Nil(t, users[0].Groups)
+}
+
+func TestGetUsersRejectsUnknownFields(t *testing.T) {
+	code, body := getUserList(t, newMemListRepo(), "?fields=name,password_hash")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, string(body["message"]), "password_hash")
+
+	code, _ = getUserList(t, newMemListRepo(), "?expand=audit")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(data)
+}
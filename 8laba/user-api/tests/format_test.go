@@ -0,0 +1,193 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"user-api/internal/format"
+	"user-api/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/ugorji/go/codec"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	cases := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", format.JSON, true},
+		{"*/*", format.JSON, true},
+		{"application/xml", format.XML, true},
+		{"text/xml", format.XML, true},
+		{"application/x-msgpack", format.MsgPack, true},
+		{"text/*", format.CSV, true},
+		{"application/json;q=0.5, text/csv", format.CSV, true},
+		{"text/csv;q=0.1, application/xml;q=0.9", format.XML, true},
+		{"text/html", "", false},
+		{"application/json;q=0", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := format.Negotiate(tc.accept, format.List)
+		assert.Equal(t, tc.ok, ok, tc.accept)
+		assert.Equal(t, tc.want, got, tc.accept)
+	}
+
+	_, ok := format.Negotiate("text/csv", format.Object)
+	assert.False(t, ok)
+}
+
+func requestUsers(t *testing.T, method, path, contentType, accept string, body []byte) *httptest.ResponseRecorder {
+	router := setupValidatedRouter(t)
+	req := httptest.NewRequest(method, path, bytes.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", accept)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGetUserAsXML(t *testing.T) {
+	w := requestUsers(t, http.MethodGet, "/api/v1/users/7", "", "application/xml", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+
+	var user struct {
+		XMLName xml.Name `xml:"user"`
+		ID      int      `xml:"id"`
+		Name    string   `xml:"name"`
+		Email   string   `xml:"email"`
+	}
+	require.NoError(t, xml.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, 7, user.ID)
+	assert.Equal(t, "test@example.com", user.Email)
+}
+
+func TestGetUsersAsMsgPack(t *testing.T) {
+	w := requestUsers(t, http.MethodGet, "/api/v1/users", "", "application/msgpack", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/msgpack", w.Header().Get("Content-Type"))
+
+	var list struct {
+		Users []struct {
+			ID   int    `codec:"id"`
+			Name string `codec:"name"`
+		} `codec:"users"`
+		Total int `codec:"total"`
+	}
+	require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), &codec.MsgpackHandle{}).Decode(&list))
+	assert.Equal(t, 1, list.Total)
+	require.Len(t, list.Users, 1)
+	assert.Equal(t, "User 1", list.Users[0].Name)
+}
+
+func TestGetUsersAsCSV(t *testing.T) {
+	w := requestUsers(t, http.MethodGet, "/api/v1/users?fields=id,name,email_verified_at", "", "text/csv", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "1", w.Header().Get("X-Total-Count"))
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"id", "name", "email_verified_at"},
+		{"1", "User 1", ""},
+	}, records)
+}
+
+func TestCSVEscapesFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	rows := []map[string]json.RawMessage{{
+		"name":     json.RawMessage(`"=HYPERLINK(\"http://evil\")"`),
+		"age":      json.RawMessage(`-5`),
+		"metadata": json.RawMessage(`{"team":"core"}`),
+	}}
+	require.NoError(t, format.EncodeCSV(&buf, []string{"name", "age", "metadata"}, rows))
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{`'=HYPERLINK("http://evil")`, "-5", `{"team":"core"}`}, records[1])
+}
+
+func TestUnsupportedAcceptReturns406(t *testing.T) {
+	w := requestUsers(t, http.MethodGet, "/api/v1/users/1", "", "text/csv", nil)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	w = requestUsers(t, http.MethodGet, "/api/v1/users", "", "text/html", nil)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+	assert.Contains(t, w.Body.String(), "text/csv")
+}
+
+func TestCreateUserFromXML(t *testing.T) {
+	body := `<user><name>Jane Doe</name><email>jane@example.com</email><age>28</age></user>`
+	w := requestUsers(t, http.MethodPost, "/api/v1/users", "application/xml", "application/json", []byte(body))
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var user models.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.Equal(t, "Jane Doe", user.Name)
+	assert.Equal(t, 28, user.Age)
+
+	// Теги binding проверяются и для XML
+	body = `<user><name>J</name><email>jane@example.com</email><age>28</age></user>`
+	w = requestUsers(t, http.MethodPost, "/api/v1/users", "text/xml", "application/xml", []byte(body))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "<?xml"))
+}
+
+func TestCreateUserFromMsgPack(t *testing.T) {
+	var body []byte
+	require.NoError(t, codec.NewEncoderBytes(&body, &codec.MsgpackHandle{WriteExt: true}).Encode(map[string]interface{}{
+		"name": "Jane Doe", "email": "jane@example.com", "age": 28,
+	}))
+	w := requestUsers(t, http.MethodPost, "/api/v1/users", "application/msgpack", "application/msgpack", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+	var user map[string]interface{}
+	h := &codec.MsgpackHandle{}
+	h.RawToString = true
+	require.NoError(t, codec.NewDecoderBytes(w.Body.Bytes(), h).Decode(&user))
+	assert.Equal(t, "jane@example.com", user["email"])
+}
+
+func TestMetadataFromXML(t *testing.T) {
+	body := `<user>
+		<name>Jane</name>
+		<metadata>
+			<department>sales</department>
+			<level>3</level>
+			<code>"007"</code>
+			<entry key="team.lead">true</entry>
+			<manager nil="true"/>
+		</metadata>
+	</user>`
+	var req models.UpdateUserRequest
+	require.NoError(t, xml.Unmarshal([]byte(body), &req))
+	assert.JSONEq(t, `{"department": "sales", "level": 3, "code": "007", "team.lead": true, "manager": null}`, mustJSON(t, req.Metadata))
+
+	err := xml.Unmarshal([]byte(`<user><metadata><address><city>Riga</city></address></metadata></user>`), &req)
+	assert.ErrorIs(t, err, models.ErrInvalidMetadata)
+}
+
+func TestEncodeXMLArraysAndNulls(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, format.EncodeXML(&buf, "user", models.User{
+		ID:       1,
+		Metadata: models.Metadata{"skills": json.RawMessage(`["go","sql"]`), "2fa": json.RawMessage(`null`)},
+		Groups:   []models.UserGroup{{ID: 2, Name: "Backend", Role: "member"}},
+	}))
+	out := buf.String()
+	assert.Contains(t, out, `<groups><group><id>2</id><name>Backend</name><role>member</role></group></groups>`)
+	assert.Contains(t, out, `<skills><skill>go</skill><skill>sql</skill></skills>`)
+	assert.Contains(t, out, `<entry key="2fa" nil="true"></entry>`)
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-api/internal/graphqlapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type graphqlResponse struct {
+	Data   map[string]interface{}   `json:"data"`
+	Errors []map[string]interface{} `json:"errors"`
+}
+
+func setupGraphQLRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+
+	schema, err := graphqlapi.NewSchema(&mockUserService{}, nil)
+	require.NoError(t, err)
+	handler := graphqlapi.NewHandler(schema, graphqlapi.Limits{MaxDepth: 4, MaxComplexity: 100})
+
+	router := setupTestRouter()
+	router.GET("/graphql", handler.Query)
+	router.POST("/graphql", handler.Query)
+	return router
+}
+
+func doGraphQL(t *testing.T, router *gin.Engine, query string, variables map[string]interface{}) (int, graphqlResponse) {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	req, _ := http.NewRequest("POST", "/graphql", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp graphqlResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return w.Code, resp
+}
+
+func TestGraphQLUsersQuery(t *testing.T) {
+	router := setupGraphQLRouter(t)
+
+	code, resp := doGraphQL(t, router, `
+		query($size: Int) {
+			users(filter: {minAge: 18}, sort: {field: NAME, direction: DESC}, pageSize: $size) {
+				total
+				pageSize
+				users { id email }
+			}
+		}`, map[string]interface{}{"size": 5})
+
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+	users := resp.Data["users"].(map[string]interface{})
+	assert.Equal(t, float64(1), users["total"])
+	assert.Equal(t, float64(5), users["pageSize"])
+}
+
+func TestGraphQLCreateUserMutation(t *testing.T) {
+	router := setupGraphQLRouter(t)
+
+	code, resp := doGraphQL(t, router, `
+		mutation {
+			createUser(input: {name: "Test User", email: "test@example.com", age: 25}) { id email }
+		}`, nil)
+
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, resp.Errors)
+	user := resp.Data["createUser"].(map[string]interface{})
+	assert.Equal(t, "test@example.com", user["email"])
+}
+
+func TestGraphQLCreateUserValidation(t *testing.T) {
+	router := setupGraphQLRouter(t)
+
+	_, resp := doGraphQL(t, router, `
+		mutation {
+			createUser(input: {name: "T", email: "broken", age: 25}) { id }
+		}`, nil)
+
+	assert.NotEmpty(t, resp.Errors)
+}
+
+func TestGraphQLComplexityLimit(t *testing.T) {
+	router := setupGraphQLRouter(t)
+
+	code, resp := doGraphQL(t, router, `
+		query {
+			users(pageSize: 100) { users { id name email age createdAt } }
+		}`, nil)
+
+	assert.Equal(t, http.StatusBadRequest, code)
+	require.NotEmpty(t, resp.Errors)
+	assert.Contains(t, resp.Errors[0]["message"], "complexity")
+}
+
+func TestGraphQLDepthLimit(t *testing.T) {
+	router := setupGraphQLRouter(t)
+
+	code, resp := doGraphQL(t, router, `
+		query {
+			users { ...page }
+		}
+		fragment page on UserPage { users { ... on User { id } } total }`, nil)
+	assert.Equal(t, http.StatusOK, code, "depth 3 is allowed")
+	assert.Empty(t, resp.Errors)
+
+	schema, err := graphqlapi.NewSchema(&mockUserService{}, nil)
+	require.NoError(t, err)
+	strict := setupTestRouter()
+	strict.POST("/graphql", graphqlapi.NewHandler(schema, graphqlapi.Limits{MaxDepth: 2, MaxComplexity: 100}).Query)
+
+	code, resp = doGraphQL(t, strict, `query { users { users { id } } }`, nil)
+	assert.Equal(t, http.StatusBadRequest, code)
+	require.NotEmpty(t, resp.Errors)
+	assert.Contains(t, resp.Errors[0]["message"], "depth")
+}
+
+func TestGraphQLIntrospectionIgnoresLimits(t *testing.T) {
+	router := setupGraphQLRouter(t)
+
+	code, resp := doGraphQL(t, router, `
+		query {
+			__schema { types { name fields { name type { name ofType { name ofType { name } } } } } }
+		}`, nil)
+
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, resp.Errors)
+}
+
+func TestGraphQLMutationViaGetRejected(t *testing.T) {
+	router := setupGraphQLRouter(t)
+
+	req, _ := http.NewRequest("GET", `/graphql?query=mutation{deleteUser(id:1)}`, nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
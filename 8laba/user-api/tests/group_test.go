@@ -0,0 +1,299 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+	"user-api/internal/handlers"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memGroupRepo - GroupRepository в памяти
+type memGroupRepo struct {
+	mu      sync.Mutex
+	nextID  int
+	users   map[int]models.User
+	groups  map[int]*models.Group
+	members map[int]map[int]*models.GroupMember
+}
+
+func newMemGroupRepo(users ...models.User) *memGroupRepo {
+	r := &memGroupRepo{
+		users:   map[int]models.User{},
+		groups:  map[int]*models.Group{},
+		members: map[int]map[int]*models.GroupMember{},
+	}
+	for _, u := range users {
+		r.users[u.ID] = u
+	}
+	return r
+}
+
+func (r *memGroupRepo) nameTaken(name string, except int) bool {
+	for id, g := range r.groups {
+		if g.Name == name && id != except {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *memGroupRepo) Create(ctx context.Context, req *models.CreateGroupRequest) (*models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.nameTaken(req.Name, 0) {
+		return nil, models.ErrGroupNameTaken
+	}
+	r.nextID++
+	now := time.Now()
+	g := &models.Group{ID: r.nextID, Name: req.Name, Description: req.Description, CreatedAt: now, UpdatedAt: now}
+	r.groups[g.ID] = g
+	r.members[g.ID] = map[int]*models.GroupMember{}
+	copied := *g
+	return &copied, nil
+}
+
+func (r *memGroupRepo) GetByID(ctx context.Context, id int) (*models.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[id]
+	if !ok {
+		return nil, models.ErrGroupNotFound
+	}
+	copied := *g
+	copied.MemberCount = len(r.members[id])
+	return &copied, nil
+}
+
+func (r *memGroupRepo) GetAll(ctx context.Context, page, pageSize int) ([]models.Group, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	groups := []models.Group{}
+	for id, g := range r.groups {
+		copied := *g
+		copied.MemberCount = len(r.members[id])
+		groups = append(groups, copied)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return paginate(groups, page, pageSize), len(groups), nil
+}
+
+func (r *memGroupRepo) Update(ctx context.Context, id int, req *models.UpdateGroupRequest) (*models.Group, error) {
+	r.mu.Lock()
+	g, ok := r.groups[id]
+	if !ok {
+		r.mu.Unlock()
+		return nil, models.ErrGroupNotFound
+	}
+	if req.Name != "" {
+		if r.nameTaken(req.Name, id) {
+			r.mu.Unlock()
+			return nil, models.ErrGroupNameTaken
+		}
+		g.Name = req.Name
+	}
+	if req.Description != nil {
+		g.Description = *req.Description
+	}
+	r.mu.Unlock()
+	return r.GetByID(ctx, id)
+}
+
+func (r *memGroupRepo) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[id]; !ok {
+		return models.ErrGroupNotFound
+	}
+	delete(r.groups, id)
+	delete(r.members, id)
+	return nil
+}
+
+func (r *memGroupRepo) AddMember(ctx context.Context, groupID, userID int, role string) (*models.GroupMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[groupID]; !ok {
+		return nil, models.ErrGroupNotFound
+	}
+	user, ok := r.users[userID]
+	if !ok {
+		return nil, models.ErrUserNotFound
+	}
+	if _, ok := r.members[groupID][userID]; ok {
+		return nil, models.ErrAlreadyGroupMember
+	}
+	m := &models.GroupMember{GroupID: groupID, UserID: userID, Name: user.Name, Email: user.Email, Role: role}
+	r.members[groupID][userID] = m
+	copied := *m
+	return &copied, nil
+}
+
+func (r *memGroupRepo) GetMembers(ctx context.Context, groupID, page, pageSize int) ([]models.GroupMember, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	members := []models.GroupMember{}
+	for _, m := range r.members[groupID] {
+		members = append(members, *m)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+	return paginate(members, page, pageSize), len(members), nil
+}
+
+func (r *memGroupRepo) UpdateMember(ctx context.Context, groupID, userID int, role string) (*models.GroupMember, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m, ok := r.members[groupID][userID]
+	if !ok {
+		return nil, models.ErrMemberNotFound
+	}
+	m.Role = role
+	copied := *m
+	return &copied, nil
+}
+
+func (r *memGroupRepo) RemoveMember(ctx context.Context, groupID, userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.members[groupID][userID]; !ok {
+		return models.ErrMemberNotFound
+	}
+	delete(r.members[groupID], userID)
+	return nil
+}
+
+func paginate[T any](items []T, page, pageSize int) []T {
+	start := min((page-1)*pageSize, len(items))
+	return items[start:min(start+pageSize, len(items))]
+}
+
+func setupGroupRouter() *gin.Engine {
+	repo := newMemGroupRepo(
+		models.User{ID: 1, Name: "Alice", Email: "alice@example.com"},
+		models.User{ID: 2, Name: "Bob", Email: "bob@example.com"},
+	)
+	handler := handlers.NewGroupHandler(service.NewGroupService(repo))
+
+	router := setupTestRouter()
+	groups := router.Group("/api/v1/groups")
+	groups.GET("", handler.GetGroups)
+	groups.GET("/:id", handler.GetGroup)
+	groups.POST("", handler.CreateGroup)
+	groups.PUT("/:id", handler.UpdateGroup)
+	groups.DELETE("/:id", handler.DeleteGroup)
+	groups.GET("/:id/members", handler.GetMembers)
+	groups.POST("/:id/members", handler.AddMember)
+	groups.PUT("/:id/members/:user_id", handler.UpdateMember)
+	groups.DELETE("/:id/members/:user_id", handler.RemoveMember)
+	return router
+}
+
+func doJSON(router http.Handler, method, url string, body interface{}) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req, _ := http.NewRequest(method, url, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestGroupCRUD(t *testing.T) {
+	router := setupGroupRouter()
+
+	w := doJSON(router, "POST", "/api/v1/groups", map[string]string{"name": "Backend", "description": "API"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var group models.Group
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+	assert.Equal(t, "Backend", group.Name)
+
+	assert.Equal(t, http.StatusConflict, doJSON(router, "POST", "/api/v1/groups", map[string]string{"name": "Backend"}).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON(router, "POST", "/api/v1/groups", map[string]string{"name": "B"}).Code)
+
+	w = doJSON(router, "PUT", "/api/v1/groups/1", map[string]string{"description": ""})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+	assert.Equal(t, "Backend", group.Name)
+	assert.Empty(t, group.Description)
+
+	doJSON(router, "POST", "/api/v1/groups", map[string]string{"name": "Frontend"})
+	assert.Equal(t, http.StatusConflict, doJSON(router, "PUT", "/api/v1/groups/2", map[string]string{"name": "Backend"}).Code)
+
+	w = doJSON(router, "GET", "/api/v1/groups?page_size=1", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list models.GroupListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 2, list.Total)
+	assert.Equal(t, 2, list.TotalPages)
+	require.Len(t, list.Groups, 1)
+	assert.Equal(t, "Backend", list.Groups[0].Name)
+
+	assert.Equal(t, http.StatusNoContent, doJSON(router, "DELETE", "/api/v1/groups/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", "/api/v1/groups/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, "DELETE", "/api/v1/groups/1", nil).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON(router, "GET", "/api/v1/groups/abc", nil).Code)
+}
+
+func TestGroupMembers(t *testing.T) {
+	router := setupGroupRouter()
+	doJSON(router, "POST", "/api/v1/groups", map[string]string{"name": "Backend"})
+
+	w := doJSON(router, "POST", "/api/v1/groups/1/members", map[string]interface{}{"user_id": 1})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var member models.GroupMember
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &member))
+	assert.Equal(t, models.GroupRoleMember, member.Role)
+	assert.Equal(t, "Alice", member.Name)
+
+	w = doJSON(router, "POST", "/api/v1/groups/1/members", map[string]interface{}{"user_id": 2, "role": "owner"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	assert.Equal(t, http.StatusConflict, doJSON(router, "POST", "/api/v1/groups/1/members", map[string]interface{}{"user_id": 1}).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, "POST", "/api/v1/groups/1/members", map[string]interface{}{"user_id": 99}).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, "POST", "/api/v1/groups/9/members", map[string]interface{}{"user_id": 1}).Code)
+	assert.Equal(t, http.StatusBadRequest, doJSON(router, "POST", "/api/v1/groups/1/members", map[string]interface{}{"user_id": 1, "role": "god"}).Code)
+
+	w = doJSON(router, "PUT", "/api/v1/groups/1/members/1", map[string]string{"role": "admin"})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &member))
+	assert.Equal(t, models.GroupRoleAdmin, member.Role)
+
+	w = doJSON(router, "GET", "/api/v1/groups/1/members", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var members models.GroupMemberListResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &members))
+	assert.Equal(t, 2, members.Total)
+
+	w = doJSON(router, "GET", "/api/v1/groups/1", nil)
+	var group models.Group
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &group))
+	assert.Equal(t, 2, group.MemberCount)
+
+	assert.Equal(t, http.StatusNoContent, doJSON(router, "DELETE", "/api/v1/groups/1/members/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, "DELETE", "/api/v1/groups/1/members/1", nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, "PUT", "/api/v1/groups/1/members/1", map[string]string{"role": "admin"}).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(router, "GET", "/api/v1/groups/9/members", nil).Code)
+}
+
+func TestGetUsersGroupFilter(t *testing.T) {
+	svc := &filterCapturingService{}
+	router := setupTestRouter()
+	router.GET("/api/v1/users", handlers.NewUserHandler(svc).GetUsers)
+
+	w := doJSON(router, "GET", "/api/v1/users?group=3", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 3, svc.filters["group"])
+}
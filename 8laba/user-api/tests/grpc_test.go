@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"user-api/internal/certs"
+	"user-api/internal/grpcapi"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/tenant"
+	userv1 "user-api/pkg/pb/user/v1"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// notFoundUserService возвращает ErrUserNotFound для любого пользователя
+type notFoundUserService struct {
+	mockUserService
+}
+
+func (m *notFoundUserService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	return nil, models.ErrUserNotFound
+}
+
+func setupGRPCClient(t *testing.T, svc service.UserService) userv1.UserServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpcapi.NewServer(svc, nil, true)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return userv1.NewUserServiceClient(conn)
+}
+
+func TestGRPCCreateUser(t *testing.T) {
+	client := setupGRPCClient(t, &mockUserService{})
+
+	user, err := client.CreateUser(context.Background(), &userv1.CreateUserRequest{
+		Name:  "Test User",
+		Email: "test@example.com",
+		Age:   25,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "test@example.com", user.GetEmail())
+}
+
+func TestGRPCCreateUserValidation(t *testing.T) {
+	client := setupGRPCClient(t, &mockUserService{})
+
+	_, err := client.CreateUser(context.Background(), &userv1.CreateUserRequest{
+		Name:  "T",
+		Email: "not-an-email",
+		Age:   25,
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCGetUserNotFound(t *testing.T) {
+	client := setupGRPCClient(t, &notFoundUserService{})
+
+	_, err := client.GetUser(context.Background(), &userv1.GetUserRequest{Id: 42})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+// shrinkingUserService - список пользователей по ID, из которого после
+// первой порции удаляется первый пользователь. Как и репозиторий,
+// понимает OFFSET (page) и after_id.
+type shrinkingUserService struct {
+	mockUserService
+	users []models.User
+	calls int
+}
+
+func (m *shrinkingUserService) GetUsers(ctx context.Context, page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
+	m.calls++
+	if m.calls == 2 {
+		m.users = m.users[1:]
+	}
+	afterID, _ := filters["after_id"].(int)
+	var matched []models.User
+	for _, user := range m.users {
+		if user.ID > afterID {
+			matched = append(matched, user)
+		}
+	}
+	start := min((page-1)*pageSize, len(matched))
+	end := min(start+pageSize, len(matched))
+	return &models.UserListResponse{
+		Users:      matched[start:end],
+		Total:      len(matched),
+		Page:       page,
+		PageSize:   pageSize,
+		TotalPages: (len(matched) + pageSize - 1) / pageSize,
+	}, nil
+}
+
+func TestGRPCStreamUsersDuringChanges(t *testing.T) {
+	svc := &shrinkingUserService{}
+	for id := 1; id <= 25; id++ {
+		svc.users = append(svc.users, models.User{ID: id, Email: "user@example.com"})
+	}
+	client := setupGRPCClient(t, svc)
+
+	stream, err := client.StreamUsers(context.Background(), &userv1.StreamUsersRequest{BatchSize: 10})
+	require.NoError(t, err)
+
+	var ids []int64
+	for {
+		user, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, user.GetId())
+	}
+
+	// Удаление уже отправленного пользователя не сдвигает следующие порции
+	require.Len(t, ids, 25)
+	for i, id := range ids {
+		assert.Equal(t, int64(i+1), id)
+	}
+}
+
+func TestGRPCStreamUsers(t *testing.T) {
+	client := setupGRPCClient(t, &mockUserService{})
+
+	stream, err := client.StreamUsers(context.Background(), &userv1.StreamUsersRequest{BatchSize: 10})
+	require.NoError(t, err)
+
+	var received []*userv1.User
+	for {
+		user, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		received = append(received, user)
+	}
+
+	require.Len(t, received, 1)
+	assert.Equal(t, "user1@example.com", received[0].GetEmail())
+}
+
+// TestGRPCTrustsVerifiedClientCertificate проверяет gRPC на отдельном
+// порту с TLS: сертификат клиента, проверенный сервером, делает заголовок
+// арендатора доверенным, как и на порту HTTP
+func TestGRPCTrustsVerifiedClientCertificate(t *testing.T) {
+	serverCert, serverKey, _ := writeSelfSigned(t, t.TempDir(), 1)
+	clientCertFile, clientKeyFile, clientCert := writeSelfSigned(t, t.TempDir(), 10)
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientCert.Raw}), 0o600))
+
+	reloader, err := certs.NewReloader(serverCert, serverKey, caFile)
+	require.NoError(t, err)
+	resolver, err := tenant.NewResolver(true, nil, nil, service.NewTenantManager(newMemTenantRepo(), 0), nil)
+	require.NoError(t, err)
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	opts := append([]grpc.ServerOption{grpc.Creds(credentials.NewTLS(reloader.TLSConfig(tls.VerifyClientCertIfGiven)))},
+		grpcapi.TenantInterceptors(resolver, "X-Tenant-ID")...)
+	srv := grpcapi.NewServer(&mockUserService{}, nil, false, opts...)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	pair, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	require.NoError(t, err)
+	getUser := func(clientTLS *tls.Config) error {
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+		require.NoError(t, err)
+		defer conn.Close()
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "X-Tenant-ID", "1")
+		_, err = userv1.NewUserServiceClient(conn).GetUser(ctx, &userv1.GetUserRequest{Id: 1})
+		return err
+	}
+
+	require.NoError(t, getUser(&tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{pair}}))
+
+	err = getUser(&tls.Config{InsecureSkipVerify: true})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
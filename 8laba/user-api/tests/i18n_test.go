@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+	"user-api/docs"
+	"user-api/internal/handlers"
+	"user-api/internal/i18n"
+	"user-api/internal/middleware"
+	"user-api/internal/models"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// testTranslator регистрируется при инициализации пакета: validator
+// запоминает имена полей структуры при первой проверке, поэтому переводы
+// нужно подключить до запуска тестов, как это делает main
+var testTranslator = func() *i18n.Translator {
+	translator, err := i18n.New()
+	if err != nil {
+		panic(err)
+	}
+	if err := translator.RegisterValidator(binding.Validator.Engine().(*validator.Validate)); err != nil {
+		panic(err)
+	}
+	return translator
+}()
+
+// setupLocalizedRouter - обработчики пользователей с переводом ошибок; при
+// validated запросы сначала проверяются по схеме OpenAPI
+func setupLocalizedRouter(t *testing.T, validated bool) http.Handler {
+	t.Helper()
+
+	router := setupTestRouter()
+	router.Use(middleware.Localize(testTranslator))
+	api := router.Group("/api/v1")
+	if validated {
+		spec, err := docs.OpenAPI()
+		require.NoError(t, err)
+		openapiValidator, err := middleware.OpenAPIValidator(spec)
+		require.NoError(t, err)
+		api.Use(openapiValidator)
+	}
+	handler := handlers.NewUserHandler(&mockUserService{})
+	api.GET("/users", handler.GetUsers)
+	api.GET("/users/stats", handler.GetUserStats)
+	api.GET("/users/:id", handler.GetUser)
+	api.POST("/users", handler.CreateUser)
+	return router
+}
+
+func localizedRequest(t *testing.T, router http.Handler, method, path, language string, body interface{}) (*httptest.ResponseRecorder, models.ErrorResponse) {
+	t.Helper()
+
+	var reader *bytes.Reader
+	switch b := body.(type) {
+	case nil:
+		reader = bytes.NewReader(nil)
+	case string:
+		reader = bytes.NewReader([]byte(b))
+	default:
+		data, err := json.Marshal(b)
+		require.NoError(t, err)
+		reader = bytes.NewReader(data)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	if language != "" {
+		req.Header.Set("Accept-Language", language)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var resp models.ErrorResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w, resp
+}
+
+func TestLocaleBundlesHaveSameKeys(t *testing.T) {
+	keys := map[string][]string{}
+	err := fs.WalkDir(os.DirFS("../internal/i18n/locales"), ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile("../internal/i18n/locales/" + path)
+		if err != nil {
+			return err
+		}
+		var bundle map[string]map[string]string
+		if err := yaml.Unmarshal(data, &bundle); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		for section, messages := range bundle {
+			for key := range messages {
+				keys[path] = append(keys[path], section+"."+key)
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, len(i18n.Languages))
+	assert.ElementsMatch(t, keys["en.yaml"], keys["ru.yaml"])
+}
+
+func TestLocalizerSelectsLanguage(t *testing.T) {
+
+	cases := map[string]string{
+		"":                      "en",
+		"ru":                    "ru",
+		"ru-RU,ru;q=0.9":        "ru",
+		"de-DE, ru;q=0.5":       "ru",
+		"en;q=0.4, ru-RU;q=0.8": "ru",
+		"ru;q=0, en":            "en",
+		"fr":                    "en",
+		"*":                     "en",
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, testTranslator.Localizer(header).Language(), header)
+	}
+}
+
+func TestLocalizedBindingErrors(t *testing.T) {
+	router := setupLocalizedRouter(t, false)
+
+	w, resp := localizedRequest(t, router, http.MethodPost, "/api/v1/users", "ru", map[string]interface{}{
+		"name": "J", "email": "not-an-email", "age": 200,
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "ru", w.Header().Get("Content-Language"))
+	assert.Contains(t, w.Header().Values("Vary"), "Accept-Language")
+	assert.Equal(t, "Validation error", resp.Error)
+	assert.Equal(t, "Имя: минимальная длина - 2; Email: некорректный адрес электронной почты; "+
+		"Возраст: значение должно быть не больше 150", resp.Message)
+
+	_, resp = localizedRequest(t, router, http.MethodPost, "/api/v1/users", "en-US", map[string]interface{}{
+		"email": "jane@example.com", "age": 20,
+	})
+	assert.Equal(t, "Name is required", resp.Message)
+
+	_, resp = localizedRequest(t, router, http.MethodPost, "/api/v1/users", "ru", `{"name": Jane}`)
+	assert.Equal(t, "тело запроса не является корректным JSON (позиция 10)", resp.Message)
+
+	_, resp = localizedRequest(t, router, http.MethodPost, "/api/v1/users", "ru", `{"name": "Jane", "age": "20"}`)
+	assert.Equal(t, "Возраст: ожидается целое число", resp.Message)
+}
+
+func TestLocalizedOpenAPIErrors(t *testing.T) {
+	router := setupLocalizedRouter(t, true)
+
+	w, resp := localizedRequest(t, router, http.MethodPost, "/api/v1/users", "ru", map[string]interface{}{
+		"name": "J", "age": 200,
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "Validation error", resp.Error)
+	assert.Contains(t, resp.Message, "Имя: минимальная длина - 2")
+	assert.Contains(t, resp.Message, "Email: обязательное поле")
+	assert.Contains(t, resp.Message, "Возраст: значение должно быть не больше 150")
+
+	_, resp = localizedRequest(t, router, http.MethodGet, "/api/v1/users/abc", "ru", nil)
+	assert.Equal(t, "ID: ожидается целое число", resp.Message)
+
+	_, resp = localizedRequest(t, router, http.MethodGet, "/api/v1/users?page=first", "en", nil)
+	assert.Equal(t, "Page must be an integer", resp.Message)
+}
+
+func TestLocalizedDomainErrors(t *testing.T) {
+	l := testTranslator.Localizer("ru")
+
+	assert.Equal(t, "пользователь не найден", l.Error(models.ErrUserNotFound))
+	assert.Equal(t, "пользователь не найден", l.Error(fmt.Errorf("get user: %w", models.ErrUserNotFound)))
+	assert.Equal(t, `некорректные доп. поля: "team" must be a string`,
+		l.Error(fmt.Errorf("%w: %q must be a string", models.ErrInvalidMetadata, "team")))
+	assert.Equal(t, "слишком много запросов, повторите через 3 с", l.Error(&models.RateLimitError{RetryAfter: 3 * time.Second}))
+	assert.Equal(t, "connection refused", l.Error(fmt.Errorf("connection refused")))
+	assert.Equal(t, "ID должен быть числом", l.Text("messages.invalid_id"))
+}
+
+func TestErrorsWithoutLocalizer(t *testing.T) {
+	// Без middleware.Localize ошибки возвращаются как есть, а сообщения -
+	// на английском
+	var l *i18n.Localizer
+	assert.Equal(t, models.ErrUserNotFound.Error(), l.Error(models.ErrUserNotFound))
+	assert.Equal(t, "ID must be a number", l.Text("messages.invalid_id"))
+
+	router := setupTestRouter()
+	router.GET("/users/:id", handlers.NewUserHandler(&mockUserService{}).GetUser)
+	w, resp := localizedRequest(t, router, http.MethodGet, "/users/abc", "ru", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "ID must be a number", resp.Message)
+	assert.Empty(t, w.Header().Get("Content-Language"))
+}
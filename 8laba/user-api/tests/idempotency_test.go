@@ -0,0 +1,294 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"user-api/internal/middleware"
+	"user-api/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memIdempotencyRepo - IdempotencyRepository в памяти
+type memIdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+	owners  map[string]string
+}
+
+func newMemIdempotencyRepo() *memIdempotencyRepo {
+	return &memIdempotencyRepo{records: map[string]*models.IdempotencyRecord{}, owners: map[string]string{}}
+}
+
+// held сообщает, занят ли еще выполняющийся ключ owner
+func (r *memIdempotencyRepo) held(key, owner string) bool {
+	record, ok := r.records[key]
+	return ok && record.StatusCode == nil && r.owners[key] == owner
+}
+
+func (r *memIdempotencyRepo) Acquire(key, owner, requestHash string, ttl, lockTimeout time.Duration) (bool, *models.IdempotencyRecord, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if record, ok := r.records[key]; ok {
+		stale := record.StatusCode == nil && record.LockedAt.Add(lockTimeout).Before(now)
+		if record.ExpiresAt.After(now) && !stale {
+			copied := *record
+			return false, &copied, nil
+		}
+	}
+	r.records[key] = &models.IdempotencyRecord{Key: key, RequestHash: requestHash, LockedAt: now, ExpiresAt: now.Add(ttl)}
+	r.owners[key] = owner
+	return true, nil, nil
+}
+
+func (r *memIdempotencyRepo) Complete(key, owner string, statusCode int, contentType string, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.held(key, owner) {
+		return models.ErrIdempotencyLockLost
+	}
+	record := r.records[key]
+	record.StatusCode = &statusCode
+	record.ContentType = &contentType
+	record.ResponseBody = append([]byte(nil), body...)
+	return nil
+}
+
+func (r *memIdempotencyRepo) Extend(key, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.held(key, owner) {
+		return models.ErrIdempotencyLockLost
+	}
+	r.records[key].LockedAt = time.Now()
+	return nil
+}
+
+func (r *memIdempotencyRepo) Release(key, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.held(key, owner) {
+		delete(r.records, key)
+	}
+	return nil
+}
+
+// stuckIdempotencyRepo не продлевает блокировки, как при недоступной БД
+type stuckIdempotencyRepo struct {
+	*memIdempotencyRepo
+}
+
+func (r stuckIdempotencyRepo) Extend(key, owner string) error {
+	return errors.New("database is unavailable")
+}
+
+func (r *memIdempotencyRepo) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+func setupIdempotentRouter(handler gin.HandlerFunc) *gin.Engine {
+	return setupIdempotentRouterWithConfig(handler, middleware.IdempotencyConfig{
+		TTL:         time.Hour,
+		LockTimeout: 2 * time.Second,
+		MaxBodySize: 1 << 10,
+	})
+}
+
+func setupIdempotentRouterWithConfig(handler gin.HandlerFunc, cfg middleware.IdempotencyConfig) *gin.Engine {
+	router := setupTestRouter()
+	router.Use(middleware.Idempotency(newMemIdempotencyRepo(), cfg))
+	router.POST("/users", handler)
+	return router
+}
+
+func postIdempotent(router http.Handler, key, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.HeaderIdempotencyKey, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	var calls atomic.Int32
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		n := calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{"id": n})
+	})
+
+	first := postIdempotent(router, "key-1", `{"name":"A"}`)
+	second := postIdempotent(router, "key-1", `{"name":"A"}`)
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get(middleware.HeaderIdempotentReplayed))
+	assert.Contains(t, second.Header().Get("Content-Type"), "application/json")
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Без ключа и с другим ключом запрос выполняется
+	postIdempotent(router, "", `{"name":"A"}`)
+	postIdempotent(router, "key-2", `{"name":"A"}`)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestIdempotencyRejectsDifferentPayload(t *testing.T) {
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	postIdempotent(router, "key-1", `{"name":"A"}`)
+	w := postIdempotent(router, "key-1", `{"name":"B"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+}
+
+func TestIdempotencyRejectsDifferentFormat(t *testing.T) {
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	})
+
+	send := func(contentType, accept string) int {
+		req, _ := http.NewRequest("POST", "/users", bytes.NewBufferString(`{"name":"A"}`))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Accept", accept)
+		req.Header.Set(middleware.HeaderIdempotencyKey, "key-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	require.Equal(t, http.StatusCreated, send("application/json", ""))
+	// Тот же формат ответа, записанный иначе, - повтор
+	assert.Equal(t, http.StatusCreated, send("application/json; charset=utf-8", "*/*"))
+	assert.Equal(t, http.StatusUnprocessableEntity, send("application/json", "application/xml"))
+	assert.Equal(t, http.StatusUnprocessableEntity, send("application/msgpack", ""))
+}
+
+func TestIdempotencyConcurrentDuplicatesWait(t *testing.T) {
+	var calls atomic.Int32
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 5)
+	for i := range responses {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			responses[i] = postIdempotent(router, "key-1", `{"name":"A"}`)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, w := range responses {
+		require.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"id":1}`, w.Body.String())
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	var calls atomic.Int32
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "db down"})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, postIdempotent(router, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, postIdempotent(router, "key-1", `{}`).Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyRejectsLargeBody(t *testing.T) {
+	var calls atomic.Int32
+	router := setupIdempotentRouter(func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	w := postIdempotent(router, "key-1", `{"name":"`+strings.Repeat("a", 2<<10)+`"}`)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, int32(0), calls.Load())
+}
+
+func TestIdempotencyExtendsLockOfLongRequest(t *testing.T) {
+	var calls atomic.Int32
+	router := setupIdempotentRouterWithConfig(func(c *gin.Context) {
+		calls.Add(1)
+		time.Sleep(600 * time.Millisecond)
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	}, middleware.IdempotencyConfig{
+		TTL:         time.Hour,
+		LockTimeout: 150 * time.Millisecond,
+		MaxBodySize: 1 << 10,
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postIdempotent(router, "key-1", `{}`) }()
+
+	// Повтор приходит, когда LockTimeout первого запроса давно прошел, но
+	// блокировка продлена: запрос не выполняется второй раз
+	time.Sleep(300 * time.Millisecond)
+	assert.Equal(t, http.StatusConflict, postIdempotent(router, "key-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyLostLockKeepsNewerRecord(t *testing.T) {
+	for _, status := range []int{http.StatusCreated, http.StatusInternalServerError} {
+		var calls atomic.Int32
+		router := setupTestRouter()
+		router.Use(middleware.Idempotency(stuckIdempotencyRepo{newMemIdempotencyRepo()}, middleware.IdempotencyConfig{
+			TTL:         time.Hour,
+			LockTimeout: 100 * time.Millisecond,
+			MaxBodySize: 1 << 10,
+		}))
+		router.POST("/users", func(c *gin.Context) {
+			n := calls.Add(1)
+			if n == 1 {
+				time.Sleep(400 * time.Millisecond)
+				c.JSON(status, gin.H{"id": n})
+				return
+			}
+			c.JSON(http.StatusCreated, gin.H{"id": n})
+		})
+
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- postIdempotent(router, "key-1", `{}`) }()
+
+		// Первый запрос не продлил блокировку, и ключ занял повтор
+		time.Sleep(200 * time.Millisecond)
+		second := postIdempotent(router, "key-1", `{}`)
+		require.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, status, (<-done).Code)
+
+		// Завершившийся позже первый запрос не перезаписал и не удалил
+		// ответ второго
+		third := postIdempotent(router, "key-1", `{}`)
+		assert.Equal(t, "true", third.Header().Get(middleware.HeaderIdempotentReplayed))
+		assert.JSONEq(t, second.Body.String(), third.Body.String())
+		assert.Equal(t, int32(2), calls.Load())
+	}
+}
@@ -0,0 +1,251 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"user-api/docs"
+	"user-api/internal/handlers"
+	"user-api/internal/middleware"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memMetadataSchemaRepo - MetadataSchemaRepository в памяти
+type memMetadataSchemaRepo struct {
+	mu      sync.Mutex
+	schemas map[string]models.MetadataSchema
+}
+
+func newMemMetadataSchemaRepo() *memMetadataSchemaRepo {
+	return &memMetadataSchemaRepo{schemas: map[string]models.MetadataSchema{}}
+}
+
+func (r *memMetadataSchemaRepo) GetAll() ([]models.MetadataSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schemas := []models.MetadataSchema{}
+	for _, s := range r.schemas {
+		schemas = append(schemas, s)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Key < schemas[j].Key })
+	return schemas, nil
+}
+
+func (r *memMetadataSchemaRepo) Get(key string) (*models.MetadataSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.schemas[key]
+	if !ok {
+		return nil, models.ErrMetadataSchemaNotFound
+	}
+	return &s, nil
+}
+
+func (r *memMetadataSchemaRepo) GetByKeys(keys []string) ([]models.MetadataSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var schemas []models.MetadataSchema
+	for _, key := range keys {
+		if s, ok := r.schemas[key]; ok {
+			schemas = append(schemas, s)
+		}
+	}
+	return schemas, nil
+}
+
+func (r *memMetadataSchemaRepo) Put(schema *models.MetadataSchema) (*models.MetadataSchema, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := *schema
+	saved.UpdatedAt = time.Now()
+	if existing, ok := r.schemas[schema.Key]; ok {
+		saved.CreatedAt = existing.CreatedAt
+	} else {
+		saved.CreatedAt = saved.UpdatedAt
+	}
+	r.schemas[schema.Key] = saved
+	return &saved, nil
+}
+
+func (r *memMetadataSchemaRepo) Delete(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.schemas[key]; !ok {
+		return models.ErrMetadataSchemaNotFound
+	}
+	delete(r.schemas, key)
+	return nil
+}
+
+func putSchema(t *testing.T, svc service.MetadataService, key, schema string) {
+	t.Helper()
+	_, err := svc.PutSchema(key, &models.PutMetadataSchemaRequest{Schema: json.RawMessage(schema)})
+	require.NoError(t, err)
+}
+
+func TestMetadataSchemaValidation(t *testing.T) {
+	svc := service.NewMetadataService(newMemMetadataSchemaRepo())
+	putSchema(t, svc, "department", `{"type": "string", "enum": ["sales", "support"]}`)
+	putSchema(t, svc, "employee_number", `{"type": "integer", "minimum": 1}`)
+
+	valid := models.Metadata{
+		"department":      json.RawMessage(`"sales"`),
+		"employee_number": json.RawMessage(`12345678901234567`),
+		"slack_handle":    json.RawMessage(`"@alice"`),
+	}
+	assert.NoError(t, svc.ValidateMetadata(valid))
+
+	// null удаляет ключ и по схеме не проверяется
+	assert.NoError(t, svc.ValidateMetadata(models.Metadata{"department": json.RawMessage(`null`)}))
+
+	err := svc.ValidateMetadata(models.Metadata{"department": json.RawMessage(`"marketing"`)})
+	assert.ErrorIs(t, err, models.ErrInvalidMetadata)
+	assert.Contains(t, err.Error(), "department")
+
+	err = svc.ValidateMetadata(models.Metadata{"employee_number": json.RawMessage(`"42"`)})
+	assert.ErrorIs(t, err, models.ErrInvalidMetadata)
+
+	for _, key := range []string{"Department", "1st", "with-dash", "metadata.key", strings.Repeat("a", 65)} {
+		err := svc.ValidateMetadata(models.Metadata{key: json.RawMessage(`1`)})
+		assert.ErrorIs(t, err, models.ErrInvalidMetadata, key)
+	}
+
+	tooLarge := models.Metadata{"notes": json.RawMessage(`"` + strings.Repeat("x", service.MaxMetadataSize) + `"`)}
+	assert.ErrorIs(t, svc.ValidateMetadata(tooLarge), models.ErrInvalidMetadata)
+}
+
+func TestMetadataSchemaReplacementTakesEffect(t *testing.T) {
+	svc := service.NewMetadataService(newMemMetadataSchemaRepo())
+	putSchema(t, svc, "level", `{"type": "integer", "maximum": 3}`)
+	value := models.Metadata{"level": json.RawMessage(`5`)}
+	assert.Error(t, svc.ValidateMetadata(value))
+
+	time.Sleep(time.Millisecond)
+	putSchema(t, svc, "level", `{"type": "integer", "maximum": 10}`)
+	assert.NoError(t, svc.ValidateMetadata(value))
+
+	require.NoError(t, svc.DeleteSchema("level"))
+	assert.NoError(t, svc.ValidateMetadata(models.Metadata{"level": json.RawMessage(`"any"`)}))
+	assert.ErrorIs(t, svc.DeleteSchema("level"), models.ErrMetadataSchemaNotFound)
+}
+
+func TestPutMetadataSchemaRejectsInvalidSchemas(t *testing.T) {
+	svc := service.NewMetadataService(newMemMetadataSchemaRepo())
+
+	cases := map[string]string{
+		"department": `{"type": "no-such-type"}`,
+		"external":   `{"$ref": "file:///etc/passwd"}`,
+		"remote":     `{"$ref": "https://example.com/schema.json"}`,
+		"Bad-Key":    `{"type": "string"}`,
+	}
+	for key, schema := range cases {
+		_, err := svc.PutSchema(key, &models.PutMetadataSchemaRequest{Schema: json.RawMessage(schema)})
+		assert.ErrorIs(t, err, models.ErrInvalidMetadataSchema, key)
+	}
+}
+
+func TestUserServiceValidatesMetadata(t *testing.T) {
+	metadata := service.NewMetadataService(newMemMetadataSchemaRepo())
+	putSchema(t, metadata, "department", `{"type": "string"}`)
+
+	// Репозиторий не должен вызываться: проверка выполняется до записи
+	var repo repository.UserRepository
+	users := service.NewUserService(repo, nil, metadata)
+
+	_, err := users.CreateUser(context.Background(), &models.CreateUserRequest{
+		Name: "Alice", Email: "alice@example.com", Age: 30,
+		Metadata: models.Metadata{"department": json.RawMessage(`42`)},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidMetadata)
+
+	_, err = users.UpdateUser(context.Background(), 1, &models.UpdateUserRequest{
+		Metadata: models.Metadata{"Department": json.RawMessage(`"sales"`)},
+	})
+	assert.ErrorIs(t, err, models.ErrInvalidMetadata)
+}
+
+// filterCapturingService запоминает фильтры запроса списка пользователей
+type filterCapturingService struct {
+	mockUserService
+	filters map[string]interface{}
+}
+
+func (s *filterCapturingService) GetUsers(ctx context.Context, page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
+	s.filters = filters
+	return s.mockUserService.GetUsers(ctx, page, pageSize, filters)
+}
+
+func TestGetUsersMetadataFilter(t *testing.T) {
+	spec, err := docs.OpenAPI()
+	require.NoError(t, err)
+	validator, err := middleware.OpenAPIValidator(spec)
+	require.NoError(t, err)
+
+	svc := &filterCapturingService{}
+	router := setupTestRouter()
+	api := router.Group("/api/v1")
+	api.Use(validator)
+	api.GET("/users", handlers.NewUserHandler(svc).GetUsers)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users?metadata.department=sales&metadata.employee_number=42&name=A", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, map[string]string{"department": "sales", "employee_number": "42"}, svc.filters["metadata"])
+	assert.Equal(t, "A", svc.filters["name"])
+
+	req, _ = http.NewRequest("GET", "/api/v1/users?metadata.Bad-Key=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestMetadataSchemaHandlers(t *testing.T) {
+	router := setupTestRouter()
+	handler := handlers.NewMetadataHandler(service.NewMetadataService(newMemMetadataSchemaRepo()))
+	router.GET("/admin/metadata/schemas", handler.GetSchemas)
+	router.GET("/admin/metadata/schemas/:key", handler.GetSchema)
+	router.PUT("/admin/metadata/schemas/:key", handler.PutSchema)
+	router.DELETE("/admin/metadata/schemas/:key", handler.DeleteSchema)
+
+	do := func(method, url, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do("PUT", "/admin/metadata/schemas/department", `{"schema": {"type": "string"}, "description": "Отдел"}`)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var schema models.MetadataSchema
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &schema))
+	assert.Equal(t, "department", schema.Key)
+	assert.JSONEq(t, `{"type": "string"}`, string(schema.Schema))
+
+	w = do("PUT", "/admin/metadata/schemas/department", `{"schema": {"minLength": "two"}}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = do("PUT", "/admin/metadata/schemas/department", `{}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = do("GET", "/admin/metadata/schemas", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"key":"department"`)
+
+	assert.Equal(t, http.StatusNoContent, do("DELETE", "/admin/metadata/schemas/department", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/admin/metadata/schemas/department", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("DELETE", "/admin/metadata/schemas/department", "").Code)
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"user-api/docs"
+	"user-api/internal/handlers"
+	"user-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupValidatedRouter(t *testing.T) http.Handler {
+	t.Helper()
+
+	spec, err := docs.OpenAPI()
+	require.NoError(t, err)
+	validator, err := middleware.OpenAPIValidator(spec)
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	handler := handlers.NewUserHandler(&mockUserService{})
+
+	api := router.Group("/api/v1")
+	api.Use(validator)
+	api.GET("/users", handler.GetUsers)
+	api.GET("/users/:id", handler.GetUser)
+	api.POST("/users", handler.CreateUser)
+	return router
+}
+
+func TestOpenAPISpecIsVersion3(t *testing.T) {
+	spec, err := docs.OpenAPI()
+	require.NoError(t, err)
+
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+	assert.NotNil(t, spec.Paths.Find("/users/{id}"))
+	assert.Contains(t, spec.Components.Schemas, "models.User")
+}
+
+func TestOpenAPIValidatorRejectsInvalidBody(t *testing.T) {
+	router := setupValidatedRouter(t)
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "A", "email": "a@example.com", "age": 200})
+	req, _ := http.NewRequest("POST", "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "request body has an error")
+}
+
+func TestOpenAPIValidatorRejectsInvalidParams(t *testing.T) {
+	router := setupValidatedRouter(t)
+
+	for _, url := range []string{"/api/v1/users/abc", "/api/v1/users?page=first"} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, url)
+	}
+}
+
+func TestOpenAPIValidatorAcceptsValidRequest(t *testing.T) {
+	router := setupValidatedRouter(t)
+
+	body, _ := json.Marshal(map[string]interface{}{"name": "Alice", "email": "alice@example.com", "age": 25})
+	req, _ := http.NewRequest("POST", "/api/v1/users", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestOpenAPIValidatorAcceptsCommaSeparatedArrays(t *testing.T) {
+	spec, err := docs.OpenAPI()
+	require.NoError(t, err)
+	validator, err := middleware.OpenAPIValidator(spec)
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	api := router.Group("/api/v1")
+	api.Use(validator)
+	api.GET("/users/events", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	for url, code := range map[string]int{
+		"/api/v1/users/events?type=user.updated,user.deleted&user_id=1,2": http.StatusNoContent,
+		"/api/v1/users/events?type=user.exploded":                         http.StatusBadRequest,
+		"/api/v1/users/events?user_id=1,x":                                http.StatusBadRequest,
+	} {
+		req, _ := http.NewRequest("GET", url, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, code, w.Code, url)
+	}
+}
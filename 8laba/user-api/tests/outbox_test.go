@@ -0,0 +1,233 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/models"
+	"user-api/internal/outbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memOutboxRepo - OutboxRepository в памяти
+type memOutboxRepo struct {
+	mu       sync.Mutex
+	messages []models.OutboxMessage
+	seq      int64
+}
+
+func (r *memOutboxRepo) add(t *testing.T, event events.Event) {
+	payload, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, models.OutboxMessage{
+		ID:        int64(len(r.messages) + 1),
+		EventID:   event.ID,
+		EventType: string(event.Type),
+		UserID:    event.UserID,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (r *memOutboxRepo) Process(limit int, skipUsers []int, publish func([]models.OutboxMessage) []int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var batch []models.OutboxMessage
+	for _, message := range r.messages {
+		if message.PublishedAt == nil && !slices.Contains(skipUsers, message.UserID) && len(batch) < limit {
+			batch = append(batch, message)
+		}
+	}
+	if len(batch) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	published := publish(batch)
+	slices.Sort(published)
+	for _, id := range published {
+		r.seq++
+		seq := r.seq
+		r.messages[id-1].PublishedAt = &now
+		r.messages[id-1].PublishedSeq = &seq
+	}
+	return len(batch), nil
+}
+
+func (r *memOutboxRepo) LastPublishedSeq(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.seq, nil
+}
+
+func (r *memOutboxRepo) PublishedAfter(ctx context.Context, seq int64, limit int) ([]models.OutboxMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var published []models.OutboxMessage
+	for _, message := range r.messages {
+		if message.PublishedSeq != nil && *message.PublishedSeq > seq && message.Payload != nil {
+			published = append(published, message)
+		}
+	}
+	slices.SortFunc(published, func(a, b models.OutboxMessage) int {
+		return int(*a.PublishedSeq - *b.PublishedSeq)
+	})
+	return published[:min(limit, len(published))], nil
+}
+
+func (r *memOutboxRepo) HasPublished(ctx context.Context, seq int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, message := range r.messages {
+		if message.PublishedSeq != nil && *message.PublishedSeq == seq && message.Payload != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memOutboxRepo) DeletePublished(before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var deleted int64
+	for i := range r.messages {
+		if published := r.messages[i].PublishedAt; published != nil && published.Before(before) {
+			// ID совпадает с индексом, поэтому запись помечается, а не удаляется
+			r.messages[i].Payload = nil
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memOutboxRepo) unpublished() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, message := range r.messages {
+		if message.PublishedAt == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// flakyPublisher отклоняет события пользователя failUser, пока fail = true
+type flakyPublisher struct {
+	mu        sync.Mutex
+	failUser  int
+	fail      bool
+	published []events.Event
+}
+
+func (p *flakyPublisher) Publish(_ context.Context, event events.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.fail && event.UserID == p.failUser {
+		return errors.New("broker unavailable")
+	}
+	p.published = append(p.published, event)
+	return nil
+}
+
+func (p *flakyPublisher) setFail(fail bool) {
+	p.mu.Lock()
+	p.fail = fail
+	p.mu.Unlock()
+}
+
+func (p *flakyPublisher) types(userID int) []events.Type {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var types []events.Type
+	for _, event := range p.published {
+		if event.UserID == userID {
+			types = append(types, event.Type)
+		}
+	}
+	return types
+}
+
+func TestOutboxRelayKeepsPerUserOrder(t *testing.T) {
+	repo := &memOutboxRepo{}
+	repo.add(t, events.New(events.UserCreated, 1, nil))
+	repo.add(t, events.New(events.UserUpdated, 1, nil))
+	repo.add(t, events.New(events.UserCreated, 2, nil))
+	repo.add(t, events.New(events.UserDeleted, 2, nil))
+	repo.add(t, events.New(events.UserDeleted, 1, nil))
+
+	publisher := &flakyPublisher{failUser: 1, fail: true}
+	relay := outbox.NewRelay(repo, publisher, outbox.Config{
+		BatchSize:       2,
+		PollInterval:    10 * time.Millisecond,
+		Retention:       time.Hour,
+		CleanupInterval: time.Hour,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go relay.Run(ctx)
+
+	// Пока брокер отклоняет события пользователя 1, события пользователя 2
+	// публикуются, даже если события пользователя 1 занимают целую порцию
+	require.Eventually(t, func() bool {
+		return len(publisher.types(2)) == 2
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, publisher.types(1))
+	assert.Equal(t, 3, repo.unpublished())
+
+	publisher.setFail(false)
+	relay.Notify()
+
+	require.Eventually(t, func() bool {
+		return repo.unpublished() == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []events.Type{events.UserCreated, events.UserUpdated, events.UserDeleted}, publisher.types(1))
+	assert.Equal(t, []events.Type{events.UserCreated, events.UserDeleted}, publisher.types(2))
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	publisher, err := events.NewFilePublisher(path)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	created := events.New(events.UserCreated, 7, &models.User{ID: 7, Email: "test@example.com"})
+	require.NoError(t, publisher.Publish(ctx, created))
+	require.NoError(t, publisher.Publish(ctx, events.New(events.UserDeleted, 7, nil)))
+	require.NoError(t, publisher.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var messages []events.Message
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var message events.Message
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &message))
+		messages = append(messages, message)
+	}
+
+	require.Len(t, messages, 2)
+	assert.Equal(t, "users.created", messages[0].Subject)
+	assert.Equal(t, created.ID, messages[0].Event.ID)
+	assert.Equal(t, "test@example.com", messages[0].Event.User.Email)
+	assert.Equal(t, "users.deleted", messages[1].Subject)
+}
@@ -0,0 +1,285 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"user-api/internal/handlers"
+	"user-api/internal/mailer"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/tenant"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memPasswordRepo - PasswordRepository в памяти
+type memPasswordRepo struct {
+	mu        sync.Mutex
+	users     []models.User
+	passwords map[int]string
+	changed   map[int]time.Time
+	tokens    map[string]*models.PasswordResetToken
+}
+
+func newMemPasswordRepo(users ...models.User) *memPasswordRepo {
+	r := &memPasswordRepo{
+		users:     users,
+		passwords: map[int]string{},
+		changed:   map[int]time.Time{},
+		tokens:    map[string]*models.PasswordResetToken{},
+	}
+	return r
+}
+
+func (r *memPasswordRepo) FindUsersByEmail(ctx context.Context, email string) ([]models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []models.User
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			found = append(found, user)
+		}
+	}
+	return found, nil
+}
+
+func (r *memPasswordRepo) CreateResetToken(token *models.PasswordResetToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *token
+	r.tokens[token.TokenHash] = &copied
+	return nil
+}
+
+func (r *memPasswordRepo) ResetPassword(tokenHash, passwordHash string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenHash]
+	if !ok || token.UsedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return 0, models.ErrInvalidResetToken
+	}
+	now := time.Now()
+	for _, t := range r.tokens {
+		if t.UserID == token.UserID && t.UsedAt == nil {
+			t.UsedAt = &now
+		}
+	}
+	r.passwords[token.UserID] = passwordHash
+	r.changed[token.UserID] = now
+	return token.UserID, nil
+}
+
+func (r *memPasswordRepo) PasswordChangedAt(ctx context.Context, userID int) (*time.Time, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed, ok := r.changed[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &changed, nil
+}
+
+func (r *memPasswordRepo) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+var resetTokenPattern = regexp.MustCompile(`reset-password\?token=([A-Za-z0-9_-]+)`)
+
+func newTestPasswordService(repo *memPasswordRepo, mail *bytes.Buffer, emailLimit, ipLimit int) *service.PasswordResetService {
+	return service.NewPasswordResetService(repo, mailer.NewWriterMailer("User API <no-reply@example.com>", mail), service.PasswordResetConfig{
+		TokenTTL:    time.Hour,
+		ResetURL:    "http://localhost:8080/reset-password",
+		SendTimeout: time.Second,
+		EmailLimit:  emailLimit,
+		IPLimit:     ipLimit,
+		LimitWindow: time.Hour,
+	})
+}
+
+func resetTokenFrom(t *testing.T, mail *bytes.Buffer) string {
+	t.Helper()
+	body, err := readQuotedPrintable(mail.String())
+	require.NoError(t, err)
+	matches := resetTokenPattern.FindAllStringSubmatch(body, -1)
+	require.NotEmpty(t, matches, "message must contain reset link")
+	return matches[len(matches)-1][1]
+}
+
+func TestPasswordResetFlow(t *testing.T) {
+	repo := newMemPasswordRepo(models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	var mail bytes.Buffer
+	svc := newTestPasswordService(repo, &mail, 10, 10)
+
+	require.NoError(t, svc.ForgotPassword(context.Background(), "john@example.com", "10.0.0.1"))
+	svc.Wait()
+	token := resetTokenFrom(t, &mail)
+
+	// В БД хранится только хэш токена
+	for hash := range repo.tokens {
+		assert.NotEqual(t, token, hash)
+	}
+
+	require.NoError(t, svc.ResetPassword(token, "new-password-1", "10.0.0.1"))
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(repo.passwords[1]), []byte("new-password-1")))
+
+	// Токен одноразовый
+	assert.ErrorIs(t, svc.ResetPassword(token, "new-password-2", "10.0.0.1"), models.ErrInvalidResetToken)
+	assert.ErrorIs(t, svc.ResetPassword("unknown", "new-password-2", "10.0.0.1"), models.ErrInvalidResetToken)
+}
+
+func TestForgotPasswordSendsToEveryTenant(t *testing.T) {
+	repo := newMemPasswordRepo(
+		models.User{ID: 1, TenantID: 1, Name: "John", Email: "john@example.com"},
+		models.User{ID: 2, TenantID: 2, Name: "John Two", Email: "john@example.com"},
+	)
+	var mail bytes.Buffer
+	svc := newTestPasswordService(repo, &mail, 10, 10)
+
+	// Запрос без арендатора: забывший пароль не может его назвать
+	require.NoError(t, svc.ForgotPassword(context.Background(), "john@example.com", "10.0.0.1"))
+	svc.Wait()
+
+	body, err := readQuotedPrintable(mail.String())
+	require.NoError(t, err)
+	tokens := resetTokenPattern.FindAllStringSubmatch(body, -1)
+	require.Len(t, tokens, 2)
+
+	// Каждая ссылка сбрасывает пароль только своей учетной записи
+	require.NoError(t, svc.ResetPassword(tokens[1][1], "new-password-1", "10.0.0.1"))
+	assert.Contains(t, repo.passwords, 2)
+	assert.NotContains(t, repo.passwords, 1)
+}
+
+func TestPasswordResetRevokesOlderTokens(t *testing.T) {
+	repo := newMemPasswordRepo(models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	var mail bytes.Buffer
+	svc := newTestPasswordService(repo, &mail, 10, 10)
+
+	require.NoError(t, svc.ForgotPassword(context.Background(), "john@example.com", "10.0.0.1"))
+	svc.Wait()
+	first := resetTokenFrom(t, &mail)
+	require.NoError(t, svc.ForgotPassword(context.Background(), "john@example.com", "10.0.0.1"))
+	svc.Wait()
+	second := resetTokenFrom(t, &mail)
+	require.NotEqual(t, first, second)
+
+	require.NoError(t, svc.ResetPassword(second, "new-password-1", "10.0.0.1"))
+	assert.ErrorIs(t, svc.ResetPassword(first, "new-password-2", "10.0.0.1"), models.ErrInvalidResetToken)
+}
+
+func TestPasswordResetRevokesAccessTokens(t *testing.T) {
+	repo := newMemPasswordRepo(models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	var mail bytes.Buffer
+	svc := newTestPasswordService(repo, &mail, 10, 10)
+	resolver, err := tenant.NewResolver(true, tenantSecret, nil, service.NewTenantManager(newMemTenantRepo(), 0),
+		service.NewTokenRevocations(repo))
+	require.NoError(t, err)
+
+	token := func(subject string, issuedAt time.Time) tenant.Credentials {
+		claims := tenant.Claims{Subject: subject, TenantID: tenant.DefaultID, ExpiresAt: time.Now().Add(time.Hour).Unix()}
+		if !issuedAt.IsZero() {
+			claims.IssuedAt = issuedAt.Unix()
+		}
+		return tenant.Credentials{Bearer: tenant.SignToken(claims, tenantSecret)}
+	}
+	ctx := context.Background()
+	before := time.Now().Add(-time.Minute)
+
+	// До сброса принимаются и токены без iat
+	_, err = resolver.Resolve(ctx, token("1", time.Time{}))
+	require.NoError(t, err)
+
+	require.NoError(t, svc.ForgotPassword(ctx, "john@example.com", "10.0.0.1"))
+	svc.Wait()
+	require.NoError(t, svc.ResetPassword(resetTokenFrom(t, &mail), "new-password-1", "10.0.0.1"))
+
+	_, err = resolver.Resolve(ctx, token("1", before))
+	assert.ErrorIs(t, err, models.ErrInvalidAuthToken, "issued before reset")
+	_, err = resolver.Resolve(ctx, token("1", time.Time{}))
+	assert.ErrorIs(t, err, models.ErrInvalidAuthToken, "no iat")
+	_, err = resolver.Resolve(ctx, token("1", time.Now().Add(time.Second)))
+	assert.NoError(t, err, "issued after reset")
+	_, err = resolver.Resolve(ctx, token("2", before))
+	assert.NoError(t, err, "other user")
+	_, err = resolver.Resolve(ctx, token("service-account", before))
+	assert.NoError(t, err, "subject is not a user")
+}
+
+func TestForgotPasswordDoesNotRevealUnknownEmail(t *testing.T) {
+	repo := newMemPasswordRepo(models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	var mail bytes.Buffer
+	svc := newTestPasswordService(repo, &mail, 10, 10)
+
+	router := setupTestRouter()
+	handler := handlers.NewAuthHandler(svc)
+	router.POST("/auth/password/forgot", handler.ForgotPassword)
+
+	forgot := func(email string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.ForgotPasswordRequest{Email: email})
+		req, _ := http.NewRequest("POST", "/auth/password/forgot", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	known := forgot("john@example.com")
+	unknown := forgot("nobody@example.com")
+	svc.Wait()
+
+	assert.Equal(t, http.StatusAccepted, known.Code)
+	assert.Equal(t, known.Code, unknown.Code)
+	assert.Equal(t, known.Body.String(), unknown.Body.String())
+	assert.NotContains(t, mail.String(), "nobody@example.com")
+}
+
+func TestPasswordResetRateLimits(t *testing.T) {
+	repo := newMemPasswordRepo()
+	var mail bytes.Buffer
+	svc := newTestPasswordService(repo, &mail, 2, 3)
+	defer svc.Wait()
+
+	// Лимит по email действует с разных IP и без учета регистра
+	require.NoError(t, svc.ForgotPassword(context.Background(), "a@example.com", "10.0.0.1"))
+	require.NoError(t, svc.ForgotPassword(context.Background(), "A@example.com", "10.0.0.2"))
+	err := svc.ForgotPassword(context.Background(), "a@example.com", "10.0.0.3")
+	var rateLimit *models.RateLimitError
+	require.ErrorAs(t, err, &rateLimit)
+	assert.Positive(t, rateLimit.RetryAfter)
+
+	// Лимит по IP действует для разных адресов и для сброса
+	require.NoError(t, svc.ForgotPassword(context.Background(), "b@example.com", "10.0.0.9"))
+	require.NoError(t, svc.ForgotPassword(context.Background(), "c@example.com", "10.0.0.9"))
+	assert.ErrorIs(t, svc.ResetPassword("token", "new-password-1", "10.0.0.9"), models.ErrInvalidResetToken)
+	assert.ErrorAs(t, svc.ForgotPassword(context.Background(), "d@example.com", "10.0.0.9"), &rateLimit)
+
+	router := setupTestRouter()
+	router.POST("/auth/password/reset", handlers.NewAuthHandler(svc).ResetPassword)
+	req, _ := http.NewRequest("POST", "/auth/password/reset", bytes.NewBufferString(`{"token":"t","password":"new-password-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.RemoteAddr = "10.0.0.9:1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// Без доверенных прокси подмена X-Forwarded-For лимит не обходит
+	require.NoError(t, router.SetTrustedProxies(nil))
+	req, _ = http.NewRequest("POST", "/auth/password/reset", bytes.NewBufferString(`{"token":"t","password":"new-password-1"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.RemoteAddr = "10.0.0.9:1234"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -0,0 +1,174 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/seed"
+	"user-api/internal/validation"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedRepository запоминает пачки CreateBatch; emails из taken считаются
+// уже занятыми
+type seedRepository struct {
+	repository.UserRepository
+	batches [][]models.User
+	taken   map[string]bool
+	deleted int
+	calls   []string
+}
+
+func (r *seedRepository) CreateBatch(ctx context.Context, users []models.User) (int, error) {
+	r.calls = append(r.calls, "create")
+	r.batches = append(r.batches, append([]models.User(nil), users...))
+	created := 0
+	for _, user := range users {
+		if !r.taken[user.Email] {
+			created++
+		}
+	}
+	return created, nil
+}
+
+func (r *seedRepository) DeleteAll(ctx context.Context) (int, error) {
+	r.calls = append(r.calls, "delete")
+	return r.deleted, nil
+}
+
+// rejectingMetadata отклоняет любые metadata с ключом city
+type rejectingMetadata struct{}
+
+func (rejectingMetadata) ValidateMetadata(metadata models.Metadata) error {
+	if _, ok := metadata["city"]; ok {
+		return models.ErrInvalidMetadata
+	}
+	return nil
+}
+
+func generateUsers(seedValue uint64, n int) []models.User {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	generator := seed.NewGenerator(seedValue, 0.5, now)
+	users := make([]models.User, n)
+	for i := range users {
+		users[i] = generator.User()
+	}
+	return users
+}
+
+func TestSeedGeneratorIsReproducible(t *testing.T) {
+	assert.Equal(t, generateUsers(42, 100), generateUsers(42, 100))
+	assert.NotEqual(t, generateUsers(42, 100), generateUsers(43, 100))
+}
+
+func TestSeedGeneratorUsers(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	users := generateUsers(7, 5000)
+
+	emails := map[string]bool{}
+	var russian, verified int
+	ages := map[string]int{}
+	for _, user := range users {
+		normalized, err := validation.NormalizeEmail(user.Email)
+		require.NoError(t, err)
+		assert.Equal(t, user.Email, normalized, "email must be stored as is")
+		assert.False(t, emails[user.Email], "duplicate email %s", user.Email)
+		emails[user.Email] = true
+		assert.Contains(t, []string{"example.com", "example.org", "example.net", "mail.example", "corp.example"},
+			validation.Domain(user.Email))
+
+		name, err := validation.NormalizeName(user.Name)
+		require.NoError(t, err)
+		assert.Equal(t, user.Name, name)
+		if strings.ContainsAny(user.Name, "абвгдеёжзийклмнопрстуфхцчшщъыьэюя") {
+			russian++
+		}
+
+		assert.GreaterOrEqual(t, user.Age, 18)
+		assert.LessOrEqual(t, user.Age, 80)
+		switch {
+		case user.Age < 25:
+			ages["18-24"]++
+		case user.Age < 35:
+			ages["25-34"]++
+		case user.Age >= 65:
+			ages["65+"]++
+		}
+
+		assert.False(t, user.CreatedAt.After(now))
+		assert.True(t, user.CreatedAt.After(now.AddDate(-2, 0, -1)))
+		assert.False(t, user.UpdatedAt.Before(user.CreatedAt))
+		if user.EmailVerifiedAt != nil {
+			verified++
+			assert.False(t, user.EmailVerifiedAt.Before(user.CreatedAt))
+		}
+	}
+
+	// Доли - в пределах статистического разброса
+	assert.InDelta(t, 2500, russian, 250)
+	assert.InDelta(t, 3500, verified, 250)
+	assert.InDelta(t, 1500, ages["25-34"], 200)
+	assert.Greater(t, ages["25-34"], ages["18-24"])
+	assert.Greater(t, ages["18-24"], ages["65+"])
+
+	// Женские формы русских фамилий
+	for _, user := range generateUsers(1, 500) {
+		if strings.HasPrefix(user.Name, "Анна ") {
+			assert.Regexp(t, `(а|ая)$`, user.Name)
+		}
+	}
+
+	// Без русской доли - только английские имена
+	english := seed.NewGenerator(1, 0, now)
+	for i := 0; i < 100; i++ {
+		assert.Regexp(t, `^[A-Za-z' ]+$`, english.User().Name)
+	}
+}
+
+func TestSeedRunsInBatches(t *testing.T) {
+	repo := &seedRepository{deleted: 12, taken: map[string]bool{}}
+	first := generateUsers(5, 3)
+	repo.taken[first[1].Email] = true
+
+	var progress []int
+	result, err := seed.New(repo, nil).Run(context.Background(), seed.Config{
+		Count: 2500, Seed: 5, BatchSize: 1000, RussianShare: 0.5, Wipe: true,
+		Now: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}, func(done int) { progress = append(progress, done) })
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"delete", "create", "create", "create"}, repo.calls)
+	assert.Equal(t, []int{1000, 2000, 2500}, progress)
+	require.Len(t, repo.batches, 3)
+	assert.Len(t, repo.batches[2], 500)
+	assert.Equal(t, first, repo.batches[0][:3])
+	assert.Equal(t, &seed.Result{Deleted: 12, Created: 2499, Skipped: 1}, result)
+
+	// Без wipe пользователи не удаляются
+	repo = &seedRepository{}
+	result, err = seed.New(repo, nil).Run(context.Background(), seed.Config{Count: 10}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"create"}, repo.calls)
+	assert.Equal(t, 10, result.Created)
+}
+
+func TestSeedValidatesMetadata(t *testing.T) {
+	repo := &seedRepository{}
+	_, err := seed.New(repo, rejectingMetadata{}).Run(context.Background(), seed.Config{Count: 100, Seed: 1}, nil)
+	assert.True(t, errors.Is(err, models.ErrInvalidMetadata))
+	assert.Empty(t, repo.batches)
+}
+
+func TestUserctlSeedRequiresDatabase(t *testing.T) {
+	_, err := runUserctl(t, "", "--api", "http://localhost:1", "seed")
+	assert.ErrorContains(t, err, "only with the database")
+
+	_, err = runUserctl(t, "n\n", "seed", "--wipe")
+	assert.ErrorContains(t, err, "aborted")
+}
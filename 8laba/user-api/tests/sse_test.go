@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/feed"
+	"user-api/internal/handlers"
+	"user-api/internal/models"
+	"user-api/internal/outbox"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  string
+}
+
+func startSSEServer(t *testing.T, userFeed *feed.Feed, heartbeat time.Duration) *httptest.Server {
+	t.Helper()
+
+	router := setupTestRouter()
+	router.GET("/users/events", handlers.NewUserEventsHandler(userFeed, heartbeat).StreamUserEvents)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func openStream(t *testing.T, ctx context.Context, url, lastEventID string) (*http.Response, *bufio.Reader) {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// readSSE читает следующий блок потока (событие или комментарий)
+func readSSE(t *testing.T, r *bufio.Reader) (sseEvent, []string) {
+	t.Helper()
+
+	var event sseEvent
+	var comments []string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if event.ID != "" || event.Event != "" || len(comments) > 0 {
+				return event, comments
+			}
+		case strings.HasPrefix(line, ":"):
+			comments = append(comments, strings.TrimSpace(line[1:]))
+		case strings.HasPrefix(line, "id: "):
+			event.ID = line[4:]
+		case strings.HasPrefix(line, "event: "):
+			event.Event = line[7:]
+		case strings.HasPrefix(line, "data: "):
+			event.Data = line[6:]
+		}
+	}
+}
+
+func waitSubscribers(t *testing.T, userFeed *feed.Feed, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return userFeed.Subscribers() == n
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestUserEventsStreamFilters(t *testing.T) {
+	userFeed := feed.New(100, 10, nil)
+	server := startSSEServer(t, userFeed, time.Minute)
+
+	resp, r := openStream(t, context.Background(), server.URL+"/users/events?type=user.updated,user.deleted&user_id=2", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	waitSubscribers(t, userFeed, 1)
+
+	userFeed.Publish(events.New(events.UserCreated, 2, nil))
+	userFeed.Publish(events.New(events.UserDeleted, 1, nil))
+	deleted := events.New(events.UserDeleted, 2, nil)
+	userFeed.Publish(deleted)
+
+	event, _ := readSSE(t, r)
+	assert.Equal(t, "user.deleted", event.Event)
+	assert.NotEmpty(t, event.ID)
+
+	var payload events.Event
+	require.NoError(t, json.Unmarshal([]byte(event.Data), &payload))
+	assert.Equal(t, deleted.ID, payload.ID)
+	assert.Equal(t, 2, payload.UserID)
+}
+
+func TestUserEventsStreamResumesFromLastEventID(t *testing.T) {
+	userFeed := feed.New(100, 10, nil)
+	server := startSSEServer(t, userFeed, time.Minute)
+
+	sub, _, err := userFeed.Subscribe(context.Background(), feed.Filter{}, 0, false)
+	require.NoError(t, err)
+	userFeed.Publish(events.New(events.UserCreated, 1, nil))
+	userFeed.Publish(events.New(events.UserUpdated, 1, nil))
+	userFeed.Publish(events.New(events.UserDeleted, 1, nil))
+	created := <-sub.C()
+	updated := <-sub.C()
+	sub.Close()
+
+	_, r := openStream(t, context.Background(), server.URL+"/users/events", strconv.FormatUint(created.Seq, 10))
+
+	first, _ := readSSE(t, r)
+	second, _ := readSSE(t, r)
+	assert.Equal(t, strconv.FormatUint(updated.Seq, 10), first.ID)
+	assert.Equal(t, "user.updated", first.Event)
+	assert.Equal(t, "user.deleted", second.Event)
+}
+
+func TestUserEventsStreamHeartbeatAndDisconnect(t *testing.T) {
+	userFeed := feed.New(100, 10, nil)
+	server := startSSEServer(t, userFeed, 20*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_, r := openStream(t, ctx, server.URL+"/users/events", "")
+	waitSubscribers(t, userFeed, 1)
+
+	_, comments := readSSE(t, r)
+	assert.Equal(t, []string{"heartbeat"}, comments)
+
+	// После отключения клиента подписка удаляется
+	cancel()
+	waitSubscribers(t, userFeed, 0)
+}
+
+func TestUserEventsStreamInvalidFilter(t *testing.T) {
+	server := startSSEServer(t, feed.New(10, 10, nil), time.Minute)
+
+	resp, err := http.Get(server.URL + "/users/events?type=user.exploded")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestFeedLogIsBounded(t *testing.T) {
+	ctx := context.Background()
+	userFeed := feed.New(2, 1, nil)
+	userFeed.Publish(events.New(events.UserUpdated, 1, nil))
+	sub, _, err := userFeed.Subscribe(ctx, feed.Filter{}, 0, false)
+	require.NoError(t, err)
+	first := sub.Seq()
+	sub.Close()
+	for i := 2; i <= 5; i++ {
+		userFeed.Publish(events.New(events.UserUpdated, i, nil))
+	}
+
+	// Событие 4 еще в журнале
+	sub, backlog, err := userFeed.Subscribe(ctx, feed.Filter{}, first+3, true)
+	require.NoError(t, err)
+	require.Len(t, backlog, 1)
+	assert.Equal(t, 5, backlog[0].Event.UserID)
+	sub.Close()
+
+	// Событие 2 из журнала выпало, а истории у ленты нет
+	sub, backlog, err = userFeed.Subscribe(ctx, feed.Filter{}, first+1, true)
+	assert.ErrorIs(t, err, feed.ErrUnknownEvent)
+	assert.Empty(t, backlog)
+	assert.Equal(t, first+4, sub.Seq())
+
+	// Подписчик, который не читает, отключается при переполнении буфера
+	userFeed.Publish(events.New(events.UserUpdated, 6, nil))
+	userFeed.Publish(events.New(events.UserUpdated, 7, nil))
+	<-sub.C()
+	_, ok := <-sub.C()
+	assert.False(t, ok)
+	assert.Equal(t, 0, userFeed.Subscribers())
+}
+
+// publishOutbox публикует все неопубликованные события repo, как relay
+func publishOutbox(t *testing.T, repo *memOutboxRepo) {
+	t.Helper()
+	_, err := repo.Process(100, nil, func(messages []models.OutboxMessage) []int64 {
+		ids := make([]int64, len(messages))
+		for i, message := range messages {
+			ids[i] = message.ID
+		}
+		return ids
+	})
+	require.NoError(t, err)
+}
+
+func TestUserEventsStreamResumesOnAnotherInstance(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	// Два экземпляра API с общим outbox
+	repo := &memOutboxRepo{}
+	history := outbox.NewHistory(repo)
+	firstFeed := feed.New(100, 10, history)
+	secondFeed := feed.New(100, 10, history)
+	go firstFeed.Follow(ctx, 10*time.Millisecond)
+	go secondFeed.Follow(ctx, 10*time.Millisecond)
+	first := startSSEServer(t, firstFeed, time.Minute)
+	second := startSSEServer(t, secondFeed, time.Minute)
+
+	streamCtx, closeStream := context.WithCancel(ctx)
+	_, r := openStream(t, streamCtx, first.URL+"/users/events", "")
+	waitSubscribers(t, firstFeed, 1)
+
+	repo.add(t, events.New(events.UserCreated, 1, nil))
+	repo.add(t, events.New(events.UserUpdated, 1, nil))
+	repo.add(t, events.New(events.UserDeleted, 1, nil))
+	publishOutbox(t, repo)
+	firstFeed.Notify()
+
+	created, _ := readSSE(t, r)
+	assert.Equal(t, "user.created", created.Event)
+	closeStream()
+
+	// Переподключение к другому экземпляру продолжает с того же события
+	_, r = openStream(t, ctx, second.URL+"/users/events", created.ID)
+	updated, _ := readSSE(t, r)
+	deleted, _ := readSSE(t, r)
+	assert.Equal(t, "user.updated", updated.Event)
+	assert.Equal(t, "user.deleted", deleted.Event)
+
+	// Новые события приходят и на экземпляр без relay
+	repo.add(t, events.New(events.UserCreated, 2, nil))
+	publishOutbox(t, repo)
+	secondFeed.Notify()
+	next, _ := readSSE(t, r)
+	assert.Equal(t, "user.created", next.Event)
+	assert.Equal(t, "4", next.ID)
+}
+
+func TestUserEventsStreamResetsUnknownLastEventID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	repo := &memOutboxRepo{}
+	repo.add(t, events.New(events.UserCreated, 1, nil))
+	repo.add(t, events.New(events.UserUpdated, 1, nil))
+	publishOutbox(t, repo)
+	// Первые события удалены по сроку хранения
+	_, err := repo.DeletePublished(time.Now().Add(time.Second))
+	require.NoError(t, err)
+	repo.add(t, events.New(events.UserDeleted, 1, nil))
+	publishOutbox(t, repo)
+
+	userFeed := feed.New(100, 10, outbox.NewHistory(repo))
+	go userFeed.Follow(ctx, 10*time.Millisecond)
+	server := startSSEServer(t, userFeed, time.Minute)
+
+	_, r := openStream(t, ctx, server.URL+"/users/events", "1")
+	reset, _ := readSSE(t, r)
+	assert.Equal(t, "reset", reset.Event)
+	assert.Equal(t, "3", reset.ID)
+
+	// С ID из reset поток продолжается без пропусков
+	repo.add(t, events.New(events.UserCreated, 2, nil))
+	publishOutbox(t, repo)
+	userFeed.Notify()
+	next, _ := readSSE(t, r)
+	assert.Equal(t, "4", next.ID)
+}
@@ -0,0 +1,257 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"user-api/internal/handlers"
+	"user-api/internal/middleware"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/tenant"
+	"user-api/pkg/client"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsUserService запоминает параметры последнего GetUserStats и
+// считает вызовы; при fail возвращает ошибку
+type statsUserService struct {
+	mockUserService
+	calls   atomic.Int32
+	filters map[string]interface{}
+	opts    models.UserStatsOptions
+	fail    atomic.Bool
+}
+
+func (s *statsUserService) GetUserStats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error) {
+	s.calls.Add(1)
+	if s.fail.Load() {
+		return nil, errors.New("database is down")
+	}
+	s.filters, s.opts = filters, opts
+	mean, median := 30.5, 29.0
+	histogram := models.NewAgeHistogram(opts.AgeBuckets)
+	histogram[1].Count = 2
+	return &models.UserStats{
+		Total:        2,
+		MeanAge:      &mean,
+		MedianAge:    &median,
+		AgeHistogram: histogram,
+		Interval:     opts.Interval,
+		Signups: []models.SignupCount{
+			{Period: time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), Count: 2},
+		},
+	}, nil
+}
+
+func setupStatsRouter(svc service.UserService, ttl time.Duration) http.Handler {
+	router := setupTestRouter()
+	router.GET("/users/stats", middleware.CacheControl(ttl), handlers.NewUserHandler(svc).GetUserStats)
+	return router
+}
+
+func TestGetUserStats(t *testing.T) {
+	svc := &statsUserService{}
+	router := setupStatsRouter(svc, 30*time.Second)
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/users/stats?min_age=18&verified=true&metadata.department=sales&sort=name&age_buckets=20,%2040,60&interval=week", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "private, max-age=30", w.Header().Get("Cache-Control"))
+	assert.Equal(t, models.UserStatsOptions{AgeBuckets: []int{20, 40, 60}, Interval: models.IntervalWeek}, svc.opts)
+	assert.Equal(t, map[string]interface{}{
+		"min_age":  18,
+		"verified": true,
+		"metadata": map[string]string{"department": "sales"},
+	}, svc.filters)
+
+	var stats models.UserStats
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Equal(t, 2, stats.Total)
+	require.Len(t, stats.AgeHistogram, 4)
+	assert.Equal(t, []string{"<20", "20-39", "40-59", "60+"},
+		[]string{stats.AgeHistogram[0].Label, stats.AgeHistogram[1].Label, stats.AgeHistogram[2].Label, stats.AgeHistogram[3].Label})
+	assert.Nil(t, stats.AgeHistogram[0].Min)
+	assert.Equal(t, 39, *stats.AgeHistogram[1].Max)
+	assert.Nil(t, stats.AgeHistogram[3].Max)
+	assert.Equal(t, 2, stats.AgeHistogram[1].Count)
+
+	// Параметры по умолчанию
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/stats", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, models.DefaultAgeBuckets, svc.opts.AgeBuckets)
+	assert.Equal(t, models.IntervalDay, svc.opts.Interval)
+
+	// Окно графика регистраций; за 30 лет месяцев меньше MaxSignupPeriods
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/stats?interval=month&from=1996-01-01&to=2026-01-05T03:00:00%2B03:00", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, time.Date(1996, 1, 1, 0, 0, 0, 0, time.UTC), *svc.opts.From)
+	assert.Equal(t, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), *svc.opts.To)
+
+	// XML
+	req = httptest.NewRequest(http.MethodGet, "/users/stats", nil)
+	req.Header.Set("Accept", "application/xml")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "<user_stats>")
+}
+
+func TestGetUserStatsInvalidParameters(t *testing.T) {
+	svc := &statsUserService{}
+	router := setupStatsRouter(svc, 30*time.Second)
+
+	for _, query := range []string{
+		"age_buckets=30,20",
+		"age_buckets=0,10",
+		"age_buckets=18,abc",
+		"age_buckets=" + strings.Repeat("1,", 20) + "1",
+		"interval=year",
+		"from=2026-13-01",
+		"from=2026-02-01&to=2026-01-01",
+		"from=1990-01-01&to=2026-01-01",
+		"metadata.Bad-Key=1",
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/stats?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+		assert.Empty(t, w.Header().Get("Cache-Control"), query)
+	}
+	assert.Zero(t, svc.calls.Load())
+
+	// Запрос проходит проверку по схеме OpenAPI, а сообщение переводится
+	localized := setupLocalizedRouter(t, true)
+	w, resp := localizedRequest(t, localized, http.MethodGet, "/api/v1/users/stats?age_buckets=30,20", "ru", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, "границы групп возраста должны возрастать и быть от 1 до 150", resp.Message)
+	w, _ = localizedRequest(t, localized, http.MethodGet, "/api/v1/users/stats?interval=day&min_age=18", "", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Ошибки сервиса не кэшируются клиентом
+	svc.fail.Store(true)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/stats", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get("Cache-Control"))
+}
+
+func TestCachedUserStats(t *testing.T) {
+	next := &statsUserService{}
+	svc := service.NewCachedUserStats(next, 50*time.Millisecond)
+	ctx := tenant.WithID(context.Background(), tenant.DefaultID)
+	opts := models.UserStatsOptions{AgeBuckets: models.DefaultAgeBuckets, Interval: models.IntervalDay}
+	filters := map[string]interface{}{"min_age": 18, "metadata": map[string]string{"a": "1", "b": "2"}}
+
+	first, err := svc.GetUserStats(ctx, filters, opts)
+	require.NoError(t, err)
+	second, err := svc.GetUserStats(ctx, map[string]interface{}{"metadata": map[string]string{"b": "2", "a": "1"}, "min_age": 18}, opts)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, int32(1), next.calls.Load())
+
+	// Другие параметры и другой арендатор считаются отдельно
+	_, err = svc.GetUserStats(ctx, filters, models.UserStatsOptions{AgeBuckets: models.DefaultAgeBuckets, Interval: models.IntervalWeek})
+	require.NoError(t, err)
+	_, err = svc.GetUserStats(tenant.WithID(context.Background(), 2), filters, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), next.calls.Load())
+
+	// Ошибки не запоминаются
+	next.fail.Store(true)
+	_, err = svc.GetUserStats(ctx, nil, opts)
+	assert.Error(t, err)
+	next.fail.Store(false)
+	_, err = svc.GetUserStats(ctx, nil, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(5), next.calls.Load())
+
+	// После ttl статистика считается заново
+	time.Sleep(60 * time.Millisecond)
+	third, err := svc.GetUserStats(ctx, filters, opts)
+	require.NoError(t, err)
+	assert.NotSame(t, first, third)
+	assert.Equal(t, int32(6), next.calls.Load())
+}
+
+// blockingStatsService считает статистику, пока не закрыт release, и
+// запоминает, был ли отменен контекст расчета
+type blockingStatsService struct {
+	mockUserService
+	started  chan struct{}
+	release  chan struct{}
+	canceled atomic.Bool
+}
+
+func (s *blockingStatsService) GetUserStats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error) {
+	close(s.started)
+	<-s.release
+	s.canceled.Store(ctx.Err() != nil)
+	return &models.UserStats{Total: 1}, nil
+}
+
+func TestCachedUserStatsSurvivesCanceledCaller(t *testing.T) {
+	next := &blockingStatsService{started: make(chan struct{}), release: make(chan struct{})}
+	svc := service.NewCachedUserStats(next, time.Minute)
+	opts := models.UserStatsOptions{AgeBuckets: models.DefaultAgeBuckets, Interval: models.IntervalDay}
+
+	// Первый вызывающий запускает расчет и уходит
+	ctx, cancel := context.WithCancel(tenant.WithID(context.Background(), tenant.DefaultID))
+	firstErr := make(chan error)
+	go func() {
+		_, err := svc.GetUserStats(ctx, nil, opts)
+		firstErr <- err
+	}()
+	<-next.started
+
+	second := make(chan *models.UserStats)
+	go func() {
+		stats, err := svc.GetUserStats(tenant.WithID(context.Background(), tenant.DefaultID), nil, opts)
+		assert.NoError(t, err)
+		second <- stats
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(next.release)
+
+	// Второй получает результат общего расчета, который не был отменен
+	stats := <-second
+	require.NotNil(t, stats)
+	assert.Equal(t, 1, stats.Total)
+	assert.False(t, next.canceled.Load())
+}
+
+func TestClientGetUserStats(t *testing.T) {
+	svc := &statsUserService{}
+	router := setupTestRouter()
+	router.GET("/api/v1/users/stats", handlers.NewUserHandler(svc).GetUserStats)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	c, err := client.New(server.URL)
+	require.NoError(t, err)
+	stats, err := c.GetUserStats(context.Background(), &client.UserFilter{Group: 3},
+		&client.UserStatsOptions{AgeBuckets: []int{30, 50}, Interval: "month"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, stats.Total)
+	assert.Equal(t, 30.5, *stats.MeanAge)
+	assert.Equal(t, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC), stats.Signups[0].Period)
+	assert.Equal(t, map[string]interface{}{"group": 3}, svc.filters)
+	assert.Equal(t, []int{30, 50}, svc.opts.AgeBuckets)
+
+	_, err = c.GetUserStats(context.Background(), nil, &client.UserStatsOptions{Interval: "year"})
+	assert.ErrorIs(t, err, client.ErrInvalidRequest)
+}
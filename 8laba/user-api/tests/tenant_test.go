@@ -0,0 +1,304 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/feed"
+	"user-api/internal/handlers"
+	"user-api/internal/middleware"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/tenant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var tenantSecret = []byte("tenant-test-secret")
+
+// memTenantRepo - TenantRepository в памяти, считает обращения к GetByID
+type memTenantRepo struct {
+	mu      sync.Mutex
+	tenants map[int]*models.Tenant
+	lookups int
+}
+
+func newMemTenantRepo() *memTenantRepo {
+	return &memTenantRepo{tenants: map[int]*models.Tenant{
+		tenant.DefaultID: {ID: tenant.DefaultID, Name: "Default", Slug: "default", Status: models.TenantStatusActive},
+	}}
+}
+
+func (r *memTenantRepo) Create(req *models.CreateTenantRequest) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, t := range r.tenants {
+		if t.Slug == req.Slug {
+			return nil, models.ErrTenantSlugTaken
+		}
+	}
+	t := &models.Tenant{ID: len(r.tenants) + 1, Name: req.Name, Slug: req.Slug, Status: models.TenantStatusActive}
+	r.tenants[t.ID] = t
+	copied := *t
+	return &copied, nil
+}
+
+func (r *memTenantRepo) GetByID(id int) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups++
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, models.ErrTenantNotFound
+	}
+	copied := *t
+	return &copied, nil
+}
+
+func (r *memTenantRepo) GetAll(page, pageSize int) ([]models.Tenant, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenants := make([]models.Tenant, 0, len(r.tenants))
+	for id := 1; id <= len(r.tenants); id++ {
+		tenants = append(tenants, *r.tenants[id])
+	}
+	return tenants, len(tenants), nil
+}
+
+func (r *memTenantRepo) SetStatus(id int, status string) (*models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tenants[id]
+	if !ok {
+		return nil, models.ErrTenantNotFound
+	}
+	t.Status = status
+	copied := *t
+	return &copied, nil
+}
+
+func tenantToken(tenantID int, expiresIn time.Duration) string {
+	return tenant.SignToken(tenant.Claims{
+		Subject:   "42",
+		TenantID:  tenantID,
+		ExpiresAt: time.Now().Add(expiresIn).Unix(),
+	}, tenantSecret)
+}
+
+func TestParseTenantToken(t *testing.T) {
+	now := time.Now()
+
+	claims, err := tenant.ParseToken(tenantToken(7, time.Hour), tenantSecret, now)
+	require.NoError(t, err)
+	assert.Equal(t, 7, claims.TenantID)
+	assert.Equal(t, "42", claims.Subject)
+
+	invalid := map[string]string{
+		"expired":      tenantToken(7, -time.Minute),
+		"wrong secret": tenant.SignToken(tenant.Claims{TenantID: 7, ExpiresAt: now.Add(time.Hour).Unix()}, []byte("other")),
+		"no tenant":    tenant.SignToken(tenant.Claims{ExpiresAt: now.Add(time.Hour).Unix()}, tenantSecret),
+		"no exp":       tenant.SignToken(tenant.Claims{TenantID: 7}, tenantSecret),
+		"not yet":      tenant.SignToken(tenant.Claims{TenantID: 7, ExpiresAt: now.Add(2 * time.Hour).Unix(), NotBefore: now.Add(time.Hour).Unix()}, tenantSecret),
+		"alg none":     "eyJhbGciOiJub25lIn0.eyJ0ZW5hbnRfaWQiOjcsImV4cCI6OTk5OTk5OTk5OX0.",
+		"garbage":      "not-a-token",
+	}
+	for name, token := range invalid {
+		_, err := tenant.ParseToken(token, tenantSecret, now)
+		assert.ErrorIs(t, err, models.ErrInvalidAuthToken, name)
+	}
+}
+
+func TestResolveTenant(t *testing.T) {
+	repo := newMemTenantRepo()
+	tenants := service.NewTenantManager(repo, 0)
+	active, err := tenants.CreateTenant(&models.CreateTenantRequest{Name: "Acme", Slug: "acme"})
+	require.NoError(t, err)
+	suspended, err := tenants.CreateTenant(&models.CreateTenantRequest{Name: "Globex", Slug: "globex"})
+	require.NoError(t, err)
+	_, err = tenants.SuspendTenant(suspended.ID)
+	require.NoError(t, err)
+
+	resolver, err := tenant.NewResolver(true, tenantSecret, []string{"10.0.0.0/8", "192.168.1.5"}, tenants, nil)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	cases := []struct {
+		name string
+		cred tenant.Credentials
+		id   int
+		err  error
+	}{
+		{"token", tenant.Credentials{Bearer: tenantToken(active.ID, time.Hour), RemoteAddr: "203.0.113.1:5000"}, active.ID, nil},
+		{"token wins over header", tenant.Credentials{Bearer: tenantToken(active.ID, time.Hour), Header: "1", RemoteAddr: "10.1.1.1:5000"}, active.ID, nil},
+		{"invalid token", tenant.Credentials{Bearer: "bad", RemoteAddr: "10.1.1.1:5000"}, 0, models.ErrInvalidAuthToken},
+		{"trusted network", tenant.Credentials{Header: "1", RemoteAddr: "10.1.1.1:5000"}, tenant.DefaultID, nil},
+		{"trusted address", tenant.Credentials{Header: "1", RemoteAddr: "192.168.1.5:5000"}, tenant.DefaultID, nil},
+		{"verified certificate", tenant.Credentials{Header: "1", RemoteAddr: "203.0.113.1:5000", VerifiedCert: true}, tenant.DefaultID, nil},
+		{"untrusted header", tenant.Credentials{Header: "1", RemoteAddr: "203.0.113.1:5000"}, 0, models.ErrUntrustedCaller},
+		{"invalid header", tenant.Credentials{Header: "acme", RemoteAddr: "10.1.1.1:5000"}, 0, models.ErrTenantRequired},
+		{"no tenant", tenant.Credentials{RemoteAddr: "10.1.1.1:5000"}, 0, models.ErrTenantRequired},
+		{"unknown tenant", tenant.Credentials{Header: "99", RemoteAddr: "10.1.1.1:5000"}, 0, models.ErrTenantNotFound},
+		{"suspended tenant", tenant.Credentials{Bearer: tenantToken(suspended.ID, time.Hour)}, 0, models.ErrTenantSuspended},
+	}
+	for _, tc := range cases {
+		id, err := resolver.Resolve(ctx, tc.cred)
+		if tc.err != nil {
+			assert.ErrorIs(t, err, tc.err, tc.name)
+			continue
+		}
+		require.NoError(t, err, tc.name)
+		assert.Equal(t, tc.id, id, tc.name)
+	}
+}
+
+func TestResolveTenantDisabled(t *testing.T) {
+	resolver, err := tenant.NewResolver(false, nil, nil, nil, nil)
+	require.NoError(t, err)
+
+	id, err := resolver.Resolve(context.Background(), tenant.Credentials{Header: "5", RemoteAddr: "203.0.113.1:5000"})
+	require.NoError(t, err)
+	assert.Equal(t, tenant.DefaultID, id)
+	assert.True(t, resolver.Trusted(tenant.Credentials{RemoteAddr: "203.0.113.1:5000"}))
+}
+
+func TestParseTrustedNetworks(t *testing.T) {
+	networks, err := tenant.ParseNetworks([]string{"10.0.0.0/8", " 127.0.0.1 ", "::1", ""})
+	require.NoError(t, err)
+	require.Len(t, networks, 3)
+	assert.Equal(t, "127.0.0.1/32", networks[1].String())
+	assert.Equal(t, "::1/128", networks[2].String())
+
+	_, err = tenant.ParseNetworks([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+	_, err = tenant.ParseNetworks([]string{"localhost"})
+	assert.Error(t, err)
+}
+
+func TestTenantMiddleware(t *testing.T) {
+	tenants := service.NewTenantManager(newMemTenantRepo(), 0)
+	resolver, err := tenant.NewResolver(true, tenantSecret, []string{"10.0.0.0/8"}, tenants, nil)
+	require.NoError(t, err)
+
+	router := setupTestRouter()
+	router.GET("/whoami", middleware.Tenant(resolver, "X-Tenant-ID"), func(c *gin.Context) {
+		id, _ := tenant.FromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"tenant_id": id})
+	})
+	router.GET("/admin", middleware.TrustedOnly(resolver), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(path, remoteAddr string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request("/whoami", "203.0.113.1:5000", map[string]string{"Authorization": "Bearer " + tenantToken(1, time.Hour)})
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"tenant_id": 1}`, w.Body.String())
+
+	w = request("/whoami", "203.0.113.1:5000", nil)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+
+	// X-Forwarded-For не делает вызывающего доверенным
+	w = request("/whoami", "203.0.113.1:5000", map[string]string{"X-Tenant-ID": "1", "X-Forwarded-For": "10.0.0.1"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = request("/whoami", "10.0.0.1:5000", map[string]string{"X-Tenant-ID": "1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = request("/whoami", "10.0.0.1:5000", map[string]string{"X-Tenant-ID": "99"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	assert.Equal(t, http.StatusForbidden, request("/admin", "203.0.113.1:5000", nil).Code)
+	assert.Equal(t, http.StatusNoContent, request("/admin", "10.0.0.1:5000", nil).Code)
+}
+
+func TestTenantStatusCache(t *testing.T) {
+	repo := newMemTenantRepo()
+	tenants := service.NewTenantManager(repo, time.Minute)
+	ctx := context.Background()
+
+	require.NoError(t, tenants.CheckTenant(ctx, 1))
+	require.NoError(t, tenants.CheckTenant(ctx, 1))
+	assert.Equal(t, 1, repo.lookups)
+
+	// Приостановка через тот же экземпляр действует сразу
+	_, err := tenants.SuspendTenant(1)
+	require.NoError(t, err)
+	assert.ErrorIs(t, tenants.CheckTenant(ctx, 1), models.ErrTenantSuspended)
+	assert.Equal(t, 1, repo.lookups)
+
+	// Отсутствующий арендатор не кэшируется
+	assert.ErrorIs(t, tenants.CheckTenant(ctx, 2), models.ErrTenantNotFound)
+	assert.ErrorIs(t, tenants.CheckTenant(ctx, 2), models.ErrTenantNotFound)
+	assert.Equal(t, 3, repo.lookups)
+}
+
+func TestTenantHandlers(t *testing.T) {
+	handler := handlers.NewTenantHandler(service.NewTenantManager(newMemTenantRepo(), time.Minute))
+	router := setupTestRouter()
+	router.POST("/admin/tenants", handler.CreateTenant)
+	router.GET("/admin/tenants/:id", handler.GetTenant)
+	router.POST("/admin/tenants/:id/suspend", handler.SuspendTenant)
+	router.POST("/admin/tenants/:id/activate", handler.ActivateTenant)
+
+	create := func(name, slug string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(models.CreateTenantRequest{Name: name, Slug: slug})
+		req := httptest.NewRequest(http.MethodPost, "/admin/tenants", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := create("Acme", "acme")
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.Tenant
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, models.TenantStatusActive, created.Status)
+
+	assert.Equal(t, http.StatusConflict, create("Acme 2", "acme").Code)
+	assert.Equal(t, http.StatusBadRequest, create("Bad", "Not A Slug").Code)
+
+	do := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	w = do(http.MethodPost, "/admin/tenants/2/suspend")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"suspended"`)
+
+	w = do(http.MethodPost, "/admin/tenants/2/activate")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"active"`)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/tenants/99").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/admin/tenants/abc").Code)
+}
+
+func TestFeedFiltersTenant(t *testing.T) {
+	filter := feed.Filter{TenantID: 2}
+
+	assert.True(t, filter.Match(events.New(events.UserCreated, 1, &models.User{ID: 1, TenantID: 2})))
+	assert.False(t, filter.Match(events.New(events.UserCreated, 3, &models.User{ID: 3, TenantID: 1})))
+	assert.False(t, filter.Match(events.New(events.UserDeleted, 4, nil)))
+	assert.True(t, feed.Filter{}.Match(events.New(events.UserCreated, 3, &models.User{ID: 3, TenantID: 1})))
+}
@@ -2,20 +2,29 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 	"user-api/internal/handlers"
 	"user-api/internal/models"
+	"user-api/internal/validation"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
 	_ "github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
 func setupTestRouter() *gin.Engine {
 	gin.SetMode(gin.TestMode)
+	// Теги правил email из моделей запросов; в тестах без ограничений
+	if err := validation.Register(binding.Validator.Engine().(*validator.Validate), nil); err != nil {
+		panic(err)
+	}
 	router := gin.Default()
 	return router
 }
@@ -115,7 +124,7 @@ func TestDeleteUser(t *testing.T) {
 // Mock service
 type mockUserService struct{}
 
-func (m *mockUserService) CreateUser(req *models.CreateUserRequest) (*models.User, error) {
+func (m *mockUserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	return &models.User{
 		ID:    1,
 		Name:  req.Name,
@@ -124,7 +133,7 @@ func (m *mockUserService) CreateUser(req *models.CreateUserRequest) (*models.Use
 	}, nil
 }
 
-func (m *mockUserService) GetUser(id int) (*models.User, error) {
+func (m *mockUserService) GetUser(ctx context.Context, id int) (*models.User, error) {
 	return &models.User{
 		ID:    id,
 		Name:  "Test User",
@@ -133,7 +142,7 @@ func (m *mockUserService) GetUser(id int) (*models.User, error) {
 	}, nil
 }
 
-func (m *mockUserService) GetUsers(page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
+func (m *mockUserService) GetUsers(ctx context.Context, page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
 	return &models.UserListResponse{
 		Users: []models.User{
 			{ID: 1, Name: "User 1", Email: "user1@example.com", Age: 25},
@@ -145,7 +154,7 @@ func (m *mockUserService) GetUsers(page, pageSize int, filters map[string]interf
 	}, nil
 }
 
-func (m *mockUserService) UpdateUser(id int, req *models.UpdateUserRequest) (*models.User, error) {
+func (m *mockUserService) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
 	return &models.User{
 		ID:    id,
 		Name:  req.Name,
@@ -154,6 +163,29 @@ func (m *mockUserService) UpdateUser(id int, req *models.UpdateUserRequest) (*mo
 	}, nil
 }
 
-func (m *mockUserService) DeleteUser(id int) error {
+func (m *mockUserService) DeleteUser(ctx context.Context, id int) error {
 	return nil
 }
+
+func (m *mockUserService) VerifyEmail(ctx context.Context, token string) (*models.User, error) {
+	if token != "valid" {
+		return nil, models.ErrInvalidToken
+	}
+	now := time.Now()
+	return &models.User{ID: 1, Name: "Test User", Email: "test@example.com", Age: 25, EmailVerifiedAt: &now}, nil
+}
+
+func (m *mockUserService) ResendVerification(ctx context.Context, id int) error {
+	if id == 2 {
+		return models.ErrEmailAlreadyVerified
+	}
+	return nil
+}
+
+func (m *mockUserService) GetUserStats(ctx context.Context, filters map[string]interface{}, opts models.UserStatsOptions) (*models.UserStats, error) {
+	return &models.UserStats{
+		AgeHistogram: models.NewAgeHistogram(opts.AgeBuckets),
+		Interval:     opts.Interval,
+		Signups:      []models.SignupCount{},
+	}, nil
+}
@@ -0,0 +1,278 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"user-api/internal/cli"
+	"user-api/internal/handlers"
+	"user-api/internal/models"
+	"user-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// memUserService хранит пользователей в памяти и запоминает фильтры
+// последнего запроса списка
+type memUserService struct {
+	service.UserService
+	mu      sync.Mutex
+	users   []models.User
+	nextID  int
+	filters map[string]interface{}
+}
+
+func (s *memUserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, user := range s.users {
+		if strings.EqualFold(user.Email, req.Email) {
+			return nil, models.ErrEmailTaken
+		}
+	}
+	s.nextID++
+	user := models.User{ID: s.nextID, TenantID: 1, Name: req.Name, Email: req.Email, Age: req.Age, Metadata: req.Metadata}
+	s.users = append(s.users, user)
+	return &user, nil
+}
+
+func (s *memUserService) GetUser(ctx context.Context, id int) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == id {
+			user := s.users[i]
+			return &user, nil
+		}
+	}
+	return nil, models.ErrUserNotFound
+}
+
+func (s *memUserService) GetUsers(ctx context.Context, page, pageSize int, filters map[string]interface{}) (*models.UserListResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filters = filters
+	response := &models.UserListResponse{Users: []models.User{}, Total: len(s.users), Page: page, PageSize: pageSize}
+	response.TotalPages = (len(s.users) + pageSize - 1) / pageSize
+	for i := (page - 1) * pageSize; i < len(s.users) && i < page*pageSize; i++ {
+		response.Users = append(response.Users, s.users[i])
+	}
+	return response, nil
+}
+
+func (s *memUserService) UpdateUser(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID != id {
+			continue
+		}
+		if req.Name != "" {
+			s.users[i].Name = req.Name
+		}
+		if req.Email != "" {
+			s.users[i].Email = req.Email
+		}
+		if req.Age != 0 {
+			s.users[i].Age = req.Age
+		}
+		user := s.users[i]
+		return &user, nil
+	}
+	return nil, models.ErrUserNotFound
+}
+
+func (s *memUserService) DeleteUser(ctx context.Context, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.users {
+		if s.users[i].ID == id {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			return nil
+		}
+	}
+	return models.ErrUserNotFound
+}
+
+// startUserAPI запускает API пользователей поверх users и возвращает его адрес
+func startUserAPI(t *testing.T, users service.UserService) string {
+	t.Helper()
+
+	router := setupTestRouter()
+	startUserRoutes(router.Group("/api/v1"), users)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+// startUserRoutes регистрирует маршруты пользователей в api
+func startUserRoutes(api *gin.RouterGroup, users service.UserService) {
+	handler := handlers.NewUserHandler(users)
+	api.GET("/users", handler.GetUsers)
+	api.GET("/users/:id", handler.GetUser)
+	api.POST("/users", handler.CreateUser)
+	api.PUT("/users/:id", handler.UpdateUser)
+	api.DELETE("/users/:id", handler.DeleteUser)
+}
+
+func runUserctl(t *testing.T, stdin string, args ...string) (string, error) {
+	t.Helper()
+	// Сообщения userctl переводятся на язык системы
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+
+	cmd := cli.NewRootCommand()
+	var stdout, stderr bytes.Buffer
+	cmd.SetIn(strings.NewReader(stdin))
+	cmd.SetOut(&stdout)
+	cmd.SetErr(&stderr)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return stdout.String(), err
+}
+
+func TestUserctlCRUDOverAPI(t *testing.T) {
+	users := &memUserService{}
+	api := startUserAPI(t, users)
+
+	out, err := runUserctl(t, "", "--api", api, "create", "--name", "Jane Doe", "--email", "jane@example.com",
+		"--age", "30", "--metadata", "department=sales", "--metadata", "level=3", "-o", "json")
+	require.NoError(t, err)
+	var created models.User
+	require.NoError(t, json.Unmarshal([]byte(out), &created))
+	assert.Equal(t, "Jane Doe", created.Name)
+	assert.JSONEq(t, `"sales"`, string(created.Metadata["department"]))
+	assert.JSONEq(t, `3`, string(created.Metadata["level"]))
+
+	out, err = runUserctl(t, "", "--api", api, "get", "1")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Name:")
+	assert.Contains(t, out, "jane@example.com")
+
+	out, err = runUserctl(t, "", "--api", api, "update", "1", "--age", "31", "-o", "yaml")
+	require.NoError(t, err)
+	var updated map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(out), &updated))
+	assert.Equal(t, 31, updated["age"])
+	assert.True(t, strings.HasPrefix(out, "id: 1\n"), "YAML keeps the JSON field order")
+
+	_, err = runUserctl(t, "", "--api", api, "update", "1")
+	assert.ErrorContains(t, err, "nothing to update")
+
+	// Ошибка проверки возвращается сообщением API
+	_, err = runUserctl(t, "", "--api", api, "create", "--name", "J", "--email", "j@example.com", "--age", "20")
+	assert.ErrorContains(t, err, "HTTP 400")
+
+	out, err = runUserctl(t, "", "--api", api, "list", "--min-age", "18", "--verified", "false",
+		"--sort", "name", "--order", "desc", "--metadata", "department=sales")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Jane Doe")
+	assert.Contains(t, out, "Page 1 of 1, 1 users total")
+	assert.Equal(t, map[string]interface{}{
+		"min_age":    18,
+		"verified":   false,
+		"sort_by":    "name",
+		"sort_order": "desc",
+		"metadata":   map[string]string{"department": "sales"},
+	}, users.filters)
+
+	_, err = runUserctl(t, "", "--api", api, "list", "--metadata", "Bad-Key=1")
+	assert.ErrorContains(t, err, "invalid metadata key")
+
+	_, err = runUserctl(t, "", "--api", api, "delete", "1")
+	require.NoError(t, err)
+	_, err = runUserctl(t, "", "--api", api, "get", "1")
+	assert.ErrorContains(t, err, "HTTP 404")
+}
+
+func TestUserctlImportExport(t *testing.T) {
+	users := &memUserService{}
+	api := startUserAPI(t, users)
+	dir := t.TempDir()
+
+	input := "name,email,age,metadata\n" +
+		"Alice Smith,alice@example.com,30,\"{\"\"team\"\": \"\"core\"\"}\"\n" +
+		"Bob Jones,bob@example.com,41,\n" +
+		"Alice Again,ALICE@example.com,25,\n" +
+		"'=Eve,eve@example.com,35,\n"
+	csvFile := filepath.Join(dir, "users.csv")
+	require.NoError(t, os.WriteFile(csvFile, []byte(input), 0o644))
+
+	// Проверка без записи находит повтор email внутри файла
+	out, err := runUserctl(t, "", "import", "--dry-run", csvFile, "-o", "json")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"total": 4, "created": 3, "failed": 1, "errors": [
+		{"record": 3, "email": "ALICE@example.com", "error": "email already exists (record 1)"}]}`, out)
+	assert.Empty(t, users.users)
+
+	_, err = runUserctl(t, "", "--api", api, "import", csvFile)
+	assert.ErrorContains(t, err, "record 3")
+	assert.Len(t, users.users, 2)
+
+	users.users, users.nextID = nil, 0
+	out, err = runUserctl(t, "", "--api", api, "import", "--continue-on-error", csvFile)
+	assert.ErrorContains(t, err, "1 of 4 users were not created")
+	assert.Contains(t, out, "Created 3 of 4 users")
+	require.Len(t, users.users, 3)
+	assert.Equal(t, "=Eve", users.users[2].Name)
+	assert.JSONEq(t, `"core"`, string(users.users[0].Metadata["team"]))
+
+	// Выгрузка читает все страницы
+	for i := 0; i < 150; i++ {
+		_, err := users.CreateUser(context.Background(), &models.CreateUserRequest{
+			Name: "Bulk User", Email: fmt.Sprintf("bulk%d@example.com", i), Age: 20,
+		})
+		require.NoError(t, err)
+	}
+	exported := filepath.Join(dir, "users.jsonl")
+	_, err = runUserctl(t, "", "--api", api, "export", "--file", exported, "--min-age", "18")
+	require.NoError(t, err)
+	assert.Equal(t, "id", users.filters["sort_by"])
+	data, err := os.ReadFile(exported)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(strings.TrimSpace(string(data)), "\n"), 153)
+
+	// Выгрузка в CSV снова читается импортом
+	out, err = runUserctl(t, "", "--api", api, "export", "--format", "csv")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(out, "id,tenant_id,name,email,age,"))
+	assert.Contains(t, out, "'=Eve")
+	out, err = runUserctl(t, out, "import", "--format", "csv", "--dry-run", "-")
+	require.NoError(t, err)
+	assert.Contains(t, out, "153 of 153 users are valid")
+
+	for _, name := range []string{"users.json", "users.yaml"} {
+		file := filepath.Join(dir, name)
+		_, err = runUserctl(t, "", "--api", api, "export", "-f", file)
+		require.NoError(t, err)
+		out, err = runUserctl(t, "", "import", "--dry-run", file)
+		require.NoError(t, err, name)
+		assert.Contains(t, out, "153 of 153 users are valid", name)
+	}
+}
+
+func TestUserctlCompletion(t *testing.T) {
+	out, err := runUserctl(t, "", "completion", "bash")
+	require.NoError(t, err)
+	assert.Contains(t, out, "__start_userctl")
+
+	out, err = runUserctl(t, "", "__complete", "list", "--sort", "")
+	require.NoError(t, err)
+	assert.Contains(t, out, "created_at\n")
+
+	out, err = runUserctl(t, "", "__complete", "get", "1", "-o", "")
+	require.NoError(t, err)
+	assert.Contains(t, out, "yaml\n")
+
+	_, err = runUserctl(t, "", "--api", "http://localhost", "list", "-o", "xml")
+	assert.ErrorContains(t, err, "unknown output format")
+}
@@ -0,0 +1,210 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"user-api/internal/handlers"
+	"user-api/internal/i18n"
+	"user-api/internal/models"
+	"user-api/internal/repository"
+	"user-api/internal/service"
+	"user-api/internal/validation"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeEmail(t *testing.T) {
+	cases := map[string]string{
+		"john@example.com":          "john@example.com",
+		"  John.Doe@Example.COM ":   "john.doe@example.com",
+		"user@Пример.РФ":            "user@xn--e1afmkfd.xn--p1ai",
+		"user@bücher.example":       "user@xn--bcher-kva.example",
+		"\"a@b\"@example.com":       "\"a@b\"@example.com",
+		"trailing.dot@example.com.": "trailing.dot@example.com",
+	}
+	for input, want := range cases {
+		got, err := validation.NormalizeEmail(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+
+	for _, input := range []string{"", "john", "john@", "@example.com", "john@exa mple.com", "john@" + strings.Repeat("a", 250) + ".com"} {
+		_, err := validation.NormalizeEmail(input)
+		assert.ErrorIs(t, err, models.ErrInvalidEmail, input)
+	}
+}
+
+func TestPlanEmailNormalization(t *testing.T) {
+	plan := repository.PlanEmailNormalization([]repository.StoredEmail{
+		{UserID: 1, TenantID: 1, Email: "a@пример.рф"},
+		{UserID: 2, TenantID: 1, Email: "a@xn--e1afmkfd.xn--p1ai"},
+		{UserID: 3, TenantID: 2, Email: "A@Пример.РФ"},
+		{UserID: 4, TenantID: 2, Email: "not an email"},
+		{UserID: 5, TenantID: 2, Email: "b@example.com"},
+	}, validation.NormalizeEmail)
+
+	// Старая строка с IDN доменом и новая в punycode - один адрес
+	assert.Equal(t, []repository.EmailConflict{
+		{TenantID: 1, Email: "a@xn--e1afmkfd.xn--p1ai", UserIDs: []int{1, 2}},
+	}, plan.Conflicts)
+	assert.Equal(t, []repository.StoredEmail{
+		{UserID: 1, TenantID: 1, Email: "a@xn--e1afmkfd.xn--p1ai"},
+		{UserID: 3, TenantID: 2, Email: "a@xn--e1afmkfd.xn--p1ai"},
+	}, plan.Changes)
+	assert.Equal(t, []repository.StoredEmail{{UserID: 4, TenantID: 2, Email: "not an email"}}, plan.Invalid)
+}
+
+func TestNormalizeName(t *testing.T) {
+	// "e" и комбинируемый акцент собираются в одну букву (NFC)
+	name, err := validation.NormalizeName("  José \t  Garcia\n")
+	require.NoError(t, err)
+	assert.Equal(t, "José Garcia", name)
+
+	_, err = validation.NormalizeName("  J   ")
+	assert.ErrorIs(t, err, models.ErrInvalidName)
+}
+
+func TestEmailRules(t *testing.T) {
+	rules, err := validation.NewEmailRules(true, []string{"Throwaway.Example"},
+		[]string{"blocked.example"}, []string{"Spammer@Example.com"})
+	require.NoError(t, err)
+
+	assert.True(t, rules.Disposable("someone@mailinator.com"))
+	assert.True(t, rules.Disposable("someone@eu.MAILINATOR.com"))
+	assert.True(t, rules.Disposable("someone@throwaway.example"))
+	assert.False(t, rules.Disposable("someone@example.com"))
+	assert.False(t, rules.Disposable("someone@notmailinator.com"))
+
+	assert.True(t, rules.Blocked("anyone@blocked.example"))
+	assert.True(t, rules.Blocked("anyone@mail.blocked.example"))
+	assert.True(t, rules.Blocked(" spammer@EXAMPLE.com"))
+	assert.False(t, rules.Blocked("someone@example.com"))
+
+	// Без block_disposable встроенный список не применяется
+	rules, err = validation.NewEmailRules(false, []string{"throwaway.example"}, nil, nil)
+	require.NoError(t, err)
+	assert.False(t, rules.Disposable("someone@mailinator.com"))
+	assert.False(t, rules.Disposable("someone@throwaway.example"))
+
+	_, err = validation.NewEmailRules(false, nil, []string{"bad domain"}, nil)
+	assert.Error(t, err)
+
+	domains, err := validation.ReadDomains(strings.NewReader("# Одноразовая почта\nfoo.example\n\n  bar.example  \n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo.example", "bar.example"}, domains)
+}
+
+func TestEmailRuleTags(t *testing.T) {
+	rules, err := validation.NewEmailRules(true, nil, []string{"blocked.example"}, nil)
+	require.NoError(t, err)
+	v := validation.New(rules)
+
+	err = v.Struct(&models.CreateUserRequest{Name: "Jane", Email: "jane@yopmail.com", Age: 30})
+	var verrs validator.ValidationErrors
+	require.ErrorAs(t, err, &verrs)
+	assert.Equal(t, validation.TagNotDisposable, verrs[0].Tag())
+
+	err = v.Struct(&models.UpdateUserRequest{Email: "jane@blocked.example"})
+	require.ErrorAs(t, err, &verrs)
+	assert.Equal(t, validation.TagNotBlocked, verrs[0].Tag())
+
+	assert.NoError(t, v.Struct(&models.CreateUserRequest{Name: "Jane", Email: "jane@example.com", Age: 30}))
+	assert.NoError(t, v.Struct(&models.UpdateUserRequest{Name: "Jane"}))
+
+	// Без правил теги ничего не запрещают
+	assert.NoError(t, validation.New(nil).Struct(&models.CreateUserRequest{Name: "Jane", Email: "jane@yopmail.com", Age: 30}))
+
+	translator, err := i18n.New()
+	require.NoError(t, err)
+	require.NoError(t, translator.RegisterValidator(v))
+	message := translator.Localizer("ru").Error(v.Struct(&models.CreateUserRequest{Name: "Jane", Email: "jane@yopmail.com", Age: 30}))
+	assert.Equal(t, "Email: адреса одноразовой почты не принимаются", message)
+}
+
+// memEmailRepo хранит адреса пользователей и запоминает последнее изменение
+type memEmailRepo struct {
+	repository.UserRepository
+	emails  map[int]string
+	updated *models.UpdateUserRequest
+}
+
+func (r *memEmailRepo) EmailExists(ctx context.Context, email string, excludeID int) (bool, error) {
+	for id, existing := range r.emails {
+		if id != excludeID && strings.EqualFold(existing, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memEmailRepo) Create(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
+	return &models.User{ID: 10, Name: req.Name, Email: req.Email, Age: req.Age}, nil
+}
+
+func (r *memEmailRepo) Update(ctx context.Context, id int, req *models.UpdateUserRequest) (*models.User, bool, error) {
+	r.updated = req
+	changed := req.Email != "" && r.emails[id] != req.Email
+	if req.Email != "" {
+		r.emails[id] = req.Email
+	}
+	return &models.User{ID: id, Name: req.Name, Email: r.emails[id]}, changed, nil
+}
+
+func TestUserServiceNormalizesAndChecksEmail(t *testing.T) {
+	repo := &memEmailRepo{emails: map[int]string{1: "alice@example.com"}}
+	users := service.NewUserService(repo, nil, nil)
+	ctx := context.Background()
+
+	req := &models.CreateUserRequest{Name: "  Bob   Smith ", Email: " Bob@Example.COM ", Age: 30}
+	user, err := users.CreateUser(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "Bob Smith", user.Name)
+	assert.Equal(t, "bob@example.com", user.Email)
+	// Запрос вызывающего не меняется
+	assert.Equal(t, " Bob@Example.COM ", req.Email)
+
+	_, err = users.CreateUser(ctx, &models.CreateUserRequest{Name: "Alice", Email: "ALICE@example.com", Age: 30})
+	assert.ErrorIs(t, err, models.ErrEmailTaken)
+
+	// Свой адрес в другом регистре не считается занятым
+	_, err = users.UpdateUser(ctx, 1, &models.UpdateUserRequest{Email: "Alice@Example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", repo.updated.Email)
+
+	_, err = users.UpdateUser(ctx, 2, &models.UpdateUserRequest{Email: "alice@EXAMPLE.com"})
+	assert.ErrorIs(t, err, models.ErrEmailTaken)
+
+	_, err = users.UpdateUser(ctx, 2, &models.UpdateUserRequest{Name: " B "})
+	assert.ErrorIs(t, err, models.ErrInvalidName)
+}
+
+func TestCreateUserConflictReturns409(t *testing.T) {
+	repo := &memEmailRepo{emails: map[int]string{1: "alice@example.com"}}
+	handler := handlers.NewUserHandler(service.NewUserService(repo, nil, nil))
+	router := setupTestRouter()
+	router.POST("/users", handler.CreateUser)
+	router.PUT("/users/:id", handler.UpdateUser)
+
+	w, resp := localizedRequest(t, router, http.MethodPost, "/users", "", map[string]interface{}{
+		"name": "Alice", "email": "Alice@Example.com", "age": 30,
+	})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, models.ErrEmailTaken.Error(), resp.Message)
+
+	w, _ = localizedRequest(t, router, http.MethodPut, "/users/2", "", map[string]interface{}{"email": "ALICE@example.com"})
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w, _ = localizedRequest(t, router, http.MethodPost, "/users", "", map[string]interface{}{
+		"name": "  B  ", "email": "bob@example.com", "age": 30,
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w, _ = localizedRequest(t, router, http.MethodPost, "/users", "", map[string]interface{}{
+		"name": "Bob", "email": "bob@example.com", "age": 30,
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
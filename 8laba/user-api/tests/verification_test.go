@@ -0,0 +1,324 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"user-api/internal/handlers"
+	"user-api/internal/mailer"
+	"user-api/internal/models"
+	"user-api/internal/service"
+	"user-api/internal/verification"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testVerificationSecret = "test-secret-that-is-at-least-32-bytes"
+
+// memVerificationRepo - VerificationRepository в памяти с одним
+// пользователем
+type memVerificationRepo struct {
+	mu     sync.Mutex
+	user   models.User
+	tokens map[string]*models.VerificationToken
+}
+
+func newMemVerificationRepo(user models.User) *memVerificationRepo {
+	return &memVerificationRepo{user: user, tokens: map[string]*models.VerificationToken{}}
+}
+
+func (r *memVerificationRepo) CreateToken(token *models.VerificationToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token.CreatedAt = time.Now()
+	copied := *token
+	r.tokens[token.ID] = &copied
+	return nil
+}
+
+func (r *memVerificationRepo) UseToken(id string, userID int) (*models.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, ok := r.tokens[id]
+	if !ok || token.UserID != userID || token.UsedAt != nil || !token.ExpiresAt.After(time.Now()) {
+		return nil, models.ErrInvalidToken
+	}
+	now := time.Now()
+	token.UsedAt = &now
+	if r.user.Email != token.Email {
+		return nil, models.ErrInvalidToken
+	}
+	if r.user.EmailVerifiedAt == nil {
+		r.user.EmailVerifiedAt = &now
+	}
+	user := r.user
+	return &user, nil
+}
+
+func (r *memVerificationRepo) DeleteExpired() (int64, error) {
+	return 0, nil
+}
+
+var tokenPattern = regexp.MustCompile(`verify-email\?token=(\S+)`)
+
+// lastToken достает токен из последнего записанного письма
+func lastToken(t *testing.T, mail *bytes.Buffer) string {
+	t.Helper()
+	body, err := readQuotedPrintable(mail.String())
+	require.NoError(t, err)
+	matches := tokenPattern.FindAllStringSubmatch(body, -1)
+	require.NotEmpty(t, matches, "message must contain verification link")
+	token, err := url.QueryUnescape(matches[len(matches)-1][1])
+	require.NoError(t, err)
+	return token
+}
+
+func readQuotedPrintable(s string) (string, error) {
+	var out bytes.Buffer
+	_, err := out.ReadFrom(quotedprintable.NewReader(strings.NewReader(s)))
+	return out.String(), err
+}
+
+func newTestVerifier(repo *memVerificationRepo, mail *bytes.Buffer, ttl time.Duration) *service.EmailVerifier {
+	return service.NewEmailVerifier(
+		repo,
+		verification.NewSigner(testVerificationSecret),
+		mailer.NewWriterMailer("User API <no-reply@example.com>", mail),
+		service.EmailVerifierConfig{
+			TokenTTL:    ttl,
+			VerifyURL:   "http://localhost:8080/api/v1/verify-email",
+			SendTimeout: time.Second,
+		},
+	)
+}
+
+func TestSignerRejectsTamperedAndExpiredTokens(t *testing.T) {
+	signer := verification.NewSigner(testVerificationSecret)
+	now := time.Now()
+	claims := verification.Claims{ID: "abc", UserID: 7, ExpiresAt: now.Add(time.Hour).Truncate(time.Second)}
+	token := signer.Sign(claims)
+
+	parsed, err := signer.Parse(token, now)
+	require.NoError(t, err)
+	assert.Equal(t, claims.ID, parsed.ID)
+	assert.Equal(t, claims.UserID, parsed.UserID)
+	assert.True(t, claims.ExpiresAt.Equal(parsed.ExpiresAt))
+
+	// Другой пользователь в том же токене
+	forged := verification.NewSigner("another-secret-that-is-32-bytes-long").Sign(verification.Claims{ID: "abc", UserID: 8, ExpiresAt: claims.ExpiresAt})
+	payload, _, _ := strings.Cut(forged, ".")
+	_, sig, _ := strings.Cut(token, ".")
+	_, err = signer.Parse(payload+"."+sig, now)
+	assert.ErrorIs(t, err, verification.ErrSignature)
+
+	_, err = signer.Parse("garbage", now)
+	assert.ErrorIs(t, err, verification.ErrMalformed)
+
+	_, err = signer.Parse(token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, verification.ErrExpired)
+}
+
+func TestEmailVerifierSendsSingleUseToken(t *testing.T) {
+	repo := newMemVerificationRepo(models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	var mail bytes.Buffer
+	verifier := newTestVerifier(repo, &mail, time.Hour)
+
+	user := repo.user
+	require.NoError(t, verifier.Send(&user))
+	assert.Contains(t, mail.String(), "To: <john@example.com>")
+	assert.Contains(t, mail.String(), "Subject: Confirm your email address")
+
+	token := lastToken(t, &mail)
+	verified, err := verifier.Verify(token)
+	require.NoError(t, err)
+	assert.NotNil(t, verified.EmailVerifiedAt)
+
+	// Токен одноразовый
+	_, err = verifier.Verify(token)
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+
+	_, err = verifier.Verify(token + "x")
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+}
+
+func TestEmailVerifierRejectsTokenForOldAddress(t *testing.T) {
+	repo := newMemVerificationRepo(models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	var mail bytes.Buffer
+	verifier := newTestVerifier(repo, &mail, time.Hour)
+
+	user := repo.user
+	require.NoError(t, verifier.Send(&user))
+	token := lastToken(t, &mail)
+
+	repo.user.Email = "john.new@example.com"
+	_, err := verifier.Verify(token)
+	assert.ErrorIs(t, err, models.ErrInvalidToken)
+}
+
+func TestEmailVerifierRejectsExpiredToken(t *testing.T) {
+	repo := newMemVerificationRepo(models.User{ID: 1, Name: "John", Email: "john@example.com"})
+	var mail bytes.Buffer
+	verifier := newTestVerifier(repo, &mail, -time.Minute)
+
+	user := repo.user
+	require.NoError(t, verifier.Send(&user))
+	_, err := verifier.Verify(lastToken(t, &mail))
+	assert.ErrorIs(t, err, models.ErrTokenExpired)
+}
+
+func TestWriterMailerRejectsHeaderInjection(t *testing.T) {
+	var out bytes.Buffer
+	m := mailer.NewWriterMailer("User API <no-reply@example.com>", &out)
+
+	err := m.Send(t.Context(), mailer.Message{To: "john@example.com\r\nBcc: spy@example.com", Subject: "Hi", Body: "Hi"})
+	assert.Error(t, err)
+	assert.Zero(t, out.Len())
+}
+
+// blockingMailer запоминает письма, но отправляет их только после
+// закрытия release
+type blockingMailer struct {
+	release chan struct{}
+	mu      sync.Mutex
+	sent    []mailer.Message
+}
+
+func (m *blockingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	select {
+	case <-m.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestUserServiceSendsVerificationInBackground(t *testing.T) {
+	mail := &blockingMailer{release: make(chan struct{})}
+	verifier := service.NewEmailVerifier(
+		newMemVerificationRepo(models.User{ID: 10}),
+		verification.NewSigner(testVerificationSecret),
+		mail,
+		service.EmailVerifierConfig{
+			TokenTTL:    time.Hour,
+			VerifyURL:   "http://localhost:8080/api/v1/verify-email",
+			SendTimeout: time.Minute,
+		},
+	)
+	users := service.NewUserService(&memEmailRepo{emails: map[int]string{}}, verifier, nil)
+
+	// Почтовый сервер не отвечает, но пользователь создается сразу
+	user, err := users.CreateUser(t.Context(), &models.CreateUserRequest{Name: "Bob", Email: "bob@example.com", Age: 30})
+	require.NoError(t, err)
+	assert.Equal(t, 10, user.ID)
+
+	close(mail.release)
+	verifier.Wait()
+	require.Len(t, mail.sent, 1)
+	assert.Equal(t, "bob@example.com", mail.sent[0].To)
+}
+
+func TestUserServiceSendsVerificationOnlyOnEmailChange(t *testing.T) {
+	var mail bytes.Buffer
+	verifier := newTestVerifier(newMemVerificationRepo(models.User{ID: 1}), &mail, time.Hour)
+	users := service.NewUserService(&memEmailRepo{emails: map[int]string{1: "bob@example.com"}}, verifier, nil)
+	ctx := context.Background()
+
+	// Тот же адрес (после нормализации) и изменение без адреса - без письма
+	_, err := users.UpdateUser(ctx, 1, &models.UpdateUserRequest{Email: " Bob@Example.com"})
+	require.NoError(t, err)
+	_, err = users.UpdateUser(ctx, 1, &models.UpdateUserRequest{Name: "Bob Smith"})
+	require.NoError(t, err)
+	verifier.Wait()
+	assert.Empty(t, mail.String())
+
+	_, err = users.UpdateUser(ctx, 1, &models.UpdateUserRequest{Email: "bob@example.org"})
+	require.NoError(t, err)
+	verifier.Wait()
+	assert.Contains(t, mail.String(), "To: <bob@example.org>")
+	assert.NotEmpty(t, lastToken(t, &mail))
+}
+
+func TestVerifyEmailHandler(t *testing.T) {
+	router := setupTestRouter()
+	handler := handlers.NewUserHandler(&mockUserService{})
+	router.GET("/verify-email", handler.VerifyEmailPage)
+	router.POST("/verify-email", handler.VerifyEmail)
+	router.POST("/users/:id/verification", handler.ResendVerification)
+
+	// Открытие ссылки токен не расходует: страница отправляет его POST
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/verify-email?token=valid%22x", nil)
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `const token = "valid\"x";`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/verify-email", strings.NewReader(`{"token":"valid"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	var user models.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &user))
+	assert.NotNil(t, user.EmailVerifiedAt)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/verify-email", strings.NewReader(`{"token":"bad"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Ответы учитывают Accept, как и у остальных запросов пользователей
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/verify-email", strings.NewReader(`{"token":"valid"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/xml")
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+	assert.Contains(t, w.Body.String(), "<user>")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users/2/verification", nil)
+	req.Header.Set("Accept", "application/xml")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/xml")
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users/1/verification", nil)
+	req.Header.Set("Accept", "text/csv")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotAcceptable, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/verify-email", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users/1/verification", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/users/2/verification", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
@@ -0,0 +1,390 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"user-api/internal/events"
+	"user-api/internal/handlers"
+	"user-api/internal/models"
+	"user-api/internal/outbox"
+	"user-api/internal/service"
+	"user-api/internal/webhooks"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memWebhookRepo - WebhookRepository в памяти для тестов доставки
+type memWebhookRepo struct {
+	mu         sync.Mutex
+	webhooks   map[int]*models.Webhook
+	deliveries []*models.WebhookDelivery
+}
+
+func newMemWebhookRepo() *memWebhookRepo {
+	return &memWebhookRepo{webhooks: map[int]*models.Webhook{}}
+}
+
+func (r *memWebhookRepo) Create(webhook *models.Webhook) (*models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	created := *webhook
+	created.ID = len(r.webhooks) + 1
+	r.webhooks[created.ID] = &created
+	result := created
+	return &result, nil
+}
+
+func (r *memWebhookRepo) GetByID(id int) (*models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil, models.ErrWebhookNotFound
+	}
+	result := *webhook
+	return &result, nil
+}
+
+func (r *memWebhookRepo) GetAll() ([]models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []models.Webhook
+	for _, webhook := range r.webhooks {
+		result = append(result, *webhook)
+	}
+	return result, nil
+}
+
+func (r *memWebhookRepo) Update(id int, req *models.UpdateWebhookRequest) (*models.Webhook, error) {
+	return r.GetByID(id)
+}
+
+func (r *memWebhookRepo) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.webhooks[id]; !ok {
+		return models.ErrWebhookNotFound
+	}
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *memWebhookRepo) GetActiveByEvent(eventType string) ([]models.Webhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []models.Webhook
+	for _, webhook := range r.webhooks {
+		for _, e := range webhook.Events {
+			if webhook.Active && e == eventType {
+				result = append(result, *webhook)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (r *memWebhookRepo) CreateDelivery(webhookID int, eventID, eventType string, payload json.RawMessage, redeliveryOf *int64) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if redeliveryOf == nil && delivery.RedeliveryOf == nil && delivery.WebhookID == webhookID && delivery.EventID == eventID {
+			return nil, models.ErrDeliveryExists
+		}
+	}
+	now := time.Now()
+	delivery := &models.WebhookDelivery{
+		ID:            int64(len(r.deliveries) + 1),
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+		RedeliveryOf:  redeliveryOf,
+	}
+	r.deliveries = append(r.deliveries, delivery)
+	result := *delivery
+	return &result, nil
+}
+
+func (r *memWebhookRepo) GetDelivery(webhookID int, id int64) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID && delivery.ID == id {
+			result := *delivery
+			return &result, nil
+		}
+	}
+	return nil, models.ErrDeliveryNotFound
+}
+
+func (r *memWebhookRepo) GetDeliveries(webhookID, page, pageSize int) ([]models.WebhookDelivery, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			result = append(result, *delivery)
+		}
+	}
+	return result, len(result), nil
+}
+
+func (r *memWebhookRepo) ClaimDue(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	var result []models.WebhookDelivery
+	for _, delivery := range r.deliveries {
+		if len(result) == limit {
+			break
+		}
+		if delivery.Status == models.DeliveryPending && !delivery.NextAttemptAt.After(now) {
+			next := now.Add(lease)
+			delivery.NextAttemptAt = &next
+			result = append(result, *delivery)
+		}
+	}
+	return result, nil
+}
+
+func (r *memWebhookRepo) MarkSucceeded(id int64, responseStatus int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery := r.deliveries[id-1]
+	delivery.Status = models.DeliverySucceeded
+	delivery.Attempts++
+	delivery.ResponseStatus = &responseStatus
+	return nil
+}
+
+func (r *memWebhookRepo) MarkFailed(id int64, responseStatus *int, lastErr string, nextAttemptAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delivery := r.deliveries[id-1]
+	delivery.Attempts++
+	delivery.ResponseStatus = responseStatus
+	delivery.LastError = &lastErr
+	delivery.NextAttemptAt = nextAttemptAt
+	if nextAttemptAt == nil {
+		delivery.Status = models.DeliveryDead
+	}
+	return nil
+}
+
+func (r *memWebhookRepo) delivery(id int64) models.WebhookDelivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.deliveries[id-1]
+}
+
+func testDispatcherConfig() webhooks.Config {
+	return webhooks.Config{
+		Workers:      2,
+		BatchSize:    10,
+		PollInterval: 10 * time.Millisecond,
+		Timeout:      time.Second,
+		MaxAttempts:  3,
+		BackoffBase:  time.Millisecond,
+		BackoffMax:   5 * time.Millisecond,
+	}
+}
+
+func TestWebhookSign(t *testing.T) {
+	sig := webhooks.Sign("secret", 1700000000, []byte(`{"a":1}`))
+	assert.Equal(t, sig, webhooks.Sign("secret", 1700000000, []byte(`{"a":1}`)))
+	assert.NotEqual(t, sig, webhooks.Sign("other", 1700000000, []byte(`{"a":1}`)))
+	assert.NotEqual(t, sig, webhooks.Sign("secret", 1700000001, []byte(`{"a":1}`)))
+	assert.Regexp(t, `^sha256=[0-9a-f]{64}$`, sig)
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	base, max := time.Second, 10*time.Second
+
+	assert.GreaterOrEqual(t, webhooks.Backoff(1, base, max), base)
+	assert.GreaterOrEqual(t, webhooks.Backoff(3, base, max), 4*time.Second)
+	assert.Less(t, webhooks.Backoff(3, base, max), 5*time.Second)
+	assert.LessOrEqual(t, webhooks.Backoff(50, base, max), max+max/5)
+}
+
+func TestWebhookDeliveryRetriesUntilSuccess(t *testing.T) {
+	var mu sync.Mutex
+	var calls int
+	var headers http.Header
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		headers = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer receiver.Close()
+
+	repo := newMemWebhookRepo()
+	webhook, _ := repo.Create(&models.Webhook{
+		URL:    receiver.URL,
+		Secret: "0123456789abcdef",
+		Events: []string{string(events.UserCreated)},
+		Active: true,
+	})
+
+	dispatcher := webhooks.NewDispatcher(repo, testDispatcherConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	event := events.New(events.UserCreated, 1, &models.User{ID: 1, Email: "test@example.com"})
+	require.NoError(t, dispatcher.Publish(ctx, event))
+	// Повторно опубликованное событие не создает вторую доставку
+	require.NoError(t, dispatcher.Publish(ctx, event))
+
+	require.Eventually(t, func() bool {
+		return repo.delivery(1).Status == models.DeliverySucceeded
+	}, 2*time.Second, 10*time.Millisecond)
+
+	delivery := repo.delivery(1)
+	assert.Len(t, repo.deliveries, 1)
+	assert.Equal(t, webhook.ID, delivery.WebhookID)
+	assert.Equal(t, 2, delivery.Attempts)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, "user.created", headers.Get(webhooks.HeaderEvent))
+	assert.Equal(t, "1", headers.Get(webhooks.HeaderDelivery))
+
+	var ts int64
+	require.NoError(t, json.Unmarshal([]byte(headers.Get(webhooks.HeaderTimestamp)), &ts))
+	assert.Equal(t, webhooks.Sign("0123456789abcdef", ts, body), headers.Get(webhooks.HeaderSignature))
+}
+
+func TestWebhookDeliveryDeadLetter(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	repo := newMemWebhookRepo()
+	_, _ = repo.Create(&models.Webhook{URL: receiver.URL, Events: []string{"user.deleted"}, Active: true})
+
+	dispatcher := webhooks.NewDispatcher(repo, testDispatcherConfig())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	// На это событие нет подписок
+	require.NoError(t, dispatcher.Publish(ctx, events.New(events.UserCreated, 1, nil)))
+	require.NoError(t, dispatcher.Publish(ctx, events.New(events.UserDeleted, 1, nil)))
+
+	require.Eventually(t, func() bool {
+		return repo.delivery(1).Status == models.DeliveryDead
+	}, 2*time.Second, 10*time.Millisecond)
+
+	delivery := repo.delivery(1)
+	assert.Equal(t, "user.deleted", delivery.EventType)
+	assert.Equal(t, 3, delivery.Attempts)
+	assert.Equal(t, http.StatusInternalServerError, *delivery.ResponseStatus)
+
+	// Повторная отправка создает новую доставку в очереди
+	svc := service.NewWebhookService(repo, dispatcher)
+	redelivery, err := svc.Redeliver(1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), *redelivery.RedeliveryOf)
+	assert.Equal(t, models.DeliveryPending, redelivery.Status)
+}
+
+// unavailableDeliveryRepo отклоняет запись доставок, пока fail = true
+type unavailableDeliveryRepo struct {
+	*memWebhookRepo
+	fail atomic.Bool
+}
+
+func (r *unavailableDeliveryRepo) CreateDelivery(webhookID int, eventID, eventType string, payload json.RawMessage, redeliveryOf *int64) (*models.WebhookDelivery, error) {
+	if r.fail.Load() {
+		return nil, errors.New("database unavailable")
+	}
+	return r.memWebhookRepo.CreateDelivery(webhookID, eventID, eventType, payload, redeliveryOf)
+}
+
+func TestOutboxRelayWaitsForWebhookDeliveries(t *testing.T) {
+	repo := &unavailableDeliveryRepo{memWebhookRepo: newMemWebhookRepo()}
+	_, _ = repo.Create(&models.Webhook{URL: "http://example.com", Events: []string{"user.created"}, Active: true})
+	repo.fail.Store(true)
+
+	outboxRepo := &memOutboxRepo{}
+	outboxRepo.add(t, events.New(events.UserCreated, 1, nil))
+	dispatcher := webhooks.NewDispatcher(repo, testDispatcherConfig())
+	relay := outbox.NewRelay(outboxRepo, dispatcher, outbox.Config{
+		BatchSize:       10,
+		PollInterval:    10 * time.Millisecond,
+		Retention:       time.Hour,
+		CleanupInterval: time.Hour,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go relay.Run(ctx)
+
+	// Пока доставка не записана, событие остается в outbox
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, outboxRepo.unpublished())
+
+	repo.fail.Store(false)
+	require.Eventually(t, func() bool {
+		return outboxRepo.unpublished() == 0
+	}, time.Second, 5*time.Millisecond)
+	repo.mu.Lock()
+	defer repo.mu.Unlock()
+	assert.Len(t, repo.deliveries, 1)
+}
+
+func TestCreateWebhookValidation(t *testing.T) {
+	router := setupTestRouter()
+	handler := handlers.NewWebhookHandler(service.NewWebhookService(newMemWebhookRepo(), nil))
+	router.POST("/webhooks", handler.CreateWebhook)
+	router.GET("/webhooks/:id", handler.GetWebhook)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/webhooks", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(`{"url":"https://example.com/hook","events":["user.exploded"]}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post(`{"url":"https://example.com/hook","events":["user.created"]}`)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.Webhook
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Len(t, created.Secret, 64)
+	assert.True(t, created.Active)
+
+	// Секрет не возвращается при чтении
+	req, _ := http.NewRequest("GET", "/webhooks/1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), created.Secret)
+
+	req, _ = http.NewRequest("GET", "/webhooks/42", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}